@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BlobInfo is output of "blob -info"
+type BlobInfo struct {
+	Kind       string `json:"kind"`
+	Compressed string `json:"compressed,omitempty"`
+	RawLength  int    `json:"rawLength"`
+	Length     int    `json:"length"`
+	Extension  string `json:"extension"`
+}
+
+// Blob runs subcommand "blob": writes decoded blob of field of row to file or stdout, NT fields as UTF-8 text
+func Blob(args []string) error {
+	var pathToBase, table, field, out, format string
+	var row int
+	var raw, info bool
+
+	fs := flag.NewFlagSet("blob", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.StringVar(&table, "t", "", "Table name")
+	fs.IntVar(&row, "n", -1, "Row number")
+	fs.StringVar(&field, "f", "", "Field name, type I or NT")
+	fs.StringVar(&out, "o", "", "Output file, empty - stdout")
+	fs.BoolVar(&raw, "raw", false, "Write blob as stored in base, without decompression and decoding")
+	fs.BoolVar(&info, "info", false, "Print kind and length of blob instead of its content")
+	formatFlag(fs, &format)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if table == "" || field == "" || row < 0 {
+		return usageError(errors.New("Table, row and field are required, use -t, -n and -f"))
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+	if _, err = splitTables(BaseOnec, table); err != nil {
+		return err
+	}
+	f, ok := BaseOnec.TableDescription[table].Fields[field]
+	if !ok || f.FieldType != "I" && f.FieldType != "NT" {
+		return usageError(errors.New(strings.Join([]string{"Field", field, "is not a blob field of table", table}, " ")))
+	}
+	obj := BaseOnec.Rows(table, row, false)
+	if obj.Table == nil || obj.NotExist || obj.Deleted {
+		return errors.New(strings.Join([]string{"Row not found", strconv.Itoa(row)}, " "))
+	}
+
+	blob := onec.DecodeBlob(f.FieldType, BaseOnec.BlobRaw(obj, field))
+	if info {
+		data := BlobInfo{Kind: blob.Kind, Compressed: blob.Compressed, RawLength: len(blob.Raw), Length: len(blob.Data),
+			Extension: onec.BlobExtension(blob.Kind)}
+		return output(os.Stdout, format, data, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "kind: %s\ncompressed: %s\nraw length: %d\nlength: %d\nextension: %s\n",
+				data.Kind, data.Compressed, data.RawLength, data.Length, data.Extension)
+			return err
+		})
+	}
+
+	content := blob.Data
+	switch {
+	case raw:
+		content = blob.Raw
+	case blob.Text != "" || blob.Kind == onec.BlobText:
+		content = []byte(blob.Text)
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(out, content, 0644)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/export"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Exit codes of commands
+const (
+	ExitOK    = 0
+	ExitError = 1 // base can not be read or output can not be written
+	ExitUsage = 2 // unknown command or bad flags
+	ExitFound = 3 // verify found problems, diff found differences
+)
+
+// Command is a subcommand of main.exe
+type Command struct {
+	Run         func(args []string) error
+	Description string
+}
+
+// Execute runs command, error of reading truncated base is returned as error of command
+func (c Command) Execute(args []string) (err error) {
+	defer onec.CatchReadError(&err)
+	return c.Run(args)
+}
+
+// Commands by name, main.exe without command runs "serve"
+var Commands = map[string]Command{
+	"serve":  {Serve, "run web viewer of base"},
+	"info":   {Info, "print summary of base"},
+	"tables": {Tables, "list tables with sizes and number of rows"},
+	"schema": {Schema, "print fields and indexes of tables"},
+	"dump":   {Dump, "print rows of table"},
+	"export": {Export, "write tables to files: " + strings.Join(export.Formats, ", ")},
+	"sqlite": {SQLite, "convert base to SQLite database"},
+	"ddl":    {DDL, "print SQL schema or write migration for another DBMS"},
+	"verify": {Verify, "check structure of base"},
+	"users":  {Users, "list users of base"},
+	"blob":   {Blob, "write blob field of row"},
+	"diff":   {Diff, "compare tables of two bases"},
+}
+
+// ExitCodeError is an error with exit code of main.exe
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+// ExitCode returns exit code for error of command
+func ExitCode(err error) int {
+	var e ExitCodeError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, &e):
+		return e.Code
+	}
+	return ExitError
+}
+
+func usageError(err error) error {
+	return ExitCodeError{Code: ExitUsage, Err: err}
+}
+
+// Usage prints list of commands
+func Usage(w io.Writer) {
+	names := make([]string, 0, len(Commands))
+	for k := range Commands {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "Usage: main.exe <command> [flags], main.exe <command> -h for flags of command")
+	for _, v := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", v, Commands[v].Description)
+	}
+}
+
+// parseFlags parses flags of command, bad flags are usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError(err)
+	}
+	return err
+}
+
+func formatFlag(fs *flag.FlagSet, format *string) {
+	fs.StringVar(format, "format", "text", "Output format: text, json")
+}
+
+// openBase opens base at path, empty path is 1Cv8.1CD in folder of main.exe
+func openBase(path string) (*onec.BaseOnec, error) {
+	CheckFlag(&path)
+	db, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	BaseOnec, err := onec.OpenBaseOnec(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return BaseOnec, nil
+}
+
+// output writes v as indented JSON or as text by text function
+func output(w io.Writer, format string, v interface{}, text func(w io.Writer) error) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "text":
+		return text(w)
+	}
+	return usageError(errors.New(strings.Join([]string{"Unknown format", format}, " ")))
+}
+
+// splitTables splits comma separated list of tables and checks that they exist
+func splitTables(b *onec.BaseOnec, table string) ([]string, error) {
+	if table == "" {
+		return nil, nil
+	}
+	tables := strings.Split(table, ",")
+	for _, t := range tables {
+		if _, ok := b.TableDescription[t]; !ok {
+			return nil, errors.New(strings.Join([]string{"Table not found", t}, " "))
+		}
+	}
+	return tables, nil
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testTable(description string, data interface{}) onectest.Table {
+	return onectest.Table{
+		Name:    "_REFERENCE1",
+		Fields:  []onectest.Field{{Name: "_IDRREF", Type: "B", Length: 16}, {Name: "_DESCRIPTION", Type: "NVC", Length: 25}, {Name: "_DATA", Type: "I"}},
+		Indexes: ",\n{\"_IDRREF\",\"1\",\n{\"_IDRREF\",16}\n}",
+		Rows: []onectest.Row{
+			{Values: map[string]interface{}{"_IDRREF": []byte{1}, "_DESCRIPTION": "Ромашка", "_DATA": data}},
+			{Values: map[string]interface{}{"_IDRREF": []byte{2}, "_DESCRIPTION": description}},
+		},
+	}
+}
+
+// writeBase writes base with table to temporary folder and returns its path
+func writeBase(t *testing.T, name string, tables ...onectest.Table) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := (onectest.Base{Tables: tables}).WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// execute runs command and returns its output and exit code
+func execute(t *testing.T, name string, args []string) (string, int) {
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = out, out
+	err = Commands[name].Execute(args)
+	os.Stdout, os.Stderr = stdout, stderr
+
+	out.Seek(0, io.SeekStart)
+	b, _ := io.ReadAll(out)
+	return string(b), ExitCode(err)
+}
+
+func TestExecute(t *testing.T) {
+	base := writeBase(t, "1Cv8.1CD", testTable("Лютик", "данные"))
+	changed := writeBase(t, "changed.1CD", testTable("Василек", "данные"))
+	broken := writeBase(t, "broken.1CD", testTable("Лютик", onectest.BlobRef{Chunk: 1000, Lenth: 10}))
+
+	testCases := []struct {
+		name     string
+		args     []string
+		code     int
+		contains string
+	}{
+		{"info", []string{"-b", base}, ExitOK, "8.3.8"},
+		{"tables", []string{"-b", base}, ExitOK, "_REFERENCE1"},
+		{"schema", []string{"-b", base, "-t", "_REFERENCE1"}, ExitOK, "_DESCRIPTION"},
+		{"dump", []string{"-b", base, "-t", "_REFERENCE1"}, ExitOK, "Лютик"},
+		{"verify", []string{"-b", base}, ExitOK, ""},
+		{"verify", []string{"-b", broken}, ExitFound, "_DATA"},
+		{"diff", []string{base, base}, ExitOK, ""},
+		{"diff", []string{"-t", "_REFERENCE1", base, changed}, ExitFound, "Василек"},
+		{"info", []string{"-b", filepath.Join(t.TempDir(), "missing.1CD")}, ExitError, ""},
+		{"dump", []string{"-b", base, "-t", "_DOCUMENT9"}, ExitError, ""},
+		{"info", []string{"-unknown"}, ExitUsage, "flag provided but not defined"},
+		{"diff", []string{base}, ExitUsage, "Usage: main.exe diff"},
+		{"serve", []string{"-b", base, "-redirect", "8080"}, ExitUsage, ""},
+		{"info", []string{"-h"}, ExitOK, "-format"},
+	}
+
+	for _, tc := range testCases {
+		out, code := execute(t, tc.name, tc.args)
+		if code != tc.code || !strings.Contains(out, tc.contains) {
+			t.Errorf("%s %v: exit code %d, expected %d, output %q", tc.name, tc.args, code, tc.code, out)
+		}
+	}
+}
+
+func TestExecuteJSON(t *testing.T) {
+	base := writeBase(t, "1Cv8.1CD", testTable("Лютик", "данные"))
+	changed := writeBase(t, "changed.1CD", testTable("Лютик", "данные"), onectest.Table{
+		Name:   "_REFERENCE2",
+		Fields: []onectest.Field{{Name: "_CODE", Type: "NC", Length: 5}},
+	})
+
+	out, code := execute(t, "info", []string{"-b", base, "-format", "json"})
+	var summary struct {
+		Version string `json:"version"`
+		Tables  int    `json:"tables"`
+	}
+	if err := json.Unmarshal([]byte(out), &summary); err != nil || code != ExitOK || summary.Version != "8.3.8.0" || summary.Tables != 1 {
+		t.Errorf("info: exit code %d, %+v %v", code, summary, err)
+	}
+
+	out, code = execute(t, "diff", []string{"-format", "json", base, changed})
+	var diff struct {
+		Schema struct {
+			AddedTables []string `json:"addedTables"`
+		} `json:"schema"`
+	}
+	if err := json.Unmarshal([]byte(out), &diff); err != nil || code != ExitFound || len(diff.Schema.AddedTables) != 1 || diff.Schema.AddedTables[0] != "_REFERENCE2" {
+		t.Errorf("diff: exit code %d, %+v %v\n%s", code, diff, err, out)
+	}
+}
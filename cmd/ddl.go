@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"flag"
+	"github.com/AlekseySP/onec/export"
+	"os"
+	"strings"
+)
+
+// DDL runs subcommand "ddl": prints CREATE TABLE/INDEX script or, with -o, writes schema, data files and load script
+func DDL(args []string) error {
+	var pathToBase, table, dialect, dir string
+	var blobs bool
+
+	fs := flag.NewFlagSet("ddl", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.StringVar(&table, "t", "", "Table name, comma separated list or empty for all tables")
+	fs.StringVar(&dialect, "d", export.PostgreSQL, "SQL dialect: "+strings.Join(export.Dialects, ", "))
+	fs.StringVar(&dir, "o", "", "Output directory for schema, data files and load script, empty - print schema only")
+	fs.BoolVar(&blobs, "blobs", true, "Export blobs to data files")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+
+	tables, err := splitTables(BaseOnec, table)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		return export.DDL(os.Stdout, BaseOnec, tables, dialect)
+	}
+	return export.Migration(BaseOnec, dir, tables, dialect, export.Options{Blobs: blobs})
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"strings"
+)
+
+// Diff runs subcommand "diff": compares tables of two bases and rows of tables -t,
+// exit code is ExitFound when they differ
+func Diff(args []string) error {
+	var table, format string
+	var limit int
+
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.StringVar(&table, "t", "", "Comma separated list of tables to compare rows, rows are matched by _IDRREF or primary index")
+	fs.IntVar(&limit, "limit", onec.DiffRowsLimit, "Limit of listed rows of each table")
+	formatFlag(fs, &format)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: main.exe diff [flags] OldBase NewBase")
+		fs.PrintDefaults()
+	}
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return usageError(errors.New("Two bases are required"))
+	}
+
+	oldBase, err := openBase(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	newBase, err := openBase(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	var tables []string
+	if table != "" {
+		tables = strings.Split(table, ",")
+	}
+	d, err := onec.Diff(oldBase, newBase, tables, limit)
+	if err != nil {
+		return err
+	}
+	err = output(os.Stdout, format, d, func(w io.Writer) error {
+		err := WriteSchemaDiff(w, d.Schema)
+		for _, v := range d.Rows {
+			if err != nil {
+				return err
+			}
+			err = WriteRowsDiff(w, v)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !d.IsEmpty() {
+		return ExitCodeError{Code: ExitFound, Err: errors.New("Bases differ")}
+	}
+	return nil
+}
+
+// WriteSchemaDiff prints difference of tables: "+" added, "-" removed, "~" changed
+func WriteSchemaDiff(w io.Writer, d onec.SchemaDiff) error {
+	for _, v := range d.RemovedTables {
+		fmt.Fprintln(w, "- "+v)
+	}
+	for _, v := range d.AddedTables {
+		fmt.Fprintln(w, "+ "+v)
+	}
+	for _, t := range d.ChangedTables {
+		fmt.Fprintln(w, "~ "+t.Name)
+		for _, v := range t.RemovedFields {
+			fmt.Fprintln(w, "  - "+v)
+		}
+		for _, v := range t.AddedFields {
+			fmt.Fprintln(w, "  + "+v)
+		}
+		for _, v := range t.ChangedFields {
+			fmt.Fprintln(w, strings.Join([]string{"  ~", v.Name, v.Old, "->", v.New}, " "))
+		}
+		if t.IndexesChanged {
+			fmt.Fprintln(w, "  ~ indexes")
+		}
+	}
+	return nil
+}
+
+// WriteRowsDiff prints counts and rows: "+" added, "-" removed, "~" changed with old and new values of fields
+func WriteRowsDiff(w io.Writer, d onec.RowsDiff) error {
+	fmt.Fprintf(w, "%s by %s: added %d, removed %d, changed %d\n", d.Table, strings.Join(d.Key, ","), d.Added, d.Removed, d.Changed)
+	for _, r := range d.Rows {
+		switch r.Kind {
+		case "added":
+			fmt.Fprintf(w, "  + %s (row %d)\n", r.Key, r.NewRow)
+		case "removed":
+			fmt.Fprintf(w, "  - %s (row %d)\n", r.Key, r.OldRow)
+		default:
+			fmt.Fprintf(w, "  ~ %s (row %d -> %d)\n", r.Key, r.OldRow, r.NewRow)
+		}
+		for _, f := range r.Fields {
+			fmt.Fprintf(w, "    %s %s -> %s\n", f.Name, textEscaper.Replace(f.Old), textEscaper.Replace(f.New))
+		}
+	}
+	if d.Truncated {
+		fmt.Fprintln(w, "  ...")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"github.com/AlekseySP/onec/onec"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listFlag is a flag which may be repeated
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// DumpRow is a line of "dump -format json"
+type DumpRow struct {
+	Row    int                    `json:"row"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+var textEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+// Dump runs subcommand "dump": prints live rows of table, text is tab separated with row number in first column
+func Dump(args []string) error {
+	var pathToBase, table, search, format string
+	var from, limit int
+	var blobs bool
+	var where listFlag
+
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.StringVar(&table, "t", "", "Table name")
+	fs.IntVar(&from, "from", 0, "First row number")
+	fs.IntVar(&limit, "n", 0, "Number of rows, 0 - all")
+	fs.Var(&where, "where", "Condition FIELD=expr, expr is null, !null, =value, from..to or substring, may be repeated")
+	fs.StringVar(&search, "search", "", "Substring of any string field")
+	fs.BoolVar(&blobs, "blobs", false, "Read blobs")
+	formatFlag(fs, &format)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if table == "" {
+		return usageError(errors.New("Table name is required, use -t"))
+	}
+	if format != "text" && format != "json" {
+		return usageError(errors.New(strings.Join([]string{"Unknown format", format}, " ")))
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+	if _, err = splitTables(BaseOnec, table); err != nil {
+		return err
+	}
+
+	fields := BaseOnec.TableDescription[table].FieldsName
+	filter := onec.Filter{Search: search}
+	for _, v := range where {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			return usageError(errors.New(strings.Join([]string{"Bad condition", v}, " ")))
+		}
+		if _, ok := BaseOnec.TableDescription[table].Fields[kv[0]]; !ok {
+			return usageError(errors.New(strings.Join([]string{"Field not found", kv[0]}, " ")))
+		}
+		filter.Conditions = append(filter.Conditions, onec.ParseCondition(kv[0], kv[1]))
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+	if format == "text" {
+		w.WriteString("ROW\t" + strings.Join(fields, "\t") + "\n")
+	}
+	count := 0
+	BaseOnec.ScanFilter(table, from, filter, func(obj onec.Object) bool {
+		if format == "json" {
+			row := DumpRow{Row: obj.Number, Fields: make(map[string]interface{}, len(fields))}
+			for _, v := range fields {
+				value := BaseOnec.Value(obj, v, blobs)
+				if t, ok := value.(time.Time); ok {
+					value = onec.FormatValue(t)
+				}
+				row.Fields[v] = value
+			}
+			err = enc.Encode(row)
+		} else {
+			w.WriteString(strconv.Itoa(obj.Number))
+			for _, v := range fields {
+				w.WriteString("\t" + textEscaper.Replace(onec.FormatValue(BaseOnec.Value(obj, v, blobs))))
+			}
+			_, err = w.WriteString("\n")
+		}
+		count++
+		return err == nil && (limit == 0 || count < limit)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
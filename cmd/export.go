@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"flag"
+	"github.com/AlekseySP/onec/export"
+	"strings"
+)
+
+// Export runs subcommand "export": writes one or all tables of base to directory
+func Export(args []string) error {
+	var pathToBase, table, format, dir string
+	var blobs bool
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.StringVar(&table, "t", "", "Table name, comma separated list or empty for all tables")
+	fs.StringVar(&format, "f", "csv", "Format: "+strings.Join(export.Formats, ", "))
+	fs.StringVar(&dir, "o", "export", "Output directory")
+	fs.BoolVar(&blobs, "blobs", false, "Export blobs inline")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+
+	tables, err := splitTables(BaseOnec, table)
+	if err != nil {
+		return err
+	}
+	return export.Dir(BaseOnec, dir, tables, format, export.Options{Blobs: blobs})
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+)
+
+// Info runs subcommand "info": prints summary of base as text or JSON
+func Info(args []string) error {
+	var pathToBase, format string
+
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	formatFlag(fs, &format)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+
+	summary := BaseOnec.Summary()
+	return output(os.Stdout, format, summary, func(w io.Writer) error {
+		return WriteSummary(w, summary)
+	})
+}
+
+// WriteSummary prints summary as lines "name: value"
+func WriteSummary(w io.Writer, s onec.Summary) error {
+	lines := []struct{ name, value interface{} }{
+		{"Format version", s.Version},
+		{"Page size", s.PageSize},
+		{"Pages", s.Pages},
+		{"Free pages", s.FreePages},
+		{"Tables", s.Tables},
+		{"Configuration", s.ConfigName},
+		{"Configuration version", s.ConfigVersion},
+		{"IB version", s.IBVersion},
+		{"Platform version required", s.PlatformVersionRequired},
+		{"Users", s.Users},
+	}
+	for _, v := range lines {
+		_, err := fmt.Fprintf(w, "%s: %v\n", v.name, v.value)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(w, "Largest tables:")
+	for _, t := range s.LargestTables {
+		_, err := fmt.Fprintf(w, "  %s %d (data %d, blob %d, index %d)\n", t.Name, t.Size, t.DataSize, t.BlobSize, t.IndexSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
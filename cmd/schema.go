@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"strings"
+)
+
+type SchemaTable struct {
+	Name        string        `json:"name"`
+	RecordLock  bool          `json:"recordLock"`
+	RowLength   int           `json:"rowLength"`
+	DataOffset  int           `json:"dataOffset"`
+	BlobOffset  int           `json:"blobOffset"`
+	IndexOffset int           `json:"indexOffset"`
+	Fields      []SchemaField `json:"fields"`
+	Indexes     []SchemaIndex `json:"indexes"`
+}
+
+type SchemaField struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Length        int    `json:"length"`
+	Precision     int    `json:"precision"`
+	NullExist     bool   `json:"nullExist"`
+	CaseSensitive bool   `json:"caseSensitive"`
+	Offset        int    `json:"offset"`
+	DataLength    int    `json:"dataLength"`
+}
+
+type SchemaIndex struct {
+	Name    string   `json:"name"`
+	Primary bool     `json:"primary"`
+	Fields  []string `json:"fields"`
+	Lengths []int    `json:"lengths"`
+}
+
+// Schema runs subcommand "schema": prints fields and indexes of tables
+func Schema(args []string) error {
+	var pathToBase, table, format string
+
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.StringVar(&table, "t", "", "Table name, comma separated list or empty for all tables")
+	formatFlag(fs, &format)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+	tables, err := splitTables(BaseOnec, table)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		tables = BaseOnec.TablesName
+	}
+
+	data := make([]SchemaTable, 0, len(tables))
+	for _, v := range tables {
+		t := BaseOnec.TableDescription[v]
+		st := SchemaTable{Name: v, RecordLock: t.RecordLock, RowLength: t.RowLength, DataOffset: t.DataOffset,
+			BlobOffset: t.BlobOffset, IndexOffset: t.IndexOffset, Fields: make([]SchemaField, 0, len(t.FieldsName)), Indexes: make([]SchemaIndex, 0, len(t.Indexes))}
+		for _, name := range t.FieldsName {
+			f := t.Fields[name]
+			st.Fields = append(st.Fields, SchemaField{Name: name, Type: f.FieldType, Length: f.Lenth, Precision: f.Precision,
+				NullExist: f.NullExist, CaseSensitive: f.CaseSensitive, Offset: f.DataFieldOffset, DataLength: f.DataLength})
+		}
+		for _, i := range t.Indexes {
+			st.Indexes = append(st.Indexes, SchemaIndex{Name: i.Name, Primary: i.Primary, Fields: i.Fields, Lengths: i.Lenths})
+		}
+		data = append(data, st)
+	}
+	return output(os.Stdout, format, data, func(w io.Writer) error {
+		for _, t := range data {
+			fmt.Fprintf(w, "%s (record %d bytes, data %d, blob %d, index %d)\n", t.Name, t.RowLength, t.DataOffset, t.BlobOffset, t.IndexOffset)
+			for _, f := range t.Fields {
+				fmt.Fprintf(w, "  %s %s\n", f.Name, onec.FieldDescription(BaseOnec.TableDescription[t.Name].Fields[f.Name]))
+			}
+			for _, i := range t.Indexes {
+				primary := ""
+				if i.Primary {
+					primary = " PRIMARY"
+				}
+				fmt.Fprintf(w, "  INDEX %s%s (%s)\n", i.Name, primary, strings.Join(i.Fields, ", "))
+			}
+			_, err := fmt.Fprintln(w)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
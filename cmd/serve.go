@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/server"
+	"net"
+	"os"
+)
+
+// Environment variables for secrets which should not be seen in list of processes
+const (
+	EnvPassword = "ONEC_PASSWORD"
+	EnvToken    = "ONEC_TOKEN"
+)
+
+// Serve runs subcommand "serve": web viewer of bases, each base at /base/{id}/
+func Serve(args []string) error {
+	var port, bind, allow, deny, mask string
+	var paths listFlag
+	var access server.Access
+	var tls server.TLS
+	var redirectPort string
+	var noRedirect bool
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Var(&paths, "b", "Path to 1CV8.1CD base or folder with *.1CD bases, may be repeated, run in base folder without it")
+	fs.StringVar(&port, "p", "80", "Port of http server, 443 by default with TLS")
+	fs.StringVar(&bind, "bind", "", "Address to listen on, empty - all interfaces, 127.0.0.1 - this computer only")
+	fs.StringVar(&access.User, "user", "", "User of basic authentication, password is -password or "+EnvPassword)
+	fs.StringVar(&access.Password, "password", os.Getenv(EnvPassword), "Password of basic authentication")
+	fs.StringVar(&access.Token, "token", os.Getenv(EnvToken), "Token of bearer authentication (header Authorization: Bearer token)")
+	fs.StringVar(&allow, "allow", "", "Comma separated patterns of shown tables, empty - all tables (_REFERENCE*,_DOCUMENT*)")
+	fs.StringVar(&deny, "deny", "", "Comma separated patterns of hidden tables (V8USERS,_USERSWORKHISTORY)")
+	fs.StringVar(&mask, "mask", server.DefaultMask, "Comma separated patterns TABLE.FIELD of masked fields (*._FLD123)")
+	fs.StringVar(&tls.CertFile, "cert", "", "Certificate file (PEM) for HTTPS")
+	fs.StringVar(&tls.KeyFile, "key", "", "Key file (PEM) for HTTPS")
+	fs.BoolVar(&tls.SelfSigned, "self-signed", false, "HTTPS with generated self-signed certificate, saved to -cert and -key if they are set and do not exist")
+	fs.StringVar(&redirectPort, "redirect", "80", "Port of plain http server which redirects to HTTPS, it is started with -cert and -key or -self-signed")
+	fs.BoolVar(&noRedirect, "no-redirect", false, "Do not start http server which redirects to HTTPS")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	portSet, redirectSet := false, false
+	fs.Visit(func(f *flag.Flag) {
+		portSet = portSet || f.Name == "p"
+		redirectSet = redirectSet || f.Name == "redirect"
+	})
+	if tls.Enabled() {
+		if !portSet {
+			port = "443"
+		}
+		if !noRedirect && redirectPort != "" && redirectPort != port {
+			tls.RedirectAddr = net.JoinHostPort(bind, redirectPort)
+		}
+	} else if redirectSet {
+		return usageError(errors.New("Redirect to HTTPS needs -cert and -key or -self-signed"))
+	}
+	if access.User != "" && access.Password == "" {
+		return usageError(errors.New("Password is required for -user, use -password or " + EnvPassword))
+	}
+	access.Allow, access.Deny, access.Mask = server.ParsePatterns(allow), server.ParsePatterns(deny), server.ParsePatterns(mask)
+	if len(paths) == 0 {
+		var pathToBase string
+		CheckFlag(&pathToBase)
+		paths = append(paths, pathToBase)
+	}
+
+	if access.User == "" && access.Token == "" && !isLoopback(bind) {
+		fmt.Fprintln(os.Stderr, "Warning: bases are available without authentication on all interfaces, use -user or -token, or -bind 127.0.0.1")
+	}
+	return server.StartBases(paths, server.Config{Addr: net.JoinHostPort(bind, port), Access: access, TLS: tls})
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
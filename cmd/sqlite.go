@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"flag"
+	"github.com/AlekseySP/onec/export"
+)
+
+// SQLite runs subcommand "sqlite": converts base to SQLite database
+func SQLite(args []string) error {
+	var pathToBase, table, out string
+	var blobs bool
+
+	fs := flag.NewFlagSet("sqlite", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.StringVar(&table, "t", "", "Table name, comma separated list or empty for all tables")
+	fs.StringVar(&out, "o", "1Cv8.sqlite", "Path to new SQLite database")
+	fs.BoolVar(&blobs, "blobs", true, "Export blobs")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+
+	tables, err := splitTables(BaseOnec, table)
+	if err != nil {
+		return err
+	}
+	return export.SQLite(BaseOnec, out, tables, export.Options{Blobs: blobs})
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+)
+
+// TableInfo is a line of "tables" output, Rows and Deleted are read only with -rows
+type TableInfo struct {
+	Name      string `json:"name"`
+	Fields    int    `json:"fields"`
+	RowLength int    `json:"rowLength"`
+	Rows      int    `json:"rows"`
+	Deleted   int    `json:"deleted,omitempty"`
+	DataSize  uint64 `json:"dataSize"`
+	BlobSize  uint64 `json:"blobSize"`
+	IndexSize uint64 `json:"indexSize"`
+}
+
+// Tables runs subcommand "tables": lists tables of base
+func Tables(args []string) error {
+	var pathToBase, format string
+	var rows bool
+
+	fs := flag.NewFlagSet("tables", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.BoolVar(&rows, "rows", false, "Count live and deleted rows, reads all data of base")
+	formatFlag(fs, &format)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+
+	data := make([]TableInfo, 0, len(BaseOnec.TablesName))
+	for _, v := range BaseOnec.TablesName {
+		t := BaseOnec.TableDescription[v]
+		info := TableInfo{Name: v, Fields: len(t.FieldsName), RowLength: t.RowLength}
+		if rows {
+			ts := BaseOnec.TableStats(v)
+			info.Rows, info.Deleted = ts.Live, ts.Deleted
+			info.DataSize, info.BlobSize, info.IndexSize = ts.DataSize, ts.BlobSize, ts.IndexSize
+		} else {
+			info.Rows = BaseOnec.RowsCount(v)
+			info.DataSize = onec.ObjectLength(BaseOnec, t.DataOffset)
+			info.BlobSize = onec.ObjectLength(BaseOnec, t.BlobOffset)
+			info.IndexSize = onec.ObjectLength(BaseOnec, t.IndexOffset)
+		}
+		data = append(data, info)
+	}
+	return output(os.Stdout, format, data, func(w io.Writer) error {
+		for _, v := range data {
+			_, err := fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\n", v.Name, v.Fields, v.Rows, v.DataSize, v.BlobSize, v.IndexSize)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// UsersTable is the table of users of infobase
+const UsersTable = "V8USERS"
+
+// Users runs subcommand "users": lists users of base, blob fields (DATA with password hashes) are not printed
+func Users(args []string) error {
+	var pathToBase, format string
+
+	fs := flag.NewFlagSet("users", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	formatFlag(fs, &format)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+	t, ok := BaseOnec.TableDescription[UsersTable]
+	if !ok {
+		return errors.New(strings.Join([]string{"Table not found", UsersTable}, " "))
+	}
+
+	var fields []string
+	for _, v := range t.FieldsName {
+		if ft := t.Fields[v].FieldType; ft != "I" && ft != "NT" {
+			fields = append(fields, v)
+		}
+	}
+	users := []map[string]interface{}{}
+	BaseOnec.Scan(UsersTable, 0, false, func(obj onec.Object) bool {
+		user := make(map[string]interface{}, len(fields))
+		for _, v := range fields {
+			value := BaseOnec.Value(obj, v, false)
+			if t, ok := value.(time.Time); ok {
+				value = onec.FormatValue(t)
+			}
+			user[v] = value
+		}
+		users = append(users, user)
+		return true
+	})
+	return output(os.Stdout, format, users, func(w io.Writer) error {
+		_, err := io.WriteString(w, strings.Join(fields, "\t")+"\n")
+		for _, user := range users {
+			values := make([]string, len(fields))
+			for k, v := range fields {
+				values[k] = textEscaper.Replace(onec.FormatValue(user[v]))
+			}
+			_, err = io.WriteString(w, strings.Join(values, "\t")+"\n")
+			if err != nil {
+				return err
+			}
+		}
+		return err
+	})
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Verify runs subcommand "verify": checks structure of base, exit code is ExitFound when problems are found
+func Verify(args []string) error {
+	var pathToBase, table, format string
+
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.StringVar(&pathToBase, "b", "", "Path to 1CV8.1CD base or run in base folder")
+	fs.StringVar(&table, "t", "", "Table name, comma separated list or empty for all tables")
+	formatFlag(fs, &format)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	BaseOnec, err := openBase(pathToBase)
+	if err != nil {
+		return err
+	}
+	tables, err := splitTables(BaseOnec, table)
+	if err != nil {
+		return err
+	}
+
+	problems := BaseOnec.Verify(tables)
+	if problems == nil {
+		problems = []onec.Problem{}
+	}
+	err = output(os.Stdout, format, problems, func(w io.Writer) error {
+		for _, v := range problems {
+			_, err := fmt.Fprintln(w, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return ExitCodeError{Code: ExitFound, Err: errors.New(strconv.Itoa(len(problems)) + " problems found")}
+	}
+	return nil
+}
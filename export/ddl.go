@@ -0,0 +1,366 @@
+package export
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQL dialects of DDL and bulk load scripts
+const (
+	PostgreSQL = "postgres"
+	MSSQL      = "mssql"
+)
+
+// BCP terminators, data of 1C strings may contain tabs and line breaks
+const (
+	BCPFieldTerminator = "|~|"
+	BCPRowTerminator   = "|~~|\n"
+)
+
+var Dialects = []string{PostgreSQL, MSSQL}
+
+// names of tables and fields in client-server mode (MS SQL), file base stores them in upper case
+var sqlWords = map[string]string{
+	"ACC": "Acc", "ACCRG": "AccRg", "ACCRGAT": "AccRgAT", "ACCRGCT": "AccRgCT", "ACCRGED": "AccRgED", "ACCUMRG": "AccumRg",
+	"ACCUMRGT": "AccumRgT", "ACCUMRGTN": "AccumRgTn", "ACCUMRGOPT": "AccumRgOpt", "ACTIVE": "Active", "BPR": "BPr", "BPRPOINTS": "BPrPoints",
+	"CHRC": "Chrc", "CODE": "Code", "CONST": "Const", "CONSTCHNGR": "ConstChngR", "CRG": "CRg", "DATE": "Date", "DESCRIPTION": "Description",
+	"DOCUMENT": "Document", "DOCUMENTJOURNAL": "DocumentJournal", "ENUM": "Enum", "ENUMORDER": "EnumOrder", "FLD": "Fld", "FOLDER": "Folder",
+	"IDRREF": "IDRRef", "INFORG": "InfoRg", "INFORGSL": "InfoRgSL", "INFORGSF": "InfoRgSF", "KEYFIELD": "KeyField", "LINENO": "LineNo",
+	"MARKED": "Marked", "NODE": "Node", "NUMBER": "Number", "NUMBERPREFIX": "NumberPrefix", "OWNERID": "OwnerID", "OWNERIDRREF": "OwnerIDRRef",
+	"PARENTIDRREF": "ParentIDRRef", "PERIOD": "Period", "POSTED": "Posted", "PREDEFINEDID": "PredefinedID", "RECORDERRREF": "RecorderRRef",
+	"RECORDERTREF": "RecorderTRef", "RECORDKIND": "RecordKind", "REFERENCE": "Reference", "REFERENCECHNGR": "ReferenceChngR", "RRREF": "RRRef",
+	"RTREF": "RTRef", "SEQ": "Seq", "SIMPLEKEY": "SimpleKey", "SPLITTER": "Splitter", "TASK": "Task", "TIME": "Time",
+	"USAGEPROFILE": "UsageProfile", "VERSION": "Version", "VT": "VT",
+}
+
+// system tables of infobase
+var sqlSystemTables = map[string]string{
+	"CONFIG": "Config", "CONFIGSAVE": "ConfigSave", "DBSCHEMA": "DBSchema", "FILES": "Files", "IBVERSION": "IBVersion",
+	"PARAMS": "Params", "V8USERS": "v8users", "_YEAROFFSET": "_YearOffset", "_USERSWORKHISTORY": "_UsersWorkHistory",
+}
+
+var (
+	sqlWordPattern  = regexp.MustCompile(`^([A-Z]+)(\d*)$`)
+	sqlPlainPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// SQLName converts name of table or field to name used by 1C in client-server mode:
+// _REFERENCE10_VT25 - _Reference10_VT25 (MS SQL), _reference10_vt25 (PostgreSQL)
+func SQLName(name string, dialect string) string {
+	cased, ok := sqlSystemTables[name]
+	if !ok {
+		parts := strings.Split(name, "_")
+		for k, part := range parts {
+			m := sqlWordPattern.FindStringSubmatch(part)
+			if m == nil {
+				continue
+			}
+			if w, ok := sqlWords[m[1]]; ok {
+				parts[k] = w + m[2]
+			}
+		}
+		cased = strings.Join(parts, "_")
+	}
+	if dialect == PostgreSQL {
+		return strings.ToLower(cased)
+	}
+	return cased
+}
+
+func sqlQuote(name string, dialect string) string {
+	if dialect == MSSQL {
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	if sqlPlainPattern.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func sqlTableName(table string, dialect string) string {
+	if dialect == MSSQL {
+		return "[dbo]." + sqlQuote(SQLName(table, dialect), dialect)
+	}
+	return sqlQuote(SQLName(table, dialect), dialect)
+}
+
+// SQLType maps type of 1C field to column type of dialect
+func SQLType(field onec.Field, dialect string) string {
+	pg := dialect == PostgreSQL
+	n := strconv.Itoa(field.Lenth)
+	switch field.FieldType {
+	case "B":
+		if pg {
+			return "bytea"
+		}
+		return "binary(" + n + ")"
+	case "RV":
+		if pg {
+			return "bytea"
+		}
+		return "binary(16)"
+	case "L":
+		if pg {
+			return "boolean"
+		}
+		return "binary(1)"
+	case "N":
+		return "numeric(" + n + "," + strconv.Itoa(field.Precision) + ")"
+	case "NC":
+		if pg {
+			return "char(" + n + ")"
+		}
+		return "nchar(" + n + ")"
+	case "NVC":
+		if pg {
+			return "varchar(" + n + ")"
+		}
+		return "nvarchar(" + n + ")"
+	case "NT":
+		if pg {
+			return "text"
+		}
+		return "nvarchar(max)"
+	case "I":
+		if pg {
+			return "bytea"
+		}
+		return "varbinary(max)"
+	case "DT":
+		if pg {
+			return "timestamp(0) without time zone"
+		}
+		return "datetime2(0)"
+	}
+	return "bytea"
+}
+
+// CreateTable returns CREATE TABLE and CREATE INDEX statements for table
+func CreateTable(t onec.Table, dialect string) []string {
+	columns := make([]string, 0, len(t.FieldsName))
+	for _, v := range t.FieldsName {
+		f := t.Fields[v]
+		column := sqlQuote(SQLName(v, dialect), dialect) + " " + SQLType(f, dialect)
+		if f.NullExist {
+			column += " NULL"
+		} else {
+			column += " NOT NULL"
+		}
+		columns = append(columns, column)
+	}
+	tableName := sqlTableName(t.Name, dialect)
+	statements := []string{"CREATE TABLE " + tableName + " (\n  " + strings.Join(columns, ",\n  ") + "\n)"}
+
+	for _, index := range t.Indexes {
+		if len(index.Fields) == 0 {
+			continue
+		}
+		fields := make([]string, len(index.Fields))
+		for k, v := range index.Fields {
+			fields[k] = sqlQuote(SQLName(v, dialect), dialect)
+		}
+		create := "CREATE INDEX "
+		if index.Primary {
+			create = "CREATE UNIQUE INDEX "
+			if dialect == MSSQL {
+				create = "CREATE UNIQUE CLUSTERED INDEX "
+			}
+		}
+		indexName := sqlQuote(SQLName(t.Name, dialect)+SQLName(index.Name, dialect), dialect)
+		statements = append(statements, create+indexName+" ON "+tableName+" ("+strings.Join(fields, ", ")+")")
+	}
+	return statements
+}
+
+// DDL writes script of tables (all tables if empty), statements are separated by ";" or "GO" for MS SQL
+func DDL(w io.Writer, b *onec.BaseOnec, tables []string, dialect string) error {
+	if dialect != PostgreSQL && dialect != MSSQL {
+		return errors.New(strings.Join([]string{"Unknown dialect", dialect}, " "))
+	}
+	if len(tables) == 0 {
+		tables = b.TablesName
+	}
+	separator := ";\n\n"
+	if dialect == MSSQL {
+		separator = "\nGO\n\n"
+	}
+	for _, table := range tables {
+		t, ok := b.TableDescription[table]
+		if !ok {
+			return errors.New(strings.Join([]string{"Table not found", table}, " "))
+		}
+		if len(t.FieldsName) == 0 {
+			continue
+		}
+		for _, statement := range CreateTable(t, dialect) {
+			_, err := io.WriteString(w, statement+separator)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BulkData writes rows of table in format of PostgreSQL COPY (text) or BCP character mode (UTF-8)
+func BulkData(w io.Writer, b *onec.BaseOnec, table string, dialect string, opt Options) error {
+	if _, ok := b.TableDescription[table]; !ok {
+		return errors.New(strings.Join([]string{"Table not found", table}, " "))
+	}
+	fieldTerminator, rowTerminator := "\t", "\n"
+	if dialect == MSSQL {
+		fieldTerminator, rowTerminator = BCPFieldTerminator, BCPRowTerminator
+	}
+	fields := b.TableDescription[table].Fields
+	names := b.TableDescription[table].FieldsName
+	record := make([]string, len(names))
+	return rows(b, table, opt, func(obj onec.Object, values []interface{}) error {
+		for k, v := range values {
+			if opt.Blobs && fields[names[k]].FieldType == "I" && v != nil {
+				v = b.BlobRaw(obj, names[k]) // binary data as stored by platform
+			}
+			record[k] = bulkValue(v, fields[names[k]], dialect)
+		}
+		_, err := io.WriteString(w, strings.Join(record, fieldTerminator)+rowTerminator)
+		return err
+	})
+}
+
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// BCPEmptyString is empty string in BCP character mode, empty field is loaded as NULL
+const BCPEmptyString = "\x00"
+
+// SQLEmptyDate replaces dates which are not decoded, they are invalid timestamps for both dialects
+const SQLEmptyDate = "0001-01-01 00:00:00"
+
+func bulkValue(v interface{}, field onec.Field, dialect string) string {
+	pg := dialect == PostgreSQL
+	if v == nil {
+		if pg {
+			return `\N`
+		}
+		return ""
+	}
+	switch v := v.(type) {
+	case json.Number:
+		return string(v)
+	case bool:
+		if pg {
+			return strconv.FormatBool(v)[:1]
+		}
+		if v {
+			return "01"
+		}
+		return "00"
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case []byte:
+		if pg {
+			return `\\x` + hex.EncodeToString(v)
+		}
+		return hex.EncodeToString(v)
+	case string:
+		switch field.FieldType {
+		case "B", "RV": // hex from onec.Value
+			if pg {
+				return `\\x` + v
+			}
+			return v
+		case "DT": // representation of date which is not decoded
+			return SQLEmptyDate
+		}
+		if pg {
+			return copyEscaper.Replace(v)
+		}
+		if v == "" {
+			return BCPEmptyString
+		}
+		return v
+	}
+	return ""
+}
+
+// Migration writes to dir schema.sql, data file of each table and load script:
+// load.sql for psql (\copy) or load.cmd for bcp
+func Migration(b *onec.BaseOnec, dir string, tables []string, dialect string, opt Options) error {
+	if len(tables) == 0 {
+		tables = b.TablesName
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(filepath.Join(dir, "schema.sql"), func(w io.Writer) error {
+		return DDL(w, b, tables, dialect)
+	})
+	if err != nil {
+		return err
+	}
+
+	script := make([]string, 0, len(tables))
+	for _, table := range tables {
+		if len(b.TableDescription[table].FieldsName) == 0 {
+			continue
+		}
+		name := SQLName(table, dialect)
+		var dataFile string
+		if dialect == PostgreSQL {
+			dataFile = name + ".tsv"
+			script = append(script, fmt.Sprintf(`\copy %s FROM '%s'`, sqlTableName(table, dialect), dataFile))
+		} else {
+			dataFile = name + ".dat"
+			script = append(script, fmt.Sprintf(`bcp %%DATABASE%%.dbo.%s in "%s" -S %%SERVER%% -T -c -C 65001 -t "%s" -r "%s"`,
+				name, dataFile, BCPFieldTerminator, strings.TrimSuffix(BCPRowTerminator, "\n")+`\n`))
+		}
+		err = writeFile(filepath.Join(dir, dataFile), func(w io.Writer) error {
+			return BulkData(w, b, table, dialect, opt)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	loadFile, header := "load.sql", "-- psql -d DATABASE -f schema.sql && psql -d DATABASE -f load.sql\n"
+	if dialect == MSSQL {
+		loadFile, header = "load.cmd", "rem sqlcmd -S %SERVER% -d %DATABASE% -i schema.sql, then run this script\r\n"
+	}
+	return writeFile(filepath.Join(dir, loadFile), func(w io.Writer) error {
+		newLine := "\n"
+		if dialect == MSSQL {
+			newLine = "\r\n"
+		}
+		_, err := io.WriteString(w, header+strings.Join(script, newLine)+newLine)
+		return err
+	})
+}
+
+func writeFile(path string, fn func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	err = fn(w)
+	if err != nil {
+		return err
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bytes"
+	"github.com/AlekseySP/onec/onec"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSQLName(t *testing.T) {
+	testCases := []struct {
+		name, mssql, postgres string
+	}{
+		{"_REFERENCE10_VT25", "_Reference10_VT25", "_reference10_vt25"},
+		{"_DOCUMENT5", "_Document5", "_document5"},
+		{"_INFORG12", "_InfoRg12", "_inforg12"},
+		{"_FLD123RREF", "_FLD123RREF", "_fld123rref"},
+		{"_IDRREF", "_IDRRef", "_idrref"},
+		{"V8USERS", "v8users", "v8users"},
+		{"_YEAROFFSET", "_YearOffset", "_yearoffset"},
+		{"_FLD12_TYPE", "_Fld12_TYPE", "_fld12_type"},
+	}
+	for _, tc := range testCases {
+		if v := SQLName(tc.name, MSSQL); v != tc.mssql {
+			t.Errorf("%s: %s, expected %s", tc.name, v, tc.mssql)
+		}
+		if v := SQLName(tc.name, PostgreSQL); v != tc.postgres {
+			t.Errorf("%s: %s, expected %s", tc.name, v, tc.postgres)
+		}
+	}
+}
+
+func TestDDL(t *testing.T) {
+	BO := testBase(t)
+	expected := map[string]string{
+		PostgreSQL: `CREATE TABLE _reference1 (
+  _big numeric(25,3) NOT NULL,
+  _code char(5) NOT NULL,
+  _date timestamp(0) without time zone NOT NULL,
+  _flag boolean NOT NULL,
+  _idrref bytea NOT NULL,
+  _kind varchar(10) NOT NULL,
+  _qty numeric(5,0) NOT NULL,
+  _sum numeric(15,2) NULL,
+  _text text NULL
+);
+
+CREATE UNIQUE INDEX _reference1_idrref ON _reference1 (_idrref);
+
+`,
+		MSSQL: `CREATE TABLE [dbo].[_Reference1] (
+  [_BIG] numeric(25,3) NOT NULL,
+  [_Code] nchar(5) NOT NULL,
+  [_Date] datetime2(0) NOT NULL,
+  [_FLAG] binary(1) NOT NULL,
+  [_IDRRef] binary(16) NOT NULL,
+  [_KIND] nvarchar(10) NOT NULL,
+  [_QTY] numeric(5,0) NOT NULL,
+  [_SUM] numeric(15,2) NULL,
+  [_TEXT] nvarchar(max) NULL
+)
+GO
+
+CREATE UNIQUE CLUSTERED INDEX [_Reference1_IDRRef] ON [dbo].[_Reference1] ([_IDRRef])
+GO
+
+`,
+	}
+	for _, dialect := range Dialects {
+		var buf bytes.Buffer
+		if err := DDL(&buf, BO, nil, dialect); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected[dialect] {
+			t.Errorf("%s:\n%s\nexpected:\n%s", dialect, buf.String(), expected[dialect])
+		}
+	}
+	if err := DDL(&bytes.Buffer{}, BO, nil, "oracle"); err == nil {
+		t.Error("unknown dialect is accepted")
+	}
+	if err := DDL(&bytes.Buffer{}, BO, []string{"_UNKNOWN"}, PostgreSQL); err == nil {
+		t.Error("unknown table is accepted")
+	}
+}
+
+func TestBulkValue(t *testing.T) {
+	text := onec.Field{FieldType: "NVC"}
+	testCases := []struct {
+		v               interface{}
+		field           onec.Field
+		postgres, mssql string
+	}{
+		{nil, text, `\N`, ""},
+		{"a\tb\nc\\d\r", text, `a\tb\nc\\d\r`, "a\tb\nc\\d\r"},
+		{"", text, "", BCPEmptyString},
+		{"0000.00.00 00:00:00", onec.Field{FieldType: "DT"}, SQLEmptyDate, SQLEmptyDate},
+		{"0a1b", onec.Field{FieldType: "B"}, `\\x0a1b`, "0a1b"},
+		{[]byte{1, 255}, onec.Field{FieldType: "I"}, `\\x01ff`, "01ff"},
+		{true, onec.Field{FieldType: "L"}, "t", "01"},
+		{false, onec.Field{FieldType: "L"}, "f", "00"},
+	}
+	for _, tc := range testCases {
+		if v := bulkValue(tc.v, tc.field, PostgreSQL); v != tc.postgres {
+			t.Errorf("%#v: postgres %q, expected %q", tc.v, v, tc.postgres)
+		}
+		if v := bulkValue(tc.v, tc.field, MSSQL); v != tc.mssql {
+			t.Errorf("%#v: mssql %q, expected %q", tc.v, v, tc.mssql)
+		}
+	}
+}
+
+// copyUnescaper reverses escaping of PostgreSQL COPY text format for values of testBase
+var copyUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+
+func TestMigration(t *testing.T) {
+	BO := testBase(t)
+	fields := BO.TableDescription["_REFERENCE1"].FieldsName
+	testCases := []struct {
+		dialect, data, load, script    string
+		fieldTerminator, rowTerminator string
+		value                          func(v string) string // converts value of data file to format of testValues
+	}{
+		{PostgreSQL, "_reference1.tsv", "load.sql", `\copy _reference1 FROM '_reference1.tsv'` + "\n", "\t", "\n", func(v string) string {
+			v = copyUnescaper.Replace(v)
+			switch {
+			case v == `\N`:
+				return ""
+			case v == "t" || v == "f":
+				return map[string]string{"t": "true", "f": "false"}[v]
+			}
+			return strings.TrimPrefix(v, `\x`)
+		}},
+		{MSSQL, "_Reference1.dat", "load.cmd", `bcp %DATABASE%.dbo._Reference1 in "_Reference1.dat" -S %SERVER% -T -c -C 65001 -t "|~|" -r "|~~|\n"` + "\r\n",
+			BCPFieldTerminator, BCPRowTerminator, func(v string) string {
+				if v == "00" || v == "01" {
+					return map[string]string{"01": "true", "00": "false"}[v]
+				}
+				return v
+			}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.dialect, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := Migration(BO, dir, nil, tc.dialect, Options{Blobs: true}); err != nil {
+				t.Fatal(err)
+			}
+			var ddl bytes.Buffer
+			DDL(&ddl, BO, nil, tc.dialect)
+			if schema, err := os.ReadFile(filepath.Join(dir, "schema.sql")); err != nil || string(schema) != ddl.String() {
+				t.Errorf("unexpected schema.sql %q: %v", schema, err)
+			}
+			if load, err := os.ReadFile(filepath.Join(dir, tc.load)); err != nil || !strings.HasSuffix(string(load), tc.script) {
+				t.Errorf("unexpected %s %q: %v", tc.load, load, err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rows []map[string]string
+			for _, line := range strings.SplitAfter(string(data), tc.rowTerminator) {
+				if line == "" {
+					continue
+				}
+				record := strings.Split(strings.TrimSuffix(line, tc.rowTerminator), tc.fieldTerminator)
+				if len(record) != len(fields) {
+					t.Fatalf("%d values in line %q", len(record), line)
+				}
+				row := make(map[string]string, len(fields))
+				for k, v := range record {
+					row[fields[k]] = tc.value(v)
+				}
+				row["_DATE"] = strings.Replace(row["_DATE"], " ", "T", 1)
+				rows = append(rows, row)
+			}
+			checkRows(t, tc.dialect, rows)
+		})
+	}
+}
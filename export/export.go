@@ -0,0 +1,153 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Formats of export
+var Formats = []string{"csv", "jsonl", "xlsx", "parquet"}
+
+type Options struct {
+	Blobs bool // read blobs inline, else blob columns are empty
+}
+
+// Table writes all live rows of table in format (csv, jsonl, xlsx, parquet)
+func Table(w io.Writer, b *onec.BaseOnec, table string, format string, opt Options) error {
+	if _, ok := b.TableDescription[table]; !ok {
+		return errors.New(strings.Join([]string{"Table not found", table}, " "))
+	}
+	switch format {
+	case "csv":
+		return CSV(w, b, table, opt)
+	case "jsonl":
+		return JSONLines(w, b, table, opt)
+	case "xlsx":
+		return XLSX(w, b, table, opt)
+	case "parquet":
+		return Parquet(w, b, table, opt)
+	}
+	return errors.New(strings.Join([]string{"Unknown format", format}, " "))
+}
+
+// Dir writes each table of tables (all tables if empty) to file <dir>/<table>.<format>
+func Dir(b *onec.BaseOnec, dir string, tables []string, format string, opt Options) error {
+	if len(tables) == 0 {
+		tables = b.TablesName
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		err = file(b, filepath.Join(dir, table+"."+format), table, format, opt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func file(b *onec.BaseOnec, path string, table string, format string, opt Options) error {
+	return writeFile(path, func(w io.Writer) error {
+		return Table(w, b, table, format, opt)
+	})
+}
+
+// rows calls fn with typed values of every live row of table, values are in order of FieldsName
+func rows(b *onec.BaseOnec, table string, opt Options, fn func(obj onec.Object, values []interface{}) error) error {
+	var err error
+	fields := b.TableDescription[table].FieldsName
+	values := make([]interface{}, len(fields))
+	b.Scan(table, 0, false, func(obj onec.Object) bool {
+		for k, v := range fields {
+			values[k] = b.Value(obj, v, opt.Blobs)
+		}
+		err = fn(obj, values)
+		return err == nil
+	})
+	return err
+}
+
+// Result writes rows of query result in format (csv, jsonl, xlsx), next returns nil at the end
+func Result(w io.Writer, name string, columns []string, format string, next func() ([]interface{}, error)) error {
+	each := func(fn func(values []interface{}) error) error {
+		for {
+			values, err := next()
+			if err != nil || values == nil {
+				return err
+			}
+			err = fn(values)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	switch format {
+	case "csv":
+		return writeCSV(w, columns, each)
+	case "jsonl":
+		return writeJSONLines(w, columns, each)
+	case "xlsx":
+		return writeXLSX(w, name, columns, each)
+	}
+	return errors.New(strings.Join([]string{"Unknown format", format}, " "))
+}
+
+// tableRows is rows of table for writers of CSV, JSONLines and XLSX
+func tableRows(b *onec.BaseOnec, table string, opt Options) func(fn func(values []interface{}) error) error {
+	return func(fn func(values []interface{}) error) error {
+		return rows(b, table, opt, func(obj onec.Object, values []interface{}) error {
+			return fn(values)
+		})
+	}
+}
+
+func CSV(w io.Writer, b *onec.BaseOnec, table string, opt Options) error {
+	return writeCSV(w, b.TableDescription[table].FieldsName, tableRows(b, table, opt))
+}
+
+func JSONLines(w io.Writer, b *onec.BaseOnec, table string, opt Options) error {
+	return writeJSONLines(w, b.TableDescription[table].FieldsName, tableRows(b, table, opt))
+}
+
+func writeCSV(w io.Writer, columns []string, each func(fn func(values []interface{}) error) error) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(columns)
+	if err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	err = each(func(values []interface{}) error {
+		for k, v := range values {
+			record[k] = onec.FormatValue(v)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSONLines(w io.Writer, columns []string, each func(fn func(values []interface{}) error) error) error {
+	enc := json.NewEncoder(w)
+	return each(func(values []interface{}) error {
+		record := make(map[string]interface{}, len(columns))
+		for k, v := range values {
+			if t, ok := v.(time.Time); ok {
+				v = onec.FormatValue(t)
+			}
+			record[columns[k]] = v
+		}
+		return enc.Encode(record)
+	})
+}
@@ -0,0 +1,244 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRows = 20
+
+// testBase has table _REFERENCE1 with fields of all types and rows 1..testRows, every 5th row is deleted.
+// _SUM of every 3rd row is NULL, _KIND has two values
+func testBase(t *testing.T) *onec.BaseOnec {
+	table := onectest.Table{
+		Name: "_REFERENCE1",
+		Fields: []onectest.Field{
+			{Name: "_IDRREF", Type: "B", Length: 16},
+			{Name: "_KIND", Type: "NVC", Length: 10},
+			{Name: "_CODE", Type: "NC", Length: 5},
+			{Name: "_QTY", Type: "N", Length: 5},
+			{Name: "_SUM", Type: "N", Null: true, Length: 15, Precision: 2},
+			{Name: "_BIG", Type: "N", Length: 25, Precision: 3},
+			{Name: "_DATE", Type: "DT"},
+			{Name: "_FLAG", Type: "L"},
+			{Name: "_TEXT", Type: "NT", Null: true},
+		},
+		Indexes: ",\n{\"_IDRREF\",\"1\",\n{\"_IDRREF\",16}\n}",
+	}
+	for n := 1; n <= testRows; n++ {
+		if n%5 == 0 {
+			table.Rows = append(table.Rows, onectest.Row{Deleted: true})
+			continue
+		}
+		values := map[string]interface{}{
+			"_IDRREF": []byte{byte(n)},
+			"_KIND":   testKind(n),
+			"_CODE":   strconv.Itoa(n),
+			"_QTY":    strconv.Itoa(n),
+			"_BIG":    testBig(n),
+			"_DATE":   testDate(n),
+			"_FLAG":   n%2 == 0,
+			"_TEXT":   "текст " + strconv.Itoa(n),
+		}
+		if n%3 != 0 {
+			values["_SUM"] = testSum(n)
+		}
+		table.Rows = append(table.Rows, onectest.Row{Values: values})
+	}
+	return onectest.Open(t, onectest.Base{Tables: []onectest.Table{table}})
+}
+
+// testLive returns numbers of live rows of testBase
+func testLive() []int {
+	var live []int
+	for n := 1; n <= testRows; n++ {
+		if n%5 != 0 {
+			live = append(live, n)
+		}
+	}
+	return live
+}
+
+func testKind(n int) string {
+	if n%2 == 0 {
+		return "Услуга"
+	}
+	return "Товар"
+}
+
+func testSum(n int) string {
+	return "-" + strconv.Itoa(n) + ".25"
+}
+
+func testBig(n int) string {
+	return strconv.Itoa(n) + "00000000000000000000.125"
+}
+
+func testDate(n int) time.Time {
+	return time.Date(2023, 1, n, 10, 30, 0, 0, time.UTC)
+}
+
+// testValues is row n of testBase as formatted by onec.FormatValue, NULL is empty
+func testValues(n int) map[string]string {
+	id := make([]byte, 16)
+	id[0] = byte(n)
+	values := map[string]string{
+		"_IDRREF": hex.EncodeToString(id),
+		"_KIND":   testKind(n),
+		"_CODE":   strconv.Itoa(n),
+		"_QTY":    strconv.Itoa(n),
+		"_SUM":    "",
+		"_BIG":    testBig(n),
+		"_DATE":   onec.FormatValue(testDate(n)),
+		"_FLAG":   strconv.FormatBool(n%2 == 0),
+		"_TEXT":   "текст " + strconv.Itoa(n),
+	}
+	if n%3 != 0 {
+		values["_SUM"] = testSum(n)
+	}
+	return values
+}
+
+// checkRows compares rows read from export with live rows of testBase
+func checkRows(t *testing.T, format string, rows []map[string]string) {
+	t.Helper()
+	live := testLive()
+	if len(rows) != len(live) {
+		t.Fatalf("%s: %d rows, expected %d", format, len(rows), len(live))
+	}
+	for k, n := range live {
+		expected := testValues(n)
+		if len(rows[k]) != len(expected) {
+			t.Errorf("%s row %d: %v", format, n, rows[k])
+		}
+		for name, v := range expected {
+			if strings.TrimRight(rows[k][name], " ") != v {
+				t.Errorf("%s row %d: %s is %q, expected %q", format, n, name, rows[k][name], v)
+			}
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	BO := testBase(t)
+	var buf bytes.Buffer
+	if err := Table(&buf, BO, "_REFERENCE1", "csv", Options{Blobs: true}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := records[0]
+	if strings.Join(header, ",") != strings.Join(BO.TableDescription["_REFERENCE1"].FieldsName, ",") {
+		t.Fatalf("unexpected header %v", header)
+	}
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for k, v := range record {
+			row[header[k]] = v
+		}
+		rows = append(rows, row)
+	}
+	checkRows(t, "csv", rows)
+}
+
+func TestJSONLines(t *testing.T) {
+	BO := testBase(t)
+	var buf bytes.Buffer
+	if err := Table(&buf, BO, "_REFERENCE1", "jsonl", Options{Blobs: true}); err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		d := json.NewDecoder(strings.NewReader(scanner.Text()))
+		d.UseNumber()
+		var record map[string]interface{}
+		if err := d.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		row := make(map[string]string, len(record))
+		for k, v := range record {
+			row[k] = onec.FormatValue(v)
+		}
+		rows = append(rows, row)
+	}
+	checkRows(t, "jsonl", rows)
+}
+
+func TestXLSX(t *testing.T) {
+	BO := testBase(t)
+	var buf bytes.Buffer
+	if err := Table(&buf, BO, "_REFERENCE1", "xlsx", Options{Blobs: true}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Style  string `xml:"s,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &sheet); err != nil {
+		t.Fatal(err)
+	}
+	fields := BO.TableDescription["_REFERENCE1"].FieldsName
+	var rows []map[string]string
+	for k, r := range sheet.Rows {
+		row := make(map[string]string, len(fields))
+		for _, name := range fields {
+			row[name] = ""
+		}
+		for _, c := range r.Cells {
+			column := fields[c.Ref[0]-'A'] //less than 26 columns
+			v := c.Value
+			switch {
+			case c.Type == "inlineStr":
+				v = c.Inline
+			case c.Type == "b":
+				v = strconv.FormatBool(v == "1")
+			case c.Style == "1":
+				days, _ := strconv.ParseFloat(v, 64)
+				v = onec.FormatValue(excelEpoch.Add(time.Duration(days * 24 * float64(time.Hour))).Round(time.Second))
+			}
+			if k == 0 && v != column {
+				t.Errorf("header %s is %q", c.Ref, v)
+			}
+			row[column] = v
+		}
+		if k > 0 {
+			rows = append(rows, row)
+		}
+	}
+	checkRows(t, "xlsx", rows)
+}
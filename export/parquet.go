@@ -0,0 +1,544 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ParquetRowGroup is the number of rows in one row group, ParquetRowGroupBytes limits size of buffered values.
+// Rows are buffered only within row group, so memory does not grow with size of table
+const (
+	ParquetRowGroup      = 100000
+	ParquetRowGroupBytes = 64 << 20
+)
+
+// parquet physical types, encodings, codecs and pages
+const (
+	parquetBoolean           = 0
+	parquetInt32             = 1
+	parquetInt64             = 2
+	parquetByteArray         = 6
+	parquetFixedLenByteArray = 7
+
+	parquetPlain          = 0
+	parquetPlainDict      = 2
+	parquetRLE            = 3
+	parquetCodecGzip      = 2
+	parquetDataPage       = 0
+	parquetDictionaryPage = 2
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedDecimal         = 5
+	parquetConvertedTimestampMillis = 9
+)
+
+var parquetMagic = []byte("PAR1")
+
+var emptyDate = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// parquetColumn is a column of schema and buffer of values of current row group
+type parquetColumn struct {
+	name       string
+	field      onec.Field
+	physical   int32
+	typeLength int32
+	optional   bool
+	dictionary bool // try dictionary encoding for column chunk
+
+	defined []bool   // definition level of each row
+	values  [][]byte // plain encoded non null values (boolean as one byte)
+	size    int
+}
+
+// ParquetColumn maps type of 1C field to parquet column:
+// N - decimal, DT - timestamp (millis), B and RV - fixed_len_byte_array, NVC/NC/NT - UTF-8 string, I - byte array
+func newParquetColumn(name string, field onec.Field) *parquetColumn {
+	c := &parquetColumn{name: name, field: field, optional: field.NullExist}
+	switch field.FieldType {
+	case "N":
+		precision := onec.Max(field.Lenth, 1)
+		switch {
+		case precision <= 9:
+			c.physical = parquetInt32
+		case precision <= 18:
+			c.physical = parquetInt64
+		default:
+			c.physical = parquetFixedLenByteArray
+			c.typeLength = int32(decimalSize(precision))
+		}
+	case "DT":
+		c.physical = parquetInt64
+	case "L":
+		c.physical = parquetBoolean
+	case "B":
+		c.physical = parquetFixedLenByteArray
+		c.typeLength = int32(onec.Max(field.Lenth, 1))
+		c.dictionary = true
+	case "RV":
+		c.physical = parquetFixedLenByteArray
+		c.typeLength = 16
+	case "NVC", "NC":
+		c.physical = parquetByteArray
+		c.dictionary = true
+	default: // NT, I: blobs are null when not read
+		c.physical = parquetByteArray
+		c.optional = true
+	}
+	return c
+}
+
+// decimalSize returns number of bytes of two's complement integer with precision decimal digits
+func decimalSize(precision int) int {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(precision)), nil)
+	return (max.BitLen() + 1 + 7) / 8
+}
+
+// unscaled converts decimal text to integer value * 10^scale
+func unscaled(number string, scale int) *big.Int {
+	negative := strings.HasPrefix(number, "-")
+	number = strings.TrimPrefix(number, "-")
+	intPart, fracPart := number, ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		intPart, fracPart = number[:i], number[i+1:]
+	}
+	if len(fracPart) < scale {
+		fracPart += strings.Repeat("0", scale-len(fracPart))
+	}
+	n, ok := new(big.Int).SetString(intPart+fracPart[:scale], 10)
+	if !ok {
+		return new(big.Int)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n
+}
+
+// fixedBigEndian encodes n as big-endian two's complement of size bytes
+func fixedBigEndian(n *big.Int, size int) []byte {
+	b := make([]byte, size)
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(8*size)), n)
+	}
+	n.FillBytes(b)
+	return b
+}
+
+// add appends typed value from onec.Value, NULL of required column is stored as zero value
+func (c *parquetColumn) add(v interface{}) {
+	var value []byte
+	switch v := v.(type) {
+	case nil:
+		if !c.optional { // blob which is not read in required column
+			value = c.zero()
+		}
+	case json.Number:
+		n := unscaled(string(v), c.field.Precision)
+		switch c.physical {
+		case parquetInt32:
+			value = binary.LittleEndian.AppendUint32(nil, uint32(int32(n.Int64())))
+		case parquetInt64:
+			value = binary.LittleEndian.AppendUint64(nil, uint64(n.Int64()))
+		default:
+			value = fixedBigEndian(n, int(c.typeLength))
+		}
+	case time.Time:
+		value = binary.LittleEndian.AppendUint64(nil, uint64(v.UnixMilli()))
+	case bool:
+		value = []byte{0}
+		if v {
+			value[0] = 1
+		}
+	case []byte:
+		value = v
+	case string:
+		switch c.field.FieldType {
+		case "B", "RV": // hex from onec.Value
+			value, _ = hex.DecodeString(v)
+		case "DT": // not a date, stored as empty date of 1C
+			value = binary.LittleEndian.AppendUint64(nil, uint64(emptyDate.UnixMilli()))
+		default:
+			value = []byte(v)
+		}
+	}
+	if value == nil && v != nil {
+		value = []byte{}
+	}
+	c.defined = append(c.defined, value != nil)
+	if value != nil {
+		c.values = append(c.values, value)
+		c.size += len(value) + 4
+	}
+}
+
+func (c *parquetColumn) zero() []byte {
+	switch c.physical {
+	case parquetBoolean:
+		return []byte{0}
+	case parquetInt32:
+		return make([]byte, 4)
+	case parquetInt64:
+		return make([]byte, 8)
+	case parquetFixedLenByteArray:
+		return make([]byte, c.typeLength)
+	}
+	return []byte{}
+}
+
+func (c *parquetColumn) reset() {
+	c.defined = c.defined[:0]
+	c.values = c.values[:0]
+	c.size = 0
+}
+
+// plain encodes values in PLAIN encoding
+func (c *parquetColumn) plain(values [][]byte) []byte {
+	var buf bytes.Buffer
+	if c.physical == parquetBoolean {
+		bits := make([]byte, (len(values)+7)/8)
+		for i, v := range values {
+			if v[0] != 0 {
+				bits[i/8] |= 1 << (i % 8)
+			}
+		}
+		return bits
+	}
+	for _, v := range values {
+		if c.physical == parquetByteArray {
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		}
+		buf.Write(v)
+	}
+	return buf.Bytes()
+}
+
+// rleRuns encodes levels (bit width 1) as RLE runs of RLE/bit-packing hybrid
+func rleRuns(levels []bool) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		buf.Write(binary.AppendUvarint(nil, uint64(j-i)<<1))
+		if levels[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// bitPacked encodes indexes as one bit-packed run of RLE/bit-packing hybrid, last group is padded
+func bitPacked(indexes []uint32, bitWidth int) []byte {
+	groups := (len(indexes) + 7) / 8
+	buf := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	packed := make([]byte, groups*bitWidth)
+	bit := 0
+	for _, v := range indexes {
+		for b := 0; b < bitWidth; b++ {
+			if v&(1<<b) != 0 {
+				packed[bit/8] |= 1 << (bit % 8)
+			}
+			bit++
+		}
+	}
+	return append(buf, packed...)
+}
+
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// parquetWriter writes parquet file: row groups of column chunks and footer with metadata
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	name      string
+	columns   []*parquetColumn
+	rows      int
+	numRows   int64
+	rowGroups *thriftWriter // encoded row groups for footer
+	groups    int
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *parquetWriter) writeRow(values []interface{}) error {
+	size := 0
+	for k, v := range values {
+		pw.columns[k].add(v)
+		size += pw.columns[k].size
+	}
+	pw.rows++
+	if pw.rows >= ParquetRowGroup || size >= ParquetRowGroupBytes {
+		return pw.flush()
+	}
+	return nil
+}
+
+// page writes page header and gzip compressed page
+func (pw *parquetWriter) page(pageType int32, data []byte, numValues int, encoding int32) (int64, int64, error) {
+	compressed := gzipBytes(data)
+	t := newThriftWriter()
+	t.i32(1, pageType)
+	t.i32(2, int32(len(data)))
+	t.i32(3, int32(len(compressed)))
+	if pageType == parquetDataPage {
+		t.begin(5)
+		t.i32(1, int32(numValues))
+		t.i32(2, encoding)
+		t.i32(3, parquetRLE)
+		t.i32(4, parquetRLE)
+		t.end()
+	} else {
+		t.begin(7)
+		t.i32(1, int32(numValues))
+		t.i32(2, encoding)
+		t.end()
+	}
+	header := t.bytes()
+	err := pw.write(header)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = pw.write(compressed)
+	return int64(len(header) + len(data)), int64(len(header) + len(compressed)), err
+}
+
+// flush writes buffered rows as row group
+func (pw *parquetWriter) flush() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	type chunk struct {
+		offset, dictOffset, uncompressed, compressed int64
+		dictionary                                   bool
+	}
+	chunks := make([]chunk, len(pw.columns))
+	var total int64
+
+	for k, c := range pw.columns {
+		ch := chunk{offset: pw.offset}
+		var values []byte
+		encoding := int32(parquetPlain)
+
+		if c.dictionary {
+			dict := make(map[string]uint32)
+			entries := make([][]byte, 0)
+			indexes := make([]uint32, len(c.values))
+			dictSize := 0
+			for i, v := range c.values {
+				index, ok := dict[string(v)]
+				if !ok {
+					index = uint32(len(entries))
+					dict[string(v)] = index
+					entries = append(entries, v)
+					dictSize += len(v) + 4
+				}
+				indexes[i] = index
+			}
+			if len(entries) <= len(c.values)/2 && dictSize < 1<<20 {
+				ch.dictionary = true
+				ch.dictOffset = pw.offset
+				u, z, err := pw.page(parquetDictionaryPage, c.plain(entries), len(entries), parquetPlainDict)
+				if err != nil {
+					return err
+				}
+				ch.uncompressed += u
+				ch.compressed += z
+				bitWidth := 1
+				for 1<<bitWidth < len(entries) {
+					bitWidth++
+				}
+				values = append([]byte{byte(bitWidth)}, bitPacked(indexes, bitWidth)...)
+				encoding = parquetPlainDict
+			}
+		}
+		if !ch.dictionary {
+			values = c.plain(c.values)
+		}
+
+		var data []byte
+		if c.optional {
+			levels := rleRuns(c.defined)
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(levels)))
+			data = append(data, levels...)
+		}
+		data = append(data, values...)
+		ch.offset = pw.offset
+		u, z, err := pw.page(parquetDataPage, data, len(c.defined), encoding)
+		if err != nil {
+			return err
+		}
+		ch.uncompressed += u
+		ch.compressed += z
+		total += ch.uncompressed
+		chunks[k] = ch
+	}
+
+	t := pw.rowGroups
+	t.begin(0)
+	t.list(1, thriftStruct, len(pw.columns))
+	for k, c := range pw.columns {
+		ch := chunks[k]
+		first := ch.offset
+		if ch.dictionary {
+			first = ch.dictOffset
+		}
+		t.begin(0)
+		t.i64(2, first)
+		t.begin(3)
+		t.i32(1, c.physical)
+		if ch.dictionary {
+			t.list(2, thriftI32, 3)
+			t.zigzag(parquetPlainDict)
+			t.zigzag(parquetRLE)
+			t.zigzag(parquetPlain)
+		} else {
+			t.list(2, thriftI32, 2)
+			t.zigzag(parquetPlain)
+			t.zigzag(parquetRLE)
+		}
+		t.list(3, thriftBinary, 1)
+		t.binary(0, []byte(c.name))
+		t.i32(4, parquetCodecGzip)
+		t.i64(5, int64(len(c.defined)))
+		t.i64(6, ch.uncompressed)
+		t.i64(7, ch.compressed)
+		t.i64(9, ch.offset)
+		if ch.dictionary {
+			t.i64(11, ch.dictOffset)
+		}
+		t.end()
+		t.end()
+	}
+	t.i64(2, total)
+	t.i64(3, int64(pw.rows))
+	t.end()
+
+	pw.groups++
+	pw.numRows += int64(pw.rows)
+	pw.rows = 0
+	for _, c := range pw.columns {
+		c.reset()
+	}
+	return nil
+}
+
+// close writes last row group and footer
+func (pw *parquetWriter) close() error {
+	err := pw.flush()
+	if err != nil {
+		return err
+	}
+	t := newThriftWriter()
+	t.i32(1, 1)
+	t.list(2, thriftStruct, len(pw.columns)+1)
+	t.begin(0)
+	t.binary(4, []byte(pw.name))
+	t.i32(5, int32(len(pw.columns)))
+	t.end()
+	for _, c := range pw.columns {
+		t.begin(0)
+		t.i32(1, c.physical)
+		if c.physical == parquetFixedLenByteArray {
+			t.i32(2, c.typeLength)
+		}
+		if c.optional {
+			t.i32(3, 1)
+		} else {
+			t.i32(3, 0)
+		}
+		t.binary(4, []byte(c.name))
+		switch c.field.FieldType {
+		case "N":
+			t.i32(6, parquetConvertedDecimal)
+			t.i32(7, int32(c.field.Precision))
+			t.i32(8, int32(onec.Max(c.field.Lenth, 1)))
+			t.begin(10)
+			t.begin(5)
+			t.i32(1, int32(c.field.Precision))
+			t.i32(2, int32(onec.Max(c.field.Lenth, 1)))
+			t.end()
+			t.end()
+		case "DT":
+			t.i32(6, parquetConvertedTimestampMillis)
+			t.begin(10)
+			t.begin(8)
+			t.bool(1, true)
+			t.begin(2)
+			t.begin(1)
+			t.end()
+			t.end()
+			t.end()
+			t.end()
+		case "NVC", "NC", "NT":
+			t.i32(6, parquetConvertedUTF8)
+			t.begin(10)
+			t.begin(1)
+			t.end()
+			t.end()
+		}
+		t.end()
+	}
+	t.i64(3, pw.numRows)
+	t.list(4, thriftStruct, pw.groups)
+	t.buf.Write(pw.rowGroups.buf.Bytes())
+	t.binary(6, []byte("github.com/AlekseySP/onec"))
+	footer := t.bytes()
+
+	err = pw.write(footer)
+	if err != nil {
+		return err
+	}
+	err = pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	if err != nil {
+		return err
+	}
+	return pw.write(parquetMagic)
+}
+
+// Parquet writes all live rows of table to parquet file with gzip compressed pages.
+// Columns are in order of FieldsName, strings and references are dictionary encoded when it is smaller
+func Parquet(w io.Writer, b *onec.BaseOnec, table string, opt Options) error {
+	t := b.TableDescription[table]
+	pw := &parquetWriter{w: w, name: t.Name, rowGroups: &thriftWriter{}}
+	for _, v := range t.FieldsName {
+		pw.columns = append(pw.columns, newParquetColumn(v, t.Fields[v]))
+	}
+	err := pw.write(parquetMagic)
+	if err != nil {
+		return err
+	}
+	err = rows(b, table, opt, func(obj onec.Object, values []interface{}) error {
+		for k, v := range values {
+			if opt.Blobs && pw.columns[k].field.FieldType == "I" && v != nil {
+				values[k] = b.BlobRaw(obj, t.FieldsName[k]) // binary data as stored by platform
+			}
+		}
+		return pw.writeRow(values)
+	})
+	if err != nil {
+		return err
+	}
+	return pw.close()
+}
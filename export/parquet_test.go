@@ -0,0 +1,345 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes structures of thrift compact protocol to maps by id of field, integers are int64
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftTrue:
+		return true
+	case thriftFalse:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		r.pos += n
+		return r.b[r.pos-n : r.pos]
+	case thriftList:
+		h := r.b[r.pos]
+		r.pos++
+		size := int(h >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, size)
+		for k := range list {
+			list[k] = r.value(h & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	panic("unknown thrift type " + strconv.Itoa(int(typ)))
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		h := r.b[r.pos]
+		r.pos++
+		if h == 0 {
+			return fields
+		}
+		if h>>4 == 0 {
+			id = int16(r.zigzag())
+		} else {
+			id += int16(h >> 4)
+		}
+		fields[id] = r.value(h & 0x0f)
+	}
+}
+
+type thriftFields = map[int16]interface{}
+
+// readPage decodes page header at offset and returns header and uncompressed page
+func readPage(t *testing.T, file []byte, offset int64) (thriftFields, []byte) {
+	t.Helper()
+	r := &thriftReader{b: file[offset:]}
+	header := r.structure()
+	zr, err := gzip.NewReader(bytes.NewReader(r.b[r.pos : r.pos+int(header[3].(int64))]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil || int64(len(data)) != header[2].(int64) {
+		t.Fatalf("page at %d: %d bytes, header %v: %v", offset, len(data), header, err)
+	}
+	return header, data
+}
+
+// plainValues decodes count values of PLAIN encoding
+func plainValues(data []byte, physical int64, typeLength int, count int) [][]byte {
+	values := make([][]byte, count)
+	for k := range values {
+		switch physical {
+		case parquetBoolean:
+			values[k] = []byte{data[k/8] >> (k % 8) & 1}
+			continue
+		case parquetInt32:
+			values[k], data = data[:4], data[4:]
+		case parquetInt64:
+			values[k], data = data[:8], data[8:]
+		case parquetByteArray:
+			n := binary.LittleEndian.Uint32(data)
+			values[k], data = data[4:4+n], data[4+n:]
+		default:
+			values[k], data = data[:typeLength], data[typeLength:]
+		}
+	}
+	return values
+}
+
+// readColumn reads column chunk of one data page: definition levels and values, indexes of dictionary are replaced by entries
+func readColumn(t *testing.T, file []byte, meta thriftFields, optional bool, typeLength int) ([]bool, [][]byte) {
+	t.Helper()
+	physical := meta[1].(int64)
+	var dictionary [][]byte
+	if offset, ok := meta[11].(int64); ok {
+		header, data := readPage(t, file, offset)
+		dict := header[7].(thriftFields)
+		if header[1] != int64(parquetDictionaryPage) || dict[2] != int64(parquetPlainDict) {
+			t.Fatalf("unexpected dictionary page %v", header)
+		}
+		dictionary = plainValues(data, physical, typeLength, int(dict[1].(int64)))
+	}
+	header, data := readPage(t, file, meta[9].(int64))
+	page := header[5].(thriftFields)
+	count := int(page[1].(int64))
+	if header[1] != int64(parquetDataPage) || count != int(meta[5].(int64)) {
+		t.Fatalf("unexpected data page %v", header)
+	}
+
+	defined := make([]bool, 0, count)
+	values := 0
+	if optional {
+		size := binary.LittleEndian.Uint32(data)
+		levels := &thriftReader{b: data[4 : 4+size]}
+		for levels.pos < len(levels.b) {
+			run := levels.varint()
+			if run&1 == 1 {
+				t.Fatal("definition levels are expected as RLE runs")
+			}
+			v := levels.b[levels.pos] == 1
+			levels.pos++
+			for k := 0; k < int(run>>1); k++ {
+				defined = append(defined, v)
+			}
+		}
+		data = data[4+size:]
+	} else {
+		for k := 0; k < count; k++ {
+			defined = append(defined, true)
+		}
+	}
+	for _, v := range defined {
+		if v {
+			values++
+		}
+	}
+	if len(defined) != count {
+		t.Fatalf("%d definition levels, expected %d", len(defined), count)
+	}
+
+	if page[2] != int64(parquetPlainDict) {
+		return defined, plainValues(data, physical, typeLength, values)
+	}
+	if dictionary == nil {
+		t.Fatal("dictionary encoded page without dictionary")
+	}
+	bitWidth := int(data[0])
+	r := &thriftReader{b: data[1:]}
+	run := r.varint()
+	if run&1 == 0 || int(run>>1) != (values+7)/8 {
+		t.Fatalf("unexpected bit-packed run %d for %d values", run, values)
+	}
+	packed := r.b[r.pos:]
+	result := make([][]byte, values)
+	for k := range result {
+		index := 0
+		for b := 0; b < bitWidth; b++ {
+			bit := k*bitWidth + b
+			index |= int(packed[bit/8]>>(bit%8)&1) << b
+		}
+		result[k] = dictionary[index]
+	}
+	return defined, result
+}
+
+func TestParquet(t *testing.T) {
+	BO := testBase(t)
+	var buf bytes.Buffer
+	if err := Parquet(&buf, BO, "_REFERENCE1", Options{Blobs: true}); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+	if !bytes.HasPrefix(file, parquetMagic) || !bytes.HasSuffix(file, parquetMagic) {
+		t.Fatal("no magic PAR1")
+	}
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	if size <= 0 || size > len(file)-12 {
+		t.Fatalf("length of footer %d, file %d", size, len(file))
+	}
+	footer := &thriftReader{b: file[len(file)-8-size : len(file)-8]}
+	meta := footer.structure()
+	if footer.pos != size {
+		t.Fatalf("footer of %d bytes, decoded %d", size, footer.pos)
+	}
+	live := testLive()
+	if meta[1] != int64(1) || meta[3] != int64(len(live)) {
+		t.Fatalf("unexpected metadata %v", meta)
+	}
+
+	expected := map[string]struct {
+		physical   int64
+		typeLength int
+		optional   bool
+		converted  int64 // -1 without converted type
+		scale      int64
+		precision  int64
+	}{
+		"_IDRREF": {parquetFixedLenByteArray, 16, false, -1, 0, 0},
+		"_KIND":   {parquetByteArray, 0, false, parquetConvertedUTF8, 0, 0},
+		"_CODE":   {parquetByteArray, 0, false, parquetConvertedUTF8, 0, 0},
+		"_QTY":    {parquetInt32, 0, false, parquetConvertedDecimal, 0, 5},
+		"_SUM":    {parquetInt64, 0, true, parquetConvertedDecimal, 2, 15},
+		"_BIG":    {parquetFixedLenByteArray, 11, false, parquetConvertedDecimal, 3, 25},
+		"_DATE":   {parquetInt64, 0, false, parquetConvertedTimestampMillis, 0, 0},
+		"_FLAG":   {parquetBoolean, 0, false, -1, 0, 0},
+		"_TEXT":   {parquetByteArray, 0, true, parquetConvertedUTF8, 0, 0},
+	}
+	fields := BO.TableDescription["_REFERENCE1"].FieldsName // order of columns
+	schema := meta[2].([]interface{})
+	root := schema[0].(thriftFields)
+	if len(schema) != len(expected)+1 || len(fields) != len(expected) || string(root[4].([]byte)) != "_REFERENCE1" || root[5] != int64(len(expected)) {
+		t.Fatalf("unexpected schema %v", schema)
+	}
+	for k, name := range fields {
+		e := expected[name]
+		c := schema[k+1].(thriftFields)
+		repetition := int64(0)
+		if e.optional {
+			repetition = 1
+		}
+		converted, ok := c[6].(int64)
+		if !ok {
+			converted = -1
+		}
+		if string(c[4].([]byte)) != name || c[1] != e.physical || c[3] != repetition || converted != e.converted {
+			t.Errorf("column %d: unexpected schema element %v, expected %+v", k, c, e)
+		}
+		if e.physical == parquetFixedLenByteArray && c[2] != int64(e.typeLength) {
+			t.Errorf("column %s: type length %v, expected %d", name, c[2], e.typeLength)
+		}
+		if e.converted == parquetConvertedDecimal {
+			decimal := c[10].(thriftFields)[5].(thriftFields)
+			if c[7] != e.scale || c[8] != e.precision || decimal[1] != e.scale || decimal[2] != e.precision {
+				t.Errorf("column %s: scale and precision %v %v, logical type %v", name, c[7], c[8], decimal)
+			}
+		}
+	}
+
+	groups := meta[4].([]interface{})
+	if len(groups) != 1 || groups[0].(thriftFields)[3] != int64(len(live)) {
+		t.Fatalf("unexpected row groups %v", groups)
+	}
+	chunks := groups[0].(thriftFields)[1].([]interface{})
+	if len(chunks) != len(expected) {
+		t.Fatalf("%d column chunks, expected %d", len(chunks), len(expected))
+	}
+	columns := make(map[string][][]byte)
+	for k, name := range fields {
+		e := expected[name]
+		md := chunks[k].(thriftFields)[3].(thriftFields)
+		path := md[3].([]interface{})
+		if md[1] != e.physical || len(path) != 1 || string(path[0].([]byte)) != name || md[4] != int64(parquetCodecGzip) {
+			t.Fatalf("column %s: unexpected metadata %v", name, md)
+		}
+		if _, dict := md[11]; dict != (name == "_KIND") {
+			t.Errorf("column %s: dictionary %v", name, dict)
+		}
+		defined, values := readColumn(t, file, md, e.optional, e.typeLength)
+		for i, n := range live {
+			if defined[i] != (name != "_SUM" || n%3 != 0) {
+				t.Errorf("column %s row %d: defined %v", name, n, defined[i])
+			}
+		}
+		columns[name] = values
+	}
+
+	sums := 0
+	for i, n := range live {
+		if v := columns["_IDRREF"][i]; v[0] != byte(n) || len(v) != 16 {
+			t.Errorf("row %d: _IDRREF %x", n, v)
+		}
+		if v := string(columns["_KIND"][i]); v != testKind(n) {
+			t.Errorf("row %d: _KIND %q", n, v)
+		}
+		if v := int32(binary.LittleEndian.Uint32(columns["_QTY"][i])); v != int32(n) {
+			t.Errorf("row %d: _QTY %d", n, v)
+		}
+		if n%3 != 0 {
+			if v := int64(binary.LittleEndian.Uint64(columns["_SUM"][sums])); v != -int64(n)*100-25 {
+				t.Errorf("row %d: _SUM %d", n, v)
+			}
+			sums++
+		}
+		if v := new(big.Int).SetBytes(columns["_BIG"][i]); v.String() != strings.Replace(testBig(n), ".", "", 1) {
+			t.Errorf("row %d: _BIG %s", n, v)
+		}
+		if v := time.UnixMilli(int64(binary.LittleEndian.Uint64(columns["_DATE"][i]))).UTC(); !v.Equal(testDate(n)) {
+			t.Errorf("row %d: _DATE %v", n, v)
+		}
+		if v := columns["_FLAG"][i][0] == 1; v != (n%2 == 0) {
+			t.Errorf("row %d: _FLAG %v", n, v)
+		}
+		if v := string(columns["_TEXT"][i]); v != "текст "+strconv.Itoa(n) {
+			t.Errorf("row %d: _TEXT %q", n, v)
+		}
+	}
+}
+
+func TestParquetNegativeDecimal(t *testing.T) {
+	for _, tc := range []struct {
+		number string
+		scale  int
+		size   int
+	}{{"-1.5", 2, 11}, {"-0.001", 3, 4}, {"12345678901234567890.12", 2, 11}} {
+		n := unscaled(tc.number, tc.scale)
+		b := fixedBigEndian(n, tc.size)
+		v := new(big.Int).SetBytes(b)
+		if b[0]&0x80 != 0 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*tc.size)))
+		}
+		if v.Cmp(n) != 0 {
+			t.Errorf("%s: decoded %s, expected %s", tc.number, v, n)
+		}
+	}
+}
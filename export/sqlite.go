@@ -0,0 +1,196 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"os"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteBatch is the number of rows inserted in one transaction
+const SQLiteBatch = 10000
+
+// SQLiteMaxNumeric is the number of digits which REAL keeps, longer numbers are stored as TEXT
+const SQLiteMaxNumeric = 15
+
+// SQLiteType maps type of 1C field to SQLite column type
+func SQLiteType(field onec.Field) string {
+	switch field.FieldType {
+	case "NVC", "NC", "NT", "DT":
+		return "TEXT"
+	case "N":
+		if field.Precision == 0 && field.Lenth <= 18 {
+			return "INTEGER"
+		}
+		if field.Lenth > SQLiteMaxNumeric {
+			return "TEXT"
+		}
+		return "NUMERIC"
+	case "L":
+		return "INTEGER"
+	}
+	return "BLOB" // B, RV, I
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// SQLiteCreateTable returns CREATE TABLE and CREATE INDEX statements for table
+func SQLiteCreateTable(t onec.Table) []string {
+	columns := make([]string, 0, len(t.FieldsName))
+	for _, v := range t.FieldsName {
+		f := t.Fields[v]
+		column := quoteIdent(v) + " " + SQLiteType(f)
+		if !f.NullExist {
+			column += " NOT NULL"
+		}
+		columns = append(columns, column)
+	}
+	statements := []string{"CREATE TABLE " + quoteIdent(t.Name) + " (\n  " + strings.Join(columns, ",\n  ") + "\n)"}
+
+	for _, index := range t.Indexes {
+		if len(index.Fields) == 0 {
+			continue
+		}
+		fields := make([]string, len(index.Fields))
+		for k, v := range index.Fields {
+			fields[k] = quoteIdent(v)
+		}
+		create := "CREATE INDEX "
+		if index.Primary {
+			create = "CREATE UNIQUE INDEX "
+		}
+		statements = append(statements, create+quoteIdent(t.Name+"_"+index.Name)+" ON "+quoteIdent(t.Name)+" ("+strings.Join(fields, ", ")+")")
+	}
+	return statements
+}
+
+// sqliteValue converts typed value from onec.Value to value for SQLite driver
+func sqliteValue(v interface{}, field onec.Field) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return string(v) // NUMERIC affinity converts text to INTEGER or REAL, numbers of more than SQLiteMaxNumeric digits are TEXT
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case string:
+		if field.FieldType == "B" || field.FieldType == "RV" { // hex from onec.Value
+			b, err := hex.DecodeString(v)
+			if err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
+
+// SQLite writes tables (all tables if empty) of base to new SQLite database at path.
+// Indexes are created after data is loaded
+func SQLite(b *onec.BaseOnec, path string, tables []string, opt Options) error {
+	if len(tables) == 0 {
+		tables = b.TablesName
+	}
+	if _, err := os.Stat(path); err == nil {
+		return errors.New(strings.Join([]string{"File already exists", path}, " "))
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, pragma := range []string{"PRAGMA journal_mode = OFF", "PRAGMA synchronous = OFF"} {
+		_, err = db.Exec(pragma)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, table := range tables {
+		t, ok := b.TableDescription[table]
+		if !ok {
+			return errors.New(strings.Join([]string{"Table not found", table}, " "))
+		}
+		if len(t.FieldsName) == 0 {
+			continue
+		}
+		statements := SQLiteCreateTable(t)
+		_, err = db.Exec(statements[0])
+		if err != nil {
+			return err
+		}
+		err = sqliteInsert(db, b, t, opt)
+		if err != nil {
+			return err
+		}
+		for _, statement := range statements[1:] {
+			_, err = db.Exec(statement)
+			if err != nil {
+				return errors.New(strings.Join([]string{err.Error(), statement}, " "))
+			}
+		}
+	}
+	return db.Close()
+}
+
+func sqliteInsert(db *sql.DB, b *onec.BaseOnec, t onec.Table, opt Options) error {
+	columns := make([]string, len(t.FieldsName))
+	params := make([]string, len(t.FieldsName))
+	for k, v := range t.FieldsName {
+		columns[k] = quoteIdent(v)
+		params[k] = "?"
+	}
+	insert := "INSERT INTO " + quoteIdent(t.Name) + " (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(params, ", ") + ")"
+
+	var tx *sql.Tx
+	var stmt *sql.Stmt
+	var err error
+	inBatch := 0
+	args := make([]interface{}, len(t.FieldsName))
+
+	err = rows(b, t.Name, opt, func(obj onec.Object, values []interface{}) error {
+		if tx == nil {
+			tx, err = db.Begin()
+			if err != nil {
+				return err
+			}
+			stmt, err = tx.Prepare(insert)
+			if err != nil {
+				return err
+			}
+		}
+		for k, v := range values {
+			args[k] = sqliteValue(v, t.Fields[t.FieldsName[k]])
+			if args[k] == nil && !t.Fields[t.FieldsName[k]].NullExist {
+				args[k] = []byte{} // blob which is not read
+			}
+		}
+		_, err = stmt.Exec(args...)
+		if err != nil {
+			return err
+		}
+		inBatch++
+		if inBatch == SQLiteBatch {
+			inBatch = 0
+			stmt.Close()
+			err, tx = tx.Commit(), nil
+		}
+		return err
+	})
+	if tx != nil {
+		stmt.Close()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSQLite(t *testing.T) {
+	BO := testBase(t)
+	path := filepath.Join(t.TempDir(), "base.db")
+	if err := SQLite(BO, path, nil, Options{Blobs: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SQLite(BO, path, nil, Options{}); err == nil || !strings.Contains(err.Error(), "File already exists") {
+		t.Fatalf("existing file is overwritten: %v", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var index string
+	err = db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = '_REFERENCE1'`).Scan(&index)
+	if err != nil || index != `CREATE UNIQUE INDEX "_REFERENCE1__IDRREF" ON "_REFERENCE1" ("_IDRREF")` {
+		t.Fatalf("unexpected index %q: %v", index, err)
+	}
+
+	rows, err := db.Query(`SELECT _IDRREF, _KIND, _CODE, _QTY, _SUM, _BIG, _DATE, _FLAG, _TEXT,
+		typeof(_IDRREF), typeof(_QTY), typeof(_SUM), typeof(_BIG) FROM _REFERENCE1 ORDER BY rowid`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	live := testLive()
+	k := 0
+	for ; rows.Next(); k++ {
+		var id []byte
+		var kind, code, big, date, text string
+		var qty, flag int64
+		var sum sql.NullFloat64
+		var types [4]string
+		err = rows.Scan(&id, &kind, &code, &qty, &sum, &big, &date, &flag, &text, &types[0], &types[1], &types[2], &types[3])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k >= len(live) {
+			t.Fatalf("more than %d rows", len(live))
+		}
+		n := live[k]
+		expectedSum := "null"
+		if n%3 != 0 {
+			expectedSum = "real"
+		}
+		if strings.Join(types[:], " ") != "blob integer "+expectedSum+" text" {
+			t.Errorf("row %d: types %v", n, types)
+		}
+		if len(id) != 16 || !bytes.Equal(id[:1], []byte{byte(n)}) || kind != testKind(n) || strings.TrimRight(code, " ") != strconv.Itoa(n) || qty != int64(n) {
+			t.Errorf("row %d: %x %q %q %d", n, id, kind, code, qty)
+		}
+		if sum.Valid != (n%3 != 0) || sum.Valid && strconv.FormatFloat(sum.Float64, 'f', -1, 64) != testSum(n) {
+			t.Errorf("row %d: _SUM %v", n, sum)
+		}
+		if big != testBig(n) || date != testDate(n).Format("2006-01-02 15:04:05") || (flag == 1) != (n%2 == 0) || text != "текст "+strconv.Itoa(n) {
+			t.Errorf("row %d: %q %q %d %q", n, big, date, flag, text)
+		}
+	}
+	if k != len(live) {
+		t.Fatalf("%d rows, expected %d", k, len(live))
+	}
+}
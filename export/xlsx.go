@@ -0,0 +1,199 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxXLSXRows is the limit of rows of one sheet, MaxXLSXString is the limit of characters in cell
+const MaxXLSXRows = 1048576
+const MaxXLSXString = 32767
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// styles: 0 - general, 1 - date time
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm:ss"/></numFmts>
+<fonts count="1"><font/></fonts>
+<fills count="1"><fill/></fills>
+<borders count="1"><border/></borders>
+<cellStyleXfs count="1"><xf/></cellStyleXfs>
+<cellXfs count="2"><xf/><xf numFmtId="164" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// XLSX writes table as one sheet workbook, rows are streamed with inline strings
+func XLSX(w io.Writer, b *onec.BaseOnec, table string, opt Options) error {
+	return writeXLSX(w, table, b.TableDescription[table].FieldsName, tableRows(b, table, opt))
+}
+
+func writeXLSX(w io.Writer, name string, columns []string, each func(fn func(values []interface{}) error) error) error {
+	zw := zip.NewWriter(w)
+
+	sheetName := name
+	if len(sheetName) > 31 {
+		sheetName = sheetName[:31]
+	}
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/workbook.xml", fmtWorkbook(sheetName)},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, p.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	sw := bufio.NewWriter(f)
+	sw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(columns))
+	for k, v := range columns {
+		header[k] = v
+	}
+	writeXLSXRow(sw, 1, header)
+
+	row := 1
+	err = each(func(values []interface{}) error {
+		row++
+		if row > MaxXLSXRows {
+			return errors.New("Too many rows for xlsx sheet " + name)
+		}
+		return writeXLSXRow(sw, row, values)
+	})
+	if err != nil {
+		return err
+	}
+	sw.WriteString(`</sheetData></worksheet>`)
+	err = sw.Flush()
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func fmtWorkbook(sheetName string) string {
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName))
+	return fmt.Sprintf(xlsxWorkbook, name.String())
+}
+
+func writeXLSXRow(w *bufio.Writer, row int, values []interface{}) error {
+	r := strconv.Itoa(row)
+	w.WriteString(`<row r="` + r + `">`)
+	for k, v := range values {
+		ref := columnName(k) + r
+		switch v := v.(type) {
+		case nil:
+			continue
+		case json.Number:
+			w.WriteString(`<c r="` + ref + `"><v>` + string(v) + `</v></c>`)
+		case bool:
+			b := "0"
+			if v {
+				b = "1"
+			}
+			w.WriteString(`<c r="` + ref + `" t="b"><v>` + b + `</v></c>`)
+		case time.Time:
+			days := v.Sub(excelEpoch).Hours() / 24
+			if v.Year() < 1900 { //excel does not show dates before 1900
+				writeXLSXString(w, ref, onec.FormatValue(v))
+				continue
+			}
+			w.WriteString(`<c r="` + ref + `" s="1"><v>` + strconv.FormatFloat(days, 'f', -1, 64) + `</v></c>`)
+		default:
+			writeXLSXString(w, ref, onec.FormatValue(v))
+		}
+	}
+	_, err := w.WriteString(`</row>`)
+	return err
+}
+
+func writeXLSXString(w *bufio.Writer, ref string, s string) {
+	w.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+	s = xmlValid(s)
+	if len(s) > MaxXLSXString {
+		if rs := []rune(s); len(rs) > MaxXLSXString {
+			s = string(rs[:MaxXLSXString])
+		}
+	}
+	xml.EscapeText(w, []byte(s))
+	w.WriteString(`</t></is></c>`)
+}
+
+// columnName: 0 - A, 25 - Z, 26 - AA
+func columnName(n int) string {
+	name := ""
+	for n++; n > 0; n = (n - 1) / 26 {
+		name = string(rune('A'+(n-1)%26)) + name
+	}
+	return name
+}
+
+// xmlValid removes characters which are not allowed in XML 1.0
+func xmlValid(s string) string {
+	valid := true
+	for _, r := range s {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			valid = false
+			break
+		}
+	}
+	if valid {
+		return s
+	}
+	rs := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r >= 0x20 || r == '\t' || r == '\n' || r == '\r' {
+			rs = append(rs, r)
+		}
+	}
+	return string(rs)
+}
@@ -1,43 +1,34 @@
 package main
 
 import (
-	"flag"
 	"fmt"
 	"github.com/AlekseySP/onec/cmd"
-	"github.com/AlekseySP/onec/onec"
-	"github.com/AlekseySP/onec/server"
 	"os"
+	"strings"
 )
 
-var flagS string
-var flagI string
-
-func init() {
-	flag.StringVar(&flagS, "b", "", "Path to 1CV8.1CD base or run in base folder")
-	flag.StringVar(&flagI, "p", "80", "Port of http server or 8081 default")
-}
-
 func main() {
 	//debug.SetGCPercent(-1)
-	flag.Parse()
-	cmd.CheckFlag(&flagS)
-
-	db, err := os.Open(flagS)
-	if err != nil {
-		panic("File not exist?")
-		return
+	args := os.Args[1:]
+	name := "serve" // main.exe -b PathToBase -p Port runs server as before commands
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-	defer db.Close()
-
-	BaseOnec, err := onec.OpenBaseOnec(db)
-	if err != nil {
-		fmt.Println(err)
+	if name == "help" {
+		cmd.Usage(os.Stdout)
 		return
 	}
 
-	err = server.Start(BaseOnec, flagI)
-	if err != nil {
-		fmt.Println(err)
-		return
+	command, ok := cmd.Commands[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Unknown command", name)
+		cmd.Usage(os.Stderr)
+		os.Exit(cmd.ExitUsage)
+	}
+	err := command.Execute(args)
+	code := cmd.ExitCode(err)
+	if err != nil && code != cmd.ExitOK {
+		fmt.Fprintln(os.Stderr, err)
 	}
+	os.Exit(code)
 }
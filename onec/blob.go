@@ -0,0 +1,263 @@
+package onec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Kinds of blob content detected by SniffBlob
+const (
+	BlobEmpty        = "empty"
+	BlobText         = "text"
+	BlobBinary       = "binary"
+	BlobDeflate      = "deflate"
+	BlobZlib         = "zlib"
+	BlobContainer    = "container" // 1C container (v8 file), starts with 0x7fffffff
+	BlobValueStorage = "valuestorage"
+	BlobPNG          = "png"
+	BlobJPEG         = "jpeg"
+	BlobGIF          = "gif"
+	BlobBMP          = "bmp"
+	BlobPDF          = "pdf"
+	BlobZip          = "zip"
+)
+
+// MaxInflatedSize limits size of inflated blob, compressed blob which is inflated to larger data is not decompressed,
+// so that a small blob can not take all memory
+var MaxInflatedSize int64 = 256 << 20
+
+var (
+	containerSignature = []byte{0xff, 0xff, 0xff, 0x7f}
+	utf8BOM            = []byte{0xef, 0xbb, 0xbf}
+)
+
+// Blob is a decoded content of NT or I field
+type Blob struct {
+	Kind       string // kind of Data
+	Compressed string // BlobDeflate or BlobZlib if Raw was compressed, else ""
+	Raw        []byte // bytes as stored in base
+	Data       []byte // bytes after decompression
+	Text       string // decoded text for NT fields and textual I fields
+}
+
+// ReadBlob reads blob of table from chunk chunkOffset and cuts it to lenth
+func (BO *BaseOnec) ReadBlob(table Table, chunkOffset uint32, lenth uint32) []byte {
+	if len(table.BlockOfReplacemantBlob) == 0 {
+		return []byte{}
+	}
+	pageSize := BO.HeadDB.PageSize
+	page := uint64(chunkOffset) * uint64(BlobChunkSize) / uint64(pageSize)
+	if page >= uint64(len(table.BlockOfReplacemantBlob)) {
+		return []byte{}
+	}
+	offset := uint64(table.BlockOfReplacemantBlob[page])*uint64(pageSize) + uint64(chunkOffset)*uint64(BlobChunkSize)%uint64(pageSize)
+	rv := ReadBlobStream(BO.Db, offset, pageSize, table.BlockOfReplacemantBlob, nil)
+	if len(rv) < int(lenth) {
+		lenth = uint32(len(rv))
+	}
+	return rv[:lenth]
+}
+
+// DecodeBlob decodes blob of field type NT (UTF-16LE text) or I (binary, sniffed)
+func DecodeBlob(fieldType string, raw []byte) Blob {
+	blob := Blob{Raw: raw, Data: raw}
+
+	if fieldType == "NT" {
+		blob.Kind = BlobText
+		blob.Text = DecodeUTF16LE(raw)
+		return blob
+	}
+
+	kind, data := sniffBlob(raw)
+	if data != nil {
+		blob.Compressed = kind
+		blob.Data = data
+		kind = SniffBlob(data)
+	}
+	blob.Kind = kind
+
+	switch kind {
+	case BlobText, BlobValueStorage:
+		blob.Text = DecodeText(blob.Data)
+	}
+	return blob
+}
+
+// BlobExtension returns file extension for kind of blob
+func BlobExtension(kind string) string {
+	switch kind {
+	case BlobPNG, BlobJPEG, BlobGIF, BlobBMP, BlobPDF, BlobZip:
+		return "." + kind
+	case BlobText, BlobValueStorage:
+		return ".txt"
+	case BlobContainer:
+		return ".cf"
+	}
+	return ".bin"
+}
+
+// BlobContentType returns MIME type for kind of blob
+func BlobContentType(kind string) string {
+	switch kind {
+	case BlobPNG, BlobJPEG, BlobGIF, BlobBMP:
+		return "image/" + kind
+	case BlobPDF, BlobZip:
+		return "application/" + kind
+	case BlobText, BlobValueStorage:
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// DecodeUTF16LE decodes UTF-16LE string, BOM is skipped
+func DecodeUTF16LE(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe {
+		b = b[2:]
+	}
+	value16 := make([]uint16, 0, len(b)/2)
+	for n := 0; n+1 < len(b); n += 2 {
+		value16 = append(value16, binary.LittleEndian.Uint16(b[n:n+2]))
+	}
+	return string(utf16.Decode(value16))
+}
+
+// DecodeText decodes textual blob: UTF-8 (with or without BOM) or UTF-16LE with BOM
+func DecodeText(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, utf8BOM):
+		return string(b[len(utf8BOM):])
+	case len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe, isUTF16Text(b):
+		return DecodeUTF16LE(b)
+	}
+	return string(b)
+}
+
+// Decompress inflates raw deflate or zlib stream up to MaxInflatedSize
+func Decompress(kind string, b []byte) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch kind {
+	case BlobZlib:
+		r, err = zlib.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		r = flate.NewReader(bytes.NewReader(b))
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, MaxInflatedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > MaxInflatedSize {
+		return nil, errors.New(strings.Join([]string{"Inflated blob is larger than", strconv.FormatInt(MaxInflatedSize, 10), "bytes"}, " "))
+	}
+	return data, nil
+}
+
+// SniffBlob detects kind of blob content by signatures
+func SniffBlob(b []byte) string {
+	kind, _ := sniffBlob(b)
+	return kind
+}
+
+// sniffBlob detects kind of blob, compressed blob is inflated to be detected and inflated data is returned, else nil
+func sniffBlob(b []byte) (string, []byte) {
+	switch {
+	case len(b) == 0:
+		return BlobEmpty, nil
+	case bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+		return BlobPNG, nil
+	case bytes.HasPrefix(b, []byte{0xff, 0xd8, 0xff}):
+		return BlobJPEG, nil
+	case bytes.HasPrefix(b, []byte("GIF87a")), bytes.HasPrefix(b, []byte("GIF89a")):
+		return BlobGIF, nil
+	case bytes.HasPrefix(b, []byte("%PDF")):
+		return BlobPDF, nil
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")):
+		return BlobZip, nil
+	case bytes.HasPrefix(b, containerSignature):
+		return BlobContainer, nil
+	case len(b) > 2 && b[0] == 'B' && b[1] == 'M' && len(b) >= 6 && int(binary.LittleEndian.Uint32(b[2:6])) == len(b):
+		return BlobBMP, nil
+	case isValueStorage(b):
+		return BlobValueStorage, nil
+	}
+	if data, ok := inflateZlib(b); ok {
+		return BlobZlib, data
+	}
+	if isText(b) {
+		return BlobText, nil
+	}
+	if data, ok := inflateDeflate(b); ok {
+		return BlobDeflate, data
+	}
+	return BlobBinary, nil
+}
+
+// isValueStorage: serialized value in 1C internal format {"#",<type uuid>,...}
+func isValueStorage(b []byte) bool {
+	b = bytes.TrimPrefix(b, utf8BOM)
+	return bytes.HasPrefix(b, []byte(`{"#",`))
+}
+
+func inflateZlib(b []byte) ([]byte, bool) {
+	if len(b) < 2 || b[0]&0x0f != 8 || b[0]>>4 > 7 {
+		return nil, false
+	}
+	if (uint16(b[0])<<8|uint16(b[1]))%31 != 0 {
+		return nil, false
+	}
+	data, err := Decompress(BlobZlib, b)
+	return data, err == nil
+}
+
+func inflateDeflate(b []byte) ([]byte, bool) {
+	data, err := Decompress(BlobDeflate, b)
+	return data, err == nil && len(data) > 0
+}
+
+func isText(b []byte) bool {
+	if len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe || isUTF16Text(b) {
+		return true
+	}
+	b = bytes.TrimPrefix(b, utf8BOM)
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, c := range b {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// isUTF16Text: UTF-16LE without BOM, every character is printable
+// and most of them are latin or cyrillic
+func isUTF16Text(b []byte) bool {
+	if len(b) < 2 || len(b)%2 != 0 {
+		return false
+	}
+	common := 0
+	runes := []rune(DecodeUTF16LE(b))
+	for _, r := range runes {
+		if r == utf8.RuneError || !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+		if r < 0x0500 {
+			common++
+		}
+	}
+	return common*2 >= len(runes)
+}
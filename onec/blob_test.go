@@ -0,0 +1,138 @@
+package onec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/base64"
+	"testing"
+)
+
+func TestDecodeBlob(t *testing.T) {
+	utf16Text := []byte{31, 4, 64, 4, 56, 4, 50, 4, 53, 4, 66, 4} //Привет
+
+	var deflated bytes.Buffer
+	fw, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	fw.Write([]byte(`{"#",87024738-fc2a-4436-ada1-df79d395c424,{0}}`))
+	fw.Close()
+
+	var zlibbed bytes.Buffer
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	testCases := []struct {
+		name         string
+		fieldType    string
+		value        []byte
+		expectedKind string
+		expectedText string
+		compressed   string
+	}{{
+		name:         "NT",
+		fieldType:    "NT",
+		value:        utf16Text,
+		expectedKind: BlobText,
+		expectedText: "Привет",
+	}, {
+		name:         "I utf16",
+		fieldType:    "I",
+		value:        utf16Text,
+		expectedKind: BlobText,
+		expectedText: "Привет",
+	}, {
+		name:         "I deflate value storage",
+		fieldType:    "I",
+		value:        deflated.Bytes(),
+		expectedKind: BlobValueStorage,
+		expectedText: `{"#",87024738-fc2a-4436-ada1-df79d395c424,{0}}`,
+		compressed:   BlobDeflate,
+	}, {
+		name:         "I zlib",
+		fieldType:    "I",
+		value:        zlibbed.Bytes(),
+		expectedKind: BlobText,
+		expectedText: "hello",
+		compressed:   BlobZlib,
+	}, {
+		name:         "I png",
+		fieldType:    "I",
+		value:        []byte("\x89PNG\r\n\x1a\n\x00\x00"),
+		expectedKind: BlobPNG,
+	}, {
+		name:         "I container",
+		fieldType:    "I",
+		value:        []byte{0xff, 0xff, 0xff, 0x7f, 0, 2, 0, 0},
+		expectedKind: BlobContainer,
+	},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blob := DecodeBlob(tc.fieldType, tc.value)
+			if blob.Kind != tc.expectedKind || blob.Text != tc.expectedText || blob.Compressed != tc.compressed {
+				t.Error(
+					"For", tc.value,
+					"expected", tc.expectedKind, tc.compressed, tc.expectedText,
+					"got", blob.Kind, blob.Compressed, blob.Text,
+				)
+			}
+		})
+	}
+}
+
+func TestDecodeValueStorage(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	inner := `{"#",7ba8b5b5-d2ac-4a8c-9ab3-0d4d7e53b8c5,{1,{#base64:` + base64.StdEncoding.EncodeToString([]byte(png)) + "\r\n}}}"
+
+	var deflated bytes.Buffer
+	fw, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	fw.Write([]byte("\ufeff" + inner))
+	fw.Close()
+
+	vs, err := DecodeValueStorage(deflated.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs.Kind != BlobPNG || string(vs.Data) != png || vs.TypeID != "7ba8b5b5-d2ac-4a8c-9ab3-0d4d7e53b8c5" {
+		t.Error("expected png", "got", vs.Kind, vs.TypeID, vs.Data)
+	}
+
+	vs, err = DecodeValueStorage([]byte(`{"#",4238019d-7e49-4fc9-91db-b6b951d5cf8e,{"a ""b""",1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs.Kind != BlobText || vs.Nested != 0 {
+		t.Error("expected structure", "got", vs.Kind, vs.Nested)
+	}
+
+	if _, err := DecodeValueStorage([]byte("plain text")); err == nil {
+		t.Error("expected error for plain text")
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	defer func(size int64) { MaxInflatedSize = size }(MaxInflatedSize)
+	MaxInflatedSize = 1000
+
+	var small, large bytes.Buffer
+	for _, v := range []struct {
+		buf  *bytes.Buffer
+		size int
+	}{{&small, 1000}, {&large, 1001}} {
+		zw := zlib.NewWriter(v.buf)
+		zw.Write(bytes.Repeat([]byte("a"), v.size))
+		zw.Close()
+	}
+	if data, err := Decompress(BlobZlib, small.Bytes()); err != nil || len(data) != 1000 {
+		t.Errorf("blob of limit: %d bytes, %v", len(data), err)
+	}
+	if _, err := Decompress(BlobZlib, large.Bytes()); err == nil {
+		t.Error("blob over limit is inflated")
+	}
+	if blob := DecodeBlob("I", large.Bytes()); blob.Compressed != "" || len(blob.Data) != large.Len() {
+		t.Errorf("blob over limit is decoded as %s %s of %d bytes", blob.Kind, blob.Compressed, len(blob.Data))
+	}
+	if blob := DecodeBlob("I", small.Bytes()); blob.Compressed != BlobZlib || blob.Kind != BlobText || len(blob.Text) != 1000 {
+		t.Errorf("blob of limit is decoded as %s %s", blob.Kind, blob.Compressed)
+	}
+}
@@ -0,0 +1,175 @@
+package onec
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Container format (v8 file): header of 16 bytes (next free block, default block size, version, reserved),
+// then blocks "\r\nDDDDDDDD SSSSSSSS NNNNNNNN \r\n" with hex size of document, size of block and address of next block.
+// First block is table of contents: addresses of header and data of each entry and 0x7fffffff
+const (
+	containerHeaderSize = 16
+	containerBlockSize  = 31
+	containerEnd        = 0x7fffffff
+)
+
+// ContainerEntry is a file in 1C container
+type ContainerEntry struct {
+	Name     string    `json:"name"`
+	Size     int       `json:"size"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	header   int
+	data     int
+}
+
+// Container is a parsed 1C container (v8 file)
+type Container struct {
+	Entries []ContainerEntry
+	b       []byte
+}
+
+// ParseContainer reads table of contents and headers of entries
+func ParseContainer(b []byte) (Container, error) {
+	c := Container{b: b}
+	if len(b) < containerHeaderSize+containerBlockSize || binary.LittleEndian.Uint32(b[:4]) != containerEnd {
+		return c, errors.New("The format of container is not valid")
+	}
+	toc, err := c.document(containerHeaderSize)
+	if err != nil {
+		return c, err
+	}
+	for k := 0; k+12 <= len(toc); k += 12 {
+		header := int(binary.LittleEndian.Uint32(toc[k : k+4]))
+		data := int(binary.LittleEndian.Uint32(toc[k+4 : k+8]))
+		if header == containerEnd || header == 0 {
+			continue
+		}
+		h, err := c.document(header)
+		if err != nil {
+			return c, err
+		}
+		if len(h) < 20 {
+			return c, errors.New(strings.Join([]string{"The format of container entry header is not valid at", strconv.Itoa(header)}, " "))
+		}
+		entry := ContainerEntry{
+			Name:     containerName(h[20:]),
+			Created:  containerDate(binary.LittleEndian.Uint64(h[:8])),
+			Modified: containerDate(binary.LittleEndian.Uint64(h[8:16])),
+			header:   header,
+			data:     data,
+		}
+		if data != containerEnd {
+			entry.Size, err = c.documentSize(data)
+			if err != nil {
+				return c, err
+			}
+		}
+		c.Entries = append(c.Entries, entry)
+	}
+	return c, nil
+}
+
+// File returns data of entry name, data is inflated when it is compressed
+func (c Container) File(name string) ([]byte, error) {
+	for _, v := range c.Entries {
+		if v.Name != name {
+			continue
+		}
+		if v.data == containerEnd {
+			return []byte{}, nil
+		}
+		data, err := c.document(v.data)
+		if err != nil {
+			return nil, err
+		}
+		if _, inflated := sniffBlob(data); inflated != nil {
+			return inflated, nil
+		}
+		return data, nil
+	}
+	return nil, errors.New(strings.Join([]string{"Entry not found", name}, " "))
+}
+
+// block parses header of block at addr: size of document, size of block and next block
+func (c Container) block(addr int) (int, int, int, error) {
+	if addr < 0 || addr+containerBlockSize > len(c.b) {
+		return 0, 0, 0, errors.New(strings.Join([]string{"Block of container is out of data", strconv.Itoa(addr)}, " "))
+	}
+	h := string(c.b[addr : addr+containerBlockSize])
+	if h[:2] != "\r\n" || h[29:] != "\r\n" {
+		return 0, 0, 0, errors.New(strings.Join([]string{"The format of container block is not valid at", strconv.Itoa(addr)}, " "))
+	}
+	var values [3]int
+	for k := range values {
+		v, err := strconv.ParseUint(h[2+k*9:10+k*9], 16, 32)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		values[k] = int(v)
+	}
+	return values[0], values[1], values[2], nil
+}
+
+func (c Container) documentSize(addr int) (int, error) {
+	size, _, _, err := c.block(addr)
+	return size, err
+}
+
+// document reads document which starts at block addr
+func (c Container) document(addr int) ([]byte, error) {
+	size, _, _, err := c.block(addr)
+	if err != nil {
+		return nil, err
+	}
+	if size > len(c.b) {
+		return nil, errors.New(strings.Join([]string{"Document of container is larger than container at", strconv.Itoa(addr)}, " "))
+	}
+	data := make([]byte, 0, size)
+	for blocks := 0; len(data) < size; blocks++ {
+		_, blockSize, next, err := c.block(addr)
+		if err != nil {
+			return nil, err
+		}
+		start := addr + containerBlockSize
+		n := Min(Min(blockSize, size-len(data)), len(c.b)-start)
+		data = append(data, c.b[start:start+n]...)
+		if next == containerEnd || blocks > len(c.b)/containerBlockSize {
+			break
+		}
+		addr = next
+	}
+	if len(data) < size {
+		return nil, errors.New(strings.Join([]string{"Document of container is truncated at", strconv.Itoa(addr)}, " "))
+	}
+	return data, nil
+}
+
+// containerName decodes UTF-16LE name ending with zero character
+func containerName(b []byte) string {
+	name := make([]uint16, 0, len(b)/2)
+	for k := 0; k+1 < len(b); k += 2 {
+		r := binary.LittleEndian.Uint16(b[k : k+2])
+		if r == 0 {
+			break
+		}
+		name = append(name, r)
+	}
+	return string(utf16.Decode(name))
+}
+
+// containerDate converts date of entry, number of 100 microseconds since 0001-01-01
+func containerDate(v uint64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	start := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := v / (10000 * 86400)
+	rest := time.Duration(v%(10000*86400)) * 100 * time.Microsecond
+	return start.AddDate(0, 0, int(days)).Add(rest)
+}
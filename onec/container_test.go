@@ -0,0 +1,99 @@
+package onec
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// testContainer builds container of files, documents longer than blockSize are split into blocks
+func testContainer(files map[string][]byte, names []string, modified uint64, blockSize int) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xff, 0xff, 0xff, 0x7f, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	document := func(data []byte, blockSize int) uint32 {
+		addr := uint32(b.Len())
+		for k := 0; k == 0 || k < len(data); k += blockSize {
+			part := data[k:Min(k+blockSize, len(data))]
+			next := containerEnd
+			if k+blockSize < len(data) {
+				next = b.Len() + containerBlockSize + len(part)
+			}
+			size := 0
+			if k == 0 {
+				size = len(data)
+			}
+			fmt.Fprintf(&b, "\r\n%08x %08x %08x \r\n", size, len(part), next)
+			b.Write(part)
+		}
+		return addr
+	}
+
+	tocAddr := b.Len()
+	toc := make([]byte, 12*len(names))
+	document(toc, len(toc)) // table of contents is written when addresses are known
+	for k, v := range names {
+		header := make([]byte, 20)
+		binary.LittleEndian.PutUint64(header[0:8], modified)
+		binary.LittleEndian.PutUint64(header[8:16], modified)
+		for _, r := range utf16.Encode([]rune(v)) {
+			header = binary.LittleEndian.AppendUint16(header, r)
+		}
+		header = append(header, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(toc[k*12:], document(header, blockSize))
+		binary.LittleEndian.PutUint32(toc[k*12+4:], document(files[v], blockSize))
+		binary.LittleEndian.PutUint32(toc[k*12+8:], containerEnd)
+	}
+	c := b.Bytes()
+	copy(c[tocAddr+containerBlockSize:], toc)
+	return c
+}
+
+func TestParseContainer(t *testing.T) {
+	var deflated bytes.Buffer
+	fw, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	fw.Write([]byte("module text"))
+	fw.Close()
+
+	files := map[string][]byte{
+		"root":   []byte("0123456789abcdefghijklmnopqrstuvwxyz"),
+		"модуль": deflated.Bytes(),
+	}
+	date := uint64(time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC).Sub(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))/(100*time.Microsecond)) +
+		uint64(730119)*86400*10000 // days from 0001-01-01 to 2000-01-01
+	c, err := ParseContainer(testContainer(files, []string{"root", "модуль"}, date, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Entries) != 2 || c.Entries[0].Name != "root" || c.Entries[1].Name != "модуль" {
+		t.Fatalf("entries %v", c.Entries)
+	}
+	if c.Entries[0].Size != len(files["root"]) {
+		t.Errorf("size %d, expected %d", c.Entries[0].Size, len(files["root"]))
+	}
+	if want := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC); !c.Entries[0].Modified.Equal(want) {
+		t.Errorf("modified %v, expected %v", c.Entries[0].Modified, want)
+	}
+
+	split, err := ParseContainer(testContainer(files, []string{"root"}, 0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := split.File("root")
+	if err != nil || !bytes.Equal(data, files["root"]) {
+		t.Errorf("document of several blocks %q %v", data, err)
+	}
+	data, err = c.File("модуль")
+	if err != nil || string(data) != "module text" {
+		t.Errorf("deflated file %q %v", data, err)
+	}
+	if _, err = c.File("missing"); err == nil {
+		t.Error("missing file is found")
+	}
+	if _, err = ParseContainer([]byte("not a container")); err == nil {
+		t.Error("text is parsed as container")
+	}
+}
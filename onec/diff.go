@@ -0,0 +1,303 @@
+package onec
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffRowsLimit is the default limit of rows listed in RowsDiff
+const DiffRowsLimit = 1000
+
+// KeyField is the reference field of objects, rows of tables are compared by it when it exists
+const KeyField = "_IDRREF"
+
+// SchemaDiff is the difference of tables of two bases
+type SchemaDiff struct {
+	AddedTables   []string    `json:"addedTables"`
+	RemovedTables []string    `json:"removedTables"`
+	ChangedTables []TableDiff `json:"changedTables"`
+}
+
+// BaseDiff is the difference of tables of two bases and of rows of chosen tables
+type BaseDiff struct {
+	Schema SchemaDiff `json:"schema"`
+	Rows   []RowsDiff `json:"rows"`
+}
+
+// IsEmpty is true when bases have the same tables and chosen tables have the same rows
+func (d BaseDiff) IsEmpty() bool {
+	for _, v := range d.Rows {
+		if !v.IsEmpty() {
+			return false
+		}
+	}
+	return d.Schema.IsEmpty()
+}
+
+// Diff compares schema of base a (old) and base b (new) and rows of tables
+func Diff(a *BaseOnec, b *BaseOnec, tables []string, limit int) (BaseDiff, error) {
+	d := BaseDiff{Schema: DiffSchema(a, b), Rows: []RowsDiff{}}
+	for _, v := range tables {
+		rd, err := DiffRows(a, b, v, limit)
+		if err != nil {
+			return d, err
+		}
+		d.Rows = append(d.Rows, rd)
+	}
+	return d, nil
+}
+
+// TableDiff is the difference of fields and indexes of table which exists in both bases
+type TableDiff struct {
+	Name           string        `json:"name"`
+	AddedFields    []string      `json:"addedFields,omitempty"`
+	RemovedFields  []string      `json:"removedFields,omitempty"`
+	ChangedFields  []FieldChange `json:"changedFields,omitempty"`
+	IndexesChanged bool          `json:"indexesChanged,omitempty"`
+}
+
+type FieldChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// IsEmpty is true when bases have the same tables
+func (d SchemaDiff) IsEmpty() bool {
+	return len(d.AddedTables) == 0 && len(d.RemovedTables) == 0 && len(d.ChangedTables) == 0
+}
+
+// DiffSchema compares tables of base a (old) and base b (new)
+func DiffSchema(a *BaseOnec, b *BaseOnec) SchemaDiff {
+	d := SchemaDiff{AddedTables: []string{}, RemovedTables: []string{}, ChangedTables: []TableDiff{}}
+	for _, v := range a.TablesName {
+		if _, ok := b.TableDescription[v]; !ok {
+			d.RemovedTables = append(d.RemovedTables, v)
+		}
+	}
+	for _, v := range b.TablesName {
+		old, ok := a.TableDescription[v]
+		if !ok {
+			d.AddedTables = append(d.AddedTables, v)
+			continue
+		}
+		if td, changed := diffTable(old, b.TableDescription[v]); changed {
+			d.ChangedTables = append(d.ChangedTables, td)
+		}
+	}
+	return d
+}
+
+func diffTable(a Table, b Table) (TableDiff, bool) {
+	td := TableDiff{Name: b.Name}
+	for _, v := range a.FieldsName {
+		if _, ok := b.Fields[v]; !ok {
+			td.RemovedFields = append(td.RemovedFields, v)
+		}
+	}
+	for _, v := range b.FieldsName {
+		old, ok := a.Fields[v]
+		if !ok {
+			td.AddedFields = append(td.AddedFields, v)
+			continue
+		}
+		if FieldDescription(old) != FieldDescription(b.Fields[v]) {
+			td.ChangedFields = append(td.ChangedFields, FieldChange{Name: v, Old: FieldDescription(old), New: FieldDescription(b.Fields[v])})
+		}
+	}
+	td.IndexesChanged = indexesDescription(a.Indexes) != indexesDescription(b.Indexes)
+	changed := len(td.AddedFields) > 0 || len(td.RemovedFields) > 0 || len(td.ChangedFields) > 0 || td.IndexesChanged
+	return td, changed
+}
+
+// FieldDescription: type, length, precision and flags of field as "N(10,2) NULL CS"
+func FieldDescription(f Field) string {
+	s := f.FieldType + "(" + strconv.Itoa(f.Lenth) + "," + strconv.Itoa(f.Precision) + ")"
+	if f.NullExist {
+		s += " NULL"
+	}
+	if f.CaseSensitive {
+		s += " CS"
+	}
+	return s
+}
+
+func indexesDescription(indexes []Index) string {
+	parts := make([]string, 0, len(indexes))
+	for _, v := range indexes {
+		fields := make([]string, len(v.Fields))
+		for k, f := range v.Fields {
+			fields[k] = f + ":" + strconv.Itoa(v.Lenths[k])
+		}
+		parts = append(parts, v.Name+" "+strconv.FormatBool(v.Primary)+" "+strings.Join(fields, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// RowsDiff is the difference of rows of table which exists in both bases, rows are matched by Key fields.
+// Counts are complete, lists of rows are cut to limit
+type RowsDiff struct {
+	Table     string      `json:"table"`
+	Key       []string    `json:"key"`
+	Added     int         `json:"added"`
+	Removed   int         `json:"removed"`
+	Changed   int         `json:"changed"`
+	Rows      []RowChange `json:"rows"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// RowChange: Kind is "added", "removed" or "changed", row number is -1 in base without row
+type RowChange struct {
+	Kind   string        `json:"kind"`
+	Key    string        `json:"key"`
+	OldRow int           `json:"oldRow"`
+	NewRow int           `json:"newRow"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// IsEmpty is true when rows of table are the same
+func (d RowsDiff) IsEmpty() bool {
+	return d.Added == 0 && d.Removed == 0 && d.Changed == 0
+}
+
+type diffRow struct {
+	row  int
+	sum  [sha1.Size]byte
+	seen bool
+}
+
+// DiffKey returns fields which identify rows of table: _IDRREF or fields of primary index
+func DiffKey(t Table) ([]string, error) {
+	if _, ok := t.Fields[KeyField]; ok {
+		return []string{KeyField}, nil
+	}
+	for _, v := range t.Indexes {
+		if v.Primary && len(v.Fields) > 0 {
+			return v.Fields, nil
+		}
+	}
+	return nil, errors.New(strings.Join([]string{"Table", t.Name, "has no", KeyField, "and no primary index"}, " "))
+}
+
+// DiffRows compares live rows of table in base a (old) and base b (new) by key of table in b.
+// Only fields of both tables are compared, blobs are compared by content. Rows of a are kept in memory as hashes
+func DiffRows(a *BaseOnec, b *BaseOnec, table string, limit int) (RowsDiff, error) {
+	d := RowsDiff{Table: table, Rows: []RowChange{}}
+	ta, ok := a.TableDescription[table]
+	tb, okb := b.TableDescription[table]
+	if !ok || !okb {
+		return d, errors.New(strings.Join([]string{"Table not found in both bases", table}, " "))
+	}
+	key, err := DiffKey(tb)
+	if err != nil {
+		return d, err
+	}
+	for _, v := range key {
+		if _, ok := ta.Fields[v]; !ok {
+			return d, errors.New(strings.Join([]string{"Key field", v, "not found in old table", table}, " "))
+		}
+	}
+	d.Key = key
+	var fields []string
+	for _, v := range tb.FieldsName {
+		if _, ok := ta.Fields[v]; ok {
+			fields = append(fields, v)
+		}
+	}
+
+	add := func(c RowChange) {
+		if len(d.Rows) < limit {
+			d.Rows = append(d.Rows, c)
+		} else {
+			d.Truncated = true
+		}
+	}
+
+	old := make(map[string]*diffRow)
+	a.Scan(table, 0, false, func(obj Object) bool {
+		old[a.diffKey(obj, key)] = &diffRow{row: obj.Number, sum: a.diffSum(obj, fields)}
+		return true
+	})
+	b.Scan(table, 0, false, func(obj Object) bool {
+		k := b.diffKey(obj, key)
+		r, ok := old[k]
+		if !ok {
+			d.Added++
+			add(RowChange{Kind: "added", Key: k, OldRow: -1, NewRow: obj.Number})
+			return true
+		}
+		r.seen = true
+		if r.sum == b.diffSum(obj, fields) {
+			return true
+		}
+		d.Changed++
+		if len(d.Rows) >= limit {
+			d.Truncated = true
+			return true
+		}
+		prev := a.Rows(table, r.row, false)
+		c := RowChange{Kind: "changed", Key: k, OldRow: r.row, NewRow: obj.Number}
+		for _, v := range fields {
+			if o, n := a.diffValue(prev, v), b.diffValue(obj, v); o != n {
+				c.Fields = append(c.Fields, FieldChange{Name: v, Old: o, New: n})
+			}
+		}
+		add(c)
+		return true
+	})
+
+	removed := make([]RowChange, 0)
+	for k, r := range old {
+		if !r.seen {
+			d.Removed++
+			removed = append(removed, RowChange{Kind: "removed", Key: k, OldRow: r.row, NewRow: -1})
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].OldRow < removed[j].OldRow })
+	for _, v := range removed {
+		add(v)
+	}
+	return d, nil
+}
+
+func (BO *BaseOnec) diffKey(obj Object, key []string) string {
+	values := make([]string, len(key))
+	for k, v := range key {
+		values[k] = BO.diffValue(obj, v)
+	}
+	return strings.Join(values, ",")
+}
+
+func (BO *BaseOnec) diffSum(obj Object, fields []string) [sha1.Size]byte {
+	h := sha1.New()
+	for _, v := range fields {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+		h.Write([]byte(BO.diffValue(obj, v)))
+		h.Write([]byte{0})
+	}
+	var sum [sha1.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// diffValue is value of field to compare: NULL, text of value or length and hash of blob
+func (BO *BaseOnec) diffValue(obj Object, name string) string {
+	if f := obj.Table.Fields[name]; f.FieldType == "I" || f.FieldType == "NT" {
+		raw := BO.BlobRaw(obj, name)
+		if raw == nil {
+			return "NULL"
+		}
+		sum := sha1.Sum(raw)
+		return "blob " + strconv.Itoa(len(raw)) + " sha1 " + hex.EncodeToString(sum[:])
+	}
+	v := BO.Value(obj, name, false)
+	if v == nil {
+		return "NULL"
+	}
+	return FormatValue(v)
+}
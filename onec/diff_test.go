@@ -0,0 +1,46 @@
+package onec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSchema(t *testing.T) {
+	a := &BaseOnec{TableDescription: map[string]Table{}}
+	b := &BaseOnec{TableDescription: map[string]Table{}}
+	add := func(base *BaseOnec, table string, indexes []Index, fields ...Field) {
+		tbl := Table{Name: table, Fields: map[string]Field{}, Indexes: indexes}
+		for _, f := range fields {
+			tbl.Fields[f.Name] = f
+			tbl.FieldsName = append(tbl.FieldsName, f.Name)
+		}
+		base.TableDescription[table] = tbl
+		base.TablesName = append(base.TablesName, table)
+	}
+	id := Field{Name: "_IDRREF", FieldType: "B", Lenth: 16}
+	primary := []Index{{Name: "BYID", Primary: true, Fields: []string{"_IDRREF"}, Lenths: []int{16}}}
+
+	add(a, "_DOCUMENT1", primary, id, Field{Name: "_NUMBER", FieldType: "NVC", Lenth: 9})
+	add(a, "_REFERENCE1", primary, id)
+	add(a, "_REFERENCE2", nil, id)
+	add(b, "_DOCUMENT1", primary, id, Field{Name: "_NUMBER", FieldType: "NVC", Lenth: 11}, Field{Name: "_FLD10", FieldType: "L"})
+	add(b, "_REFERENCE1", nil, id)
+	add(b, "_REFERENCE2", nil, id)
+	add(b, "_REFERENCE3", nil, id)
+
+	d := DiffSchema(a, b)
+	want := SchemaDiff{
+		AddedTables:   []string{"_REFERENCE3"},
+		RemovedTables: []string{},
+		ChangedTables: []TableDiff{
+			{Name: "_DOCUMENT1", AddedFields: []string{"_FLD10"}, ChangedFields: []FieldChange{{"_NUMBER", "NVC(9,0)", "NVC(11,0)"}}},
+			{Name: "_REFERENCE1", IndexesChanged: true},
+		},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("DiffSchema: got %+v", d)
+	}
+	if !DiffSchema(b, b).IsEmpty() {
+		t.Errorf("DiffSchema of the same base is not empty")
+	}
+}
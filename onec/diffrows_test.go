@@ -0,0 +1,58 @@
+package onec_test
+
+import (
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffRows(t *testing.T) {
+	table := func(rows ...onectest.Row) onectest.Base {
+		return onectest.Base{Tables: []onectest.Table{{
+			Name: "_REFERENCE1",
+			Fields: []onectest.Field{
+				{Name: "_IDRREF", Type: "B", Length: 16},
+				{Name: "_DESCRIPTION", Type: "NVC", Length: 20},
+				{Name: "_DATA", Type: "I", Null: true},
+			},
+			Rows: rows,
+		}}}
+	}
+	row := func(id byte, description string, data string) onectest.Row {
+		values := map[string]interface{}{"_IDRREF": []byte{id}, "_DESCRIPTION": description}
+		if data != "" {
+			values["_DATA"] = data
+		}
+		return onectest.Row{Values: values}
+	}
+	a := onectest.Open(t, table(row(1, "one", ""), row(2, "two", "blob"), row(3, "three", "blob"), row(4, "four", ""), onectest.Row{Deleted: true}))
+	b := onectest.Open(t, table(row(3, "three", "blob"), onectest.Row{Deleted: true}, row(1, "one!", ""), row(2, "two", "blob2"), row(5, "five", "")))
+
+	d, err := onec.DiffRows(a, b, "_REFERENCE1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Added != 1 || d.Removed != 1 || d.Changed != 2 || d.Truncated || !reflect.DeepEqual(d.Key, []string{"_IDRREF"}) {
+		t.Fatalf("unexpected diff %+v", d)
+	}
+	kinds := make([]string, 0, len(d.Rows))
+	for _, v := range d.Rows {
+		kinds = append(kinds, v.Kind+" "+strconv.Itoa(v.OldRow)+" "+strconv.Itoa(v.NewRow))
+	}
+	if !reflect.DeepEqual(kinds, []string{"changed 1 3", "changed 2 4", "added -1 5", "removed 4 -1"}) {
+		t.Fatalf("unexpected rows %v", kinds)
+	}
+	if f := d.Rows[0].Fields; len(f) != 1 || f[0].Name != "_DESCRIPTION" || f[0].Old != "one" || f[0].New != "one!" {
+		t.Errorf("unexpected fields %+v", f)
+	}
+	if f := d.Rows[1].Fields; len(f) != 1 || f[0].Name != "_DATA" || !strings.HasPrefix(f[0].New, "blob 5 sha1 ") {
+		t.Errorf("unexpected fields %+v", f)
+	}
+
+	if d, err = onec.DiffRows(a, b, "_REFERENCE1", 1); err != nil || len(d.Rows) != 1 || !d.Truncated || d.Changed != 2 {
+		t.Errorf("unexpected truncated diff %+v %v", d, err)
+	}
+}
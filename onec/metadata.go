@@ -0,0 +1,128 @@
+package onec
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DBNamesFile is the file of table PARAMS which maps uuids of metadata objects to names of tables and fields
+const DBNamesFile = "DBNames"
+
+var dbNamePattern = regexp.MustCompile(`_[A-Z]+[0-9]+`)
+
+// DBName is an entry of DBNames: {uuid,"Fld",123} is field _Fld123 of metadata object uuid
+type DBName struct {
+	UUID   string
+	Type   string
+	Number int
+}
+
+// Name is name of table or field in upper case as in base: _FLD123
+func (n DBName) Name() string {
+	return strings.ToUpper("_" + n.Type + strconv.Itoa(n.Number))
+}
+
+// MetadataNames maps names of tables and fields (_REFERENCE45, _FLD123) to names of metadata objects,
+// Tables maps numbers of DBNames to names of tables, it is used by references of composite type (_RTREF)
+type MetadataNames struct {
+	Names  map[string]string
+	Tables map[int][]string
+}
+
+// ParseDBNames parses DBNames: {1,{count,{uuid,"Type",number},...}}, entries are searched at any level
+func ParseDBNames(s string) ([]DBName, error) {
+	root, err := ParseInternal(s)
+	if err != nil {
+		return nil, err
+	}
+	if !root.IsList() {
+		return nil, errors.New("The format of DBNames is not valid")
+	}
+	names := []DBName{}
+	var walk func(v InternalValue)
+	walk = func(v InternalValue) {
+		if len(v.List) == 3 && uuidPattern.MatchString(v.List[0].Value) && v.List[1].Quoted {
+			if number, err := strconv.Atoi(v.List[2].Value); err == nil {
+				names = append(names, DBName{UUID: v.List[0].Value, Type: v.List[1].Value, Number: number})
+				return
+			}
+		}
+		for _, item := range v.List {
+			walk(item)
+		}
+	}
+	walk(root)
+	return names, nil
+}
+
+// collectMetadataNames adds names of objects of tree, name is the string after {0,0,uuid}
+func collectMetadataNames(v InternalValue, names map[string]string) {
+	for k, item := range v.List {
+		if item.Quoted && k > 0 && isMetadataID(v.List[k-1]) && item.Value != "" {
+			if _, ok := names[v.List[k-1].List[2].Value]; !ok {
+				names[v.List[k-1].List[2].Value] = item.Value
+			}
+			continue
+		}
+		collectMetadataNames(item, names)
+	}
+}
+
+// MetadataNames reads DBNames from PARAMS and names of metadata objects from files of CONFIG.
+// Names are empty when base has no such tables or files
+func (BO *BaseOnec) MetadataNames() MetadataNames {
+	m := MetadataNames{Names: make(map[string]string), Tables: make(map[int][]string)}
+	file, ok := BO.tableFile("PARAMS", DBNamesFile)
+	if !ok {
+		return m
+	}
+	dbNames, err := ParseDBNames(file.Text)
+	if err != nil {
+		return m
+	}
+	for _, v := range dbNames {
+		if _, ok := BO.TableDescription[v.Name()]; ok {
+			m.Tables[v.Number] = append(m.Tables[v.Number], v.Name())
+		}
+	}
+
+	names := make(map[string]string)
+	if _, ok := BO.TableDescription["CONFIG"]; ok {
+		BO.Scan("CONFIG", 0, false, func(obj Object) bool {
+			blob := DecodeBlob("I", BO.BlobRaw(obj, "BINARYDATA"))
+			if blob.Kind != BlobValueStorage && blob.Kind != BlobText {
+				return true
+			}
+			if tree, err := ParseInternal(blob.Text); err == nil {
+				collectMetadataNames(tree, names)
+			}
+			return true
+		})
+	}
+	for _, v := range dbNames {
+		if name, ok := names[v.UUID]; ok {
+			m.Names[v.Name()] = name
+		}
+	}
+	return m
+}
+
+// Name returns names of metadata objects of table or field: _REFERENCE45_VT67 is "Name.Name",
+// _FLD123RREF is name of _FLD123. It is "" when name is unknown
+func (m MetadataNames) Name(name string) string {
+	parts := dbNamePattern.FindAllString(strings.ToUpper(name), -1)
+	if len(parts) == 0 || !strings.HasPrefix(strings.ToUpper(name), parts[0]) {
+		return ""
+	}
+	names := make([]string, 0, len(parts))
+	for _, v := range parts {
+		n, ok := m.Names[v]
+		if !ok {
+			return ""
+		}
+		names = append(names, n)
+	}
+	return strings.Join(names, ".")
+}
@@ -6,8 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
-	"math"
+	"io"
 	"os"
 	"regexp"
 	"sort"
@@ -29,8 +28,14 @@ const RootObjectOffset uint64 = 2
 const BlobChunkSize uint32 = 256
 const BlobChunkOffset uint32 = 1
 
+// Page sizes of base: 4K, 8K, 16K, 32K or 64K
+const (
+	MinPageSize uint32 = 4096
+	MaxPageSize uint32 = 65536
+)
+
 type BaseOnec struct {
-	Db               *os.File
+	Db               io.ReaderAt // *os.File or bytes.Reader for base in memory
 	HeadDB           headDB
 	TableDescription map[string]Table
 	TablesName       []string
@@ -53,6 +58,7 @@ type Table struct {
 	RowLength   int
 	Fields      map[string]Field
 	FieldsName  []string
+	Indexes     []Index
 	//NoRecords              bool //0 records of this table in base
 	BlockOfReplacemant     []uint32
 	BlockOfReplacemantBlob []uint32
@@ -67,6 +73,15 @@ type Field struct {
 	CaseSensitive   bool
 	DataFieldOffset int
 	DataLength      int
+	Masked          bool // value is hidden: represented as MaskedValue, Value and BlobRaw return nil
+}
+
+// Index of table: {"_IDRREF","1",{"_IDRREF",16}}, Primary is "1" flag
+type Index struct {
+	Name    string
+	Primary bool
+	Fields  []string
+	Lenths  []int
 }
 
 type Object struct {
@@ -79,21 +94,19 @@ type Object struct {
 	//BlobData        []byte
 }
 
-func ReadBytesOfObject(db *os.File, BlockOfReplacemant []uint32, RowLength int, PageSize int, n int, mu *sync.Mutex) []byte {
+func ReadBytesOfObject(db io.ReaderAt, BlockOfReplacemant []uint32, RowLength int, PageSize int, n int, mu *sync.Mutex) []byte {
 	var ToRead, ToEndOfBlock, pos int
 	offsetOfNObject := n * RowLength
 	LefrToRead := RowLength
 	bufTableObject := make([]byte, 0, RowLength)
 
-	if offsetOfNObject/PageSize > len(BlockOfReplacemant) { //out of file
-		fmt.Println("out1 ", n)
+	if offsetOfNObject/PageSize > len(BlockOfReplacemant) { //out of object, record is read as not existing
 		return []byte{}
 	}
 
 	for i := 0; LefrToRead > 0; i++ {
 		//error??
-		if (offsetOfNObject/PageSize + i) >= len(BlockOfReplacemant) { //out of file
-			fmt.Println("out2 ", n)
+		if (offsetOfNObject/PageSize + i) >= len(BlockOfReplacemant) { //out of object
 			return []byte{}
 		}
 		if i == 0 {
@@ -147,6 +160,11 @@ func (BO *BaseOnec) ReadTableObject(BlockOfReplacemant []uint32, Table Table, n
 	//}
 
 	for k, v := range Table.Fields {
+		if v.Masked {
+			Object.ValueObject[k] = make([]byte, v.DataLength)
+			Object.RepresentObject[k] = MaskedValue
+			continue
+		}
 		value := bufTableObject[v.DataFieldOffset:(v.DataFieldOffset + v.DataLength)] //RepresentObject[k]
 		Object.ValueObject[k] = value
 		Object.RepresentObject[k] = FromFormat1C(value, v, &Object, BO, blobValue)
@@ -154,6 +172,34 @@ func (BO *BaseOnec) ReadTableObject(BlockOfReplacemant []uint32, Table Table, n
 	return Object
 }
 
+// DecodeNumber decodes field type N to exact decimal: sign nibble (0 - negative, 1 - positive),
+// then FieldLength digits, FieldPrecision of them after point
+func DecodeNumber(value []byte, field Field) string {
+	digits := make([]byte, 0, len(value)*2)
+	for _, b := range value {
+		digits = append(digits, '0'+b>>4, '0'+b&0x0f)
+	}
+	negative := digits[0] == '0'
+	digits = digits[1:]
+	if field.Lenth > 0 && field.Lenth < len(digits) {
+		digits = digits[:field.Lenth]
+	}
+	precision := Min(field.Precision, len(digits))
+	intPart := strings.TrimLeft(string(digits[:len(digits)-precision]), "0")
+	fracPart := strings.TrimRight(string(digits[len(digits)-precision:]), "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	returnValue := intPart
+	if fracPart != "" {
+		returnValue += "." + fracPart
+	}
+	if negative && returnValue != "0" {
+		returnValue = "-" + returnValue
+	}
+	return returnValue
+}
+
 func allZero(s []byte) bool {
 	for _, v := range s {
 		if v != 0 {
@@ -188,7 +234,7 @@ func FromFormat1C(value []byte, field Field, object *Object, BO *BaseOnec, blobV
 		returnValue = string(utf16.Decode(value16))
 	case "NC":
 		var value16 []uint16
-		for n := 0; n < len(value); n++ { //len(value)/2-2; n++ {
+		for n := 0; n < len(value)/2; n++ {
 			value16 = append(value16, binary.LittleEndian.Uint16(value[n*2:n*2+2]))
 		}
 		returnValue = string(utf16.Decode(value16))
@@ -204,16 +250,7 @@ func FromFormat1C(value []byte, field Field, object *Object, BO *BaseOnec, blobV
 		}
 		returnValue = d[0] + d[1] + "." + d[2] + "." + d[3] + " " + d[4] + ":" + d[5] + ":" + d[6]
 	case "N": //«N» - число. Длина поля в байтах равна Цел((FieldLength + 2) / 2). Числа хранятся в двоично-десятичном виде. Первый полубайт означает знак числа. 0 – число отрицательное, 1 – положительное. Каждый следующий полубайт соответствует одной десятичной цифре. Всего цифр FieldLength. Десятичная точка находится в FieldPrecision цифрах справа. Например, FieldLength = 5, FieldPrecision = 3. Байты 0x18, 0x47, 0x23 означают число 84.723, а байты 0x00, 0x00, 0x91 представляют число -0.091.
-		Ib, _ := strconv.Atoi(strconv.FormatInt(int64(value[0]), 16))
-		sign := ((Ib/10)*2 - 1)
-		returnValueF := float64(sign * Ib % 10)
-		//for i := 1; i < field.Lenth/2+1; i++ {
-		for i := 1; i < len(value); i++ {
-			Ib, _ = strconv.Atoi(strconv.FormatInt(int64(value[i]), 16))
-			returnValueF = returnValueF*100 + float64(Ib) // float64(Ib/10)*10 + float64(Ib%10)
-		}
-		returnValueF = returnValueF / math.Pow10(field.Precision+1)
-		returnValue = strconv.FormatFloat(returnValueF, 'f', -1, 64) //fmt.Sprintf("%f", returnValueF)
+		returnValue = DecodeNumber(value, field)
 	case "L":
 		if value[0] == 0 {
 			return "false"
@@ -224,25 +261,16 @@ func FromFormat1C(value []byte, field Field, object *Object, BO *BaseOnec, blobV
 		LenthBlob := binary.LittleEndian.Uint32(value[4:])
 
 		if blobValue {
-			rv := ReadBlobStream(BO.Db, uint64(object.Table.BlockOfReplacemantBlob[uint32(ChunkOffset)*BlobChunkSize/BO.HeadDB.PageSize])*uint64(BO.HeadDB.PageSize)+uint64(ChunkOffset*BlobChunkSize%BO.HeadDB.PageSize), BO.HeadDB.PageSize, object.Table.BlockOfReplacemantBlob, nil)
-			returnValue = string(rv[:LenthBlob])
-			if len(rv) < int(LenthBlob) {
-				LenthBlob = uint32(len(rv))
+			blob := DecodeBlob(field.FieldType, BO.ReadBlob(*object.Table, ChunkOffset, LenthBlob))
+			if blob.Text != "" || blob.Kind == BlobText {
+				returnValue = blob.Text
+			} else {
+				returnValue = ByteSliceToHexString(blob.Data)
 			}
-			returnValue = string(rv[:LenthBlob])
 		} else {
 			returnValue = strings.Join([]string{"/blob/", strconv.Itoa(object.Table.BlobOffset), "/", strconv.Itoa(int(ChunkOffset)), "/", strconv.Itoa(int(LenthBlob))}, "")
 		}
 
-		/*
-			//rv := rvb[:LenthBlob]
-			var value16 []uint16
-			for n := 0; n < (len(rv)/2 - 2); n++ { //len(value)/2-2; n++ {
-				value16 = append(value16, binary.LittleEndian.Uint16(rv[n*2:n*2+2]))
-			}
-			returnValue = string(utf16.Decode(value16))
-		*/
-
 	default:
 		returnValue = ByteSliceToHexString(value)
 	}
@@ -282,24 +310,50 @@ func ByteSliceToHexString1(originalBytes []byte) string {
 	return buff.String()
 }
 
+// CheckBlockOfReplacemant reads pages of data and blob of table s if they are not read yet. Pages of tables of opened base
+// are read by readPageLists, so the map of tables is not written after open and base may be read concurrently.
+// Unknown tables are skipped
 func (BO *BaseOnec) CheckBlockOfReplacemant(s string) {
-
-	if BO.TableDescription[s].BlockOfReplacemant == nil {
-		BlockOfReplacemant := ReadBlockOfReplacemant(BO, BO.TableDescription[s].DataOffset)
-		tempT := BO.TableDescription[s]
-		tempT.BlockOfReplacemant = BlockOfReplacemant
-		BO.TableDescription[s] = tempT
+	t, ok := BO.TableDescription[s]
+	if !ok || t.BlockOfReplacemant != nil && t.BlockOfReplacemantBlob != nil {
+		return
 	}
+	readPageLists(BO, &t)
+	BO.TableDescription[s] = t
+}
 
-	if len(BO.TableDescription[s].BlockOfReplacemantBlob) == 0 && len(BO.TableDescription[s].BlockOfReplacemant) > 0 && BO.TableDescription[s].BlobOffset != 0 {
-		BlockOfReplacemantBlob := ReadBlockOfReplacemant(BO, BO.TableDescription[s].BlobOffset)
-		if len(BlockOfReplacemantBlob) != 0 {
-			tempT := BO.TableDescription[s]
-			tempT.BlockOfReplacemantBlob = BlockOfReplacemantBlob
-			BO.TableDescription[s] = tempT
+// readPageLists reads pages of data and blob of table when base is opened, lists of corrupted objects are empty
+func readPageLists(BO *BaseOnec, t *Table) {
+	t.BlockOfReplacemant, t.BlockOfReplacemantBlob = []uint32{}, []uint32{}
+	defer func() {
+		if r := recover(); r != nil {
+			t.BlockOfReplacemant, t.BlockOfReplacemantBlob = []uint32{}, []uint32{}
 		}
+	}()
+	if t.DataOffset != 0 {
+		t.BlockOfReplacemant = ReadBlockOfReplacemant(BO, t.DataOffset)
+	}
+	if t.BlobOffset != 0 {
+		t.BlockOfReplacemantBlob = ReadBlockOfReplacemant(BO, t.BlobOffset)
 	}
+}
 
+// ObjectLength reads length of object (bytes) from its header page
+func ObjectLength(BO *BaseOnec, dataOffset int) uint64 {
+	if dataOffset == 0 {
+		return 0
+	}
+	buf := ReadBytes(BO.Db, uint64(BO.HeadDB.PageSize)*uint64(dataOffset), 24, nil)
+	return binary.LittleEndian.Uint64(buf[16:24])
+}
+
+// RowsCount returns number of records in data object of table s, deleted records included
+func (BO *BaseOnec) RowsCount(s string) int {
+	t, ok := BO.TableDescription[s]
+	if !ok || t.RowLength == 0 {
+		return 0
+	}
+	return int(ObjectLength(BO, t.DataOffset) / uint64(t.RowLength))
 }
 
 func (BO *BaseOnec) Rows(s string, n int, blobValue bool) Object {
@@ -307,55 +361,93 @@ func (BO *BaseOnec) Rows(s string, n int, blobValue bool) Object {
 	return BO.ReadTableObject(BO.TableDescription[s].BlockOfReplacemant, BO.TableDescription[s], n, blobValue)
 }
 
-func ReadBytes(db *os.File, position uint64, lenth uint32, mu *sync.Mutex) []byte {
+// ReadError is panic of ReadBytes when base is truncated or can not be read
+type ReadError struct {
+	Position uint64
+	Err      error
+}
+
+func (e ReadError) Error() string {
+	return strings.Join([]string{"read at", strconv.FormatUint(e.Position, 10), "failed:", e.Err.Error()}, " ")
+}
+
+func (e ReadError) Unwrap() error {
+	return e.Err
+}
+
+// CatchReadError is deferred by functions which return error of reading base instead of panic of ReadBytes
+func CatchReadError(err *error) {
+	if r := recover(); r != nil {
+		e, ok := r.(ReadError)
+		if !ok {
+			panic(r)
+		}
+		*err = e
+	}
+}
+
+// ReadBytes reads lenth bytes at position, ReadAt does not move offset of file so bases may be read concurrently.
+// It panics with ReadError if base is shorter
+func ReadBytes(db io.ReaderAt, position uint64, lenth uint32, mu *sync.Mutex) []byte {
 	if mu != nil {
 		mu.Lock()
 		defer mu.Unlock()
 	}
-	_, err := db.Seek(int64(position), 0)
-	if err != nil {
-		log.Fatal(err)
-	}
 	bytes := make([]byte, lenth)
-	_, err = db.Read(bytes)
-	if err != nil {
-		log.Fatal(err)
+	n, err := db.ReadAt(bytes, int64(position))
+	if err != nil && n < len(bytes) {
+		panic(ReadError{Position: position, Err: err})
 	}
 
 	return bytes
 }
 
-func readHeadDB(db *os.File) (headDB, error) {
+// Size returns size of file of base, for base in memory it is size of reader
+func (BO *BaseOnec) Size() (int64, error) {
+	switch db := BO.Db.(type) {
+	case *os.File:
+		info, err := db.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	case interface{ Size() int64 }:
+		return db.Size(), nil
+	}
+	return 0, errors.New("Size of base is unknown")
+}
+
+func readHeadDB(db io.ReaderAt) (headDB, error) {
 	headDB := headDB{}
 	buf := ReadBytes(db, 0, 24, nil)
 	buffer := bytes.NewBuffer(buf)
 	err := binary.Read(buffer, binary.LittleEndian, &headDB)
 	if err != nil {
-		log.Fatal("binary.Read failed", err)
 		return headDB, err
 	}
 
 	return headDB, nil
 }
 
-func ReadBlobStream(db *os.File, offset uint64, pageSize uint32, dataPagesOffsets []uint32, mu *sync.Mutex) []byte {
+func ReadBlobStream(db io.ReaderAt, offset uint64, pageSize uint32, dataPagesOffsets []uint32, mu *sync.Mutex) []byte {
 	nextBlock := uint32(1)
 	data := make([]byte, 0, 250)
 	currentOffset := offset
-	for nextBlock != 0 {
+	maxChunks := len(dataPagesOffsets) * int(pageSize/BlobChunkSize) //chain of chunks is not longer than object, else it is a loop
+	for chunks := 0; nextBlock != 0; chunks++ {
+		if chunks >= maxChunks {
+			return []byte{}
+		}
 		b := ReadBytes(db, currentOffset, BlobChunkSize, mu)
 		nextBlock = binary.LittleEndian.Uint32(b[:4])
-		nextPage := uint32(nextBlock) * BlobChunkSize / pageSize
+		nextPage := uint64(nextBlock) * uint64(BlobChunkSize) / uint64(pageSize)
 
-		if int(nextPage) > len(dataPagesOffsets) { //for del
-			fmt.Println("nextPage>888 ")
+		if nextPage >= uint64(len(dataPagesOffsets)) { //chain leaves object, stream is broken
 			return []byte{}
 		}
-		currentOffset = uint64(dataPagesOffsets[nextPage])*uint64(pageSize) + uint64(uint32(nextBlock)*BlobChunkSize%pageSize)
+		currentOffset = uint64(dataPagesOffsets[nextPage])*uint64(pageSize) + uint64(nextBlock)*uint64(BlobChunkSize)%uint64(pageSize)
 		size := binary.LittleEndian.Uint16(b[4:6])
-		if size > 250 { //for del
-			size = uint16(len(b)) - 6
-			fmt.Println("size>250 ")
+		if size > 250 { //chunk is corrupted
 			return []byte{}
 		}
 		data = append(data, b[6:6+size]...)
@@ -401,39 +493,32 @@ func ReadBlockOfReplacemant(BO *BaseOnec, dataOffset int) []uint32 {
 	//a0 := [2]byte(buf)
 	//fatLevel := [1]byte(buf[2:3])
 
-	sig := hex.EncodeToString(buf[:2])
-	if sig != "1cfd" {
-		fmt.Println("sig??? ", sig)
+	if !bytes.Equal(buf[:2], objectSignature) {
+		panic(ReadError{Position: offset, Err: errors.New(strings.Join([]string{"bad signature of object header", hex.EncodeToString(buf[:2])}, " "))})
 	}
 	fatLevel := buf[2:3][0] //fatLevel, _ :=strconv.Atoi(string(buf[2:3]))
-	lenth := binary.LittleEndian.Uint64(buf[16:25])
-	numberOfBlocks := int(math.Ceil(float64(lenth) / float64(pageSize)))
-	blocksOfReplacemant := make([]uint32, 0, numberOfBlocks)
+	lenth := binary.LittleEndian.Uint64(buf[16:24])
+	numberOfBlocks := int((lenth + uint64(pageSize) - 1) / uint64(pageSize))
+	tableSize := Min(int(ObjectPageTableSize(fatLevel, lenth, uint64(pageSize))), (int(pageSize)-24)/4)
+	blocksOfReplacemant := make([]uint32, 0, Min(numberOfBlocks, Max(int(BO.HeadDB.NumberOfPages), tableSize))) //length of corrupted object may be huge
 	if fatLevel == 0 {
-		for n := 0; n < numberOfBlocks; n++ {
+		for n := 0; n < Min(numberOfBlocks, tableSize); n++ {
 			blocksOfReplacemant = append(blocksOfReplacemant, binary.LittleEndian.Uint32(buf[24+n*4:24+(n+1)*4]))
 		}
 	} else if fatLevel == 1 {
-		index_pages_offsets := make([]uint32, 0, 2) //need correct - mistake
-		for n := 0; ; n++ {
-			pages_offset := binary.LittleEndian.Uint32(buf[24+n*4 : 25+(n+1)*4])
-			if pages_offset == 0 {
-				break
-			}
-			index_pages_offsets = append(index_pages_offsets, pages_offset)
+		// header lists pages of page table, each of them lists pageSize/4 data pages, the last one is filled partially
+		index_pages_offsets := make([]uint32, 0, tableSize)
+		for n := 0; n < tableSize; n++ {
+			index_pages_offsets = append(index_pages_offsets, binary.LittleEndian.Uint32(buf[24+n*4:24+(n+1)*4]))
 		}
-		for n := 0; n < len(index_pages_offsets); n++ {
+		for n := 0; n < len(index_pages_offsets) && len(blocksOfReplacemant) < numberOfBlocks; n++ {
 			buf = ReadBytes(BO.Db, uint64(index_pages_offsets[n])*uint64(pageSize), pageSize, nil)
-			for i := 0; i < len(buf)/4; i++ {
-				block := binary.LittleEndian.Uint32(buf[i*4 : i*4+4])
-				if block == 0 {
-					break
-				}
-				blocksOfReplacemant = append(blocksOfReplacemant, block)
+			for i := 0; i < len(buf)/4 && len(blocksOfReplacemant) < numberOfBlocks; i++ {
+				blocksOfReplacemant = append(blocksOfReplacemant, binary.LittleEndian.Uint32(buf[i*4:i*4+4]))
 			}
 		}
 	} else {
-		panic("unknown fatlevel")
+		panic(ReadError{Position: offset, Err: errors.New(strings.Join([]string{"unknown fat level", strconv.Itoa(int(fatLevel))}, " "))})
 	}
 
 	return blocksOfReplacemant
@@ -473,6 +558,7 @@ func getTableDescription(s string) (Table, error) {
 	var dataFieldOffset int
 	var offset int
 	var caseSensitive bool
+	var err error
 
 	result := TableDescriptionPattern.FindStringSubmatch(s)
 
@@ -566,12 +652,51 @@ func getTableDescription(s string) (Table, error) {
 		TableFieldsName = append(TableFieldsName, name)
 	}
 	Table.RowLength = Max(5, offset)
+
+	Table.Indexes, err = getIndexes(result[3])
+	if err != nil {
+		return Table, err
+	}
+
 	sort.Strings(TableFieldsName)
 	Table.FieldsName = TableFieldsName
 
 	return Table, nil
 }
 
+// getIndexes parses indexes of table description: list of {"name","primary",{"field",lenth},...}
+func getIndexes(s string) ([]Index, error) {
+	list, err := ParseInternal("{" + s + "}")
+	if err != nil {
+		return nil, errors.New(strings.Join([]string{"The format of Indexes is not valid ", s}, " "))
+	}
+	indexes := make([]Index, 0, len(list.List))
+	for _, v := range list.List {
+		if !v.IsList() || len(v.List) < 2 {
+			return nil, errors.New(strings.Join([]string{"The format of Index is not valid ", s}, " "))
+		}
+		index := Index{Name: v.List[0].Value, Primary: v.List[1].Value == "1"}
+		for _, f := range v.List[2:] {
+			if !f.IsList() || len(f.List) == 0 {
+				continue
+			}
+			lenth := 0
+			if len(f.List) > 1 {
+				lenth, _ = strconv.Atoi(f.List[1].Value)
+			}
+			index.Fields = append(index.Fields, f.List[0].Value)
+			index.Lenths = append(index.Lenths, lenth)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// ValidPageSize reports whether size is a power of two from MinPageSize to MaxPageSize
+func ValidPageSize(size uint32) bool {
+	return size >= MinPageSize && size <= MaxPageSize && size&(size-1) == 0
+}
+
 // Max returns the larger of x or y.
 func Max(x, y int) int {
 	if x < y {
@@ -588,22 +713,12 @@ func Min(x, y int) int {
 }
 
 func readDataPagesOffsets(BO *BaseOnec) []uint32 {
-	pageSize := BO.HeadDB.PageSize
-	b := ReadBytes(BO.Db, RootObjectOffset*uint64(pageSize), pageSize, nil) //sig := b[:2] //signature of object	//fatLevel := binary.LittleEndian.Uint16(b[2:4])
-	lenth := binary.LittleEndian.Uint64(b[16 : 16+8])
-
-	dataPagesCount := int(math.Ceil(float64(lenth) / float64(pageSize)))
-	dataPagesOffsets := make([]uint32, dataPagesCount) // Pages of Root Object
-
-	for n := 0; n < dataPagesCount; n++ {
-		dataPagesOffsets[n] = binary.LittleEndian.Uint32(b[24+n*4 : 24+n*4+4])
-	}
-
-	return dataPagesOffsets
+	return ReadBlockOfReplacemant(BO, int(RootObjectOffset)) // Pages of Root Object
 }
 
 func readTablesDescriptions(BO *BaseOnec, dataPagesOffsets []uint32, blocksOfReplacemant []uint32, mu *sync.Mutex) (map[string]Table, []string, error) {
-	//var err error
+	var err error
+	var errOnce sync.Once
 	tablesChan := make(chan Table)
 
 	wg := new(sync.WaitGroup)
@@ -619,14 +734,27 @@ func readTablesDescriptions(BO *BaseOnec, dataPagesOffsets []uint32, blocksOfRep
 
 		//run goroutines for each table
 		wg.Add(1)
-		go func(db *os.File, chunkOffset uint32, pageSize uint32, dataPagesOffsets []uint32, tablesChan chan<- Table, wg *sync.WaitGroup) {
+		go func(db io.ReaderAt, chunkOffset uint32, pageSize uint32, dataPagesOffsets []uint32, tablesChan chan<- Table, wg *sync.WaitGroup) {
 			defer wg.Done()
-			offset := uint64(dataPagesOffsets[uint32(chunkOffset)*BlobChunkSize/pageSize])*uint64(pageSize) + uint64(uint32(chunkOffset)*BlobChunkSize%pageSize)
+			defer func() {
+				if r := recover(); r != nil { //corrupted description must not kill process
+					errOnce.Do(func() {
+						err = errors.New(strings.Join([]string{"table at chunk", strconv.FormatUint(uint64(chunkOffset), 10), fmt.Sprint(r)}, " "))
+					})
+				}
+			}()
+			page := uint64(chunkOffset) * uint64(BlobChunkSize) / uint64(pageSize)
+			if page >= uint64(len(dataPagesOffsets)) {
+				tablesChan <- Table{Name: strings.Join([]string{"chunk", strconv.FormatUint(uint64(chunkOffset), 10), "is out of root object"}, " ")}
+				return
+			}
+			offset := uint64(dataPagesOffsets[page])*uint64(pageSize) + uint64(chunkOffset)*uint64(BlobChunkSize)%uint64(pageSize)
 			tablesDescription, err := getTableDescription(string(ReadBlobStream(db, uint64(offset), pageSize, dataPagesOffsets, mu)))
 			if err != nil {
-				tablesDescription = Table{Name: strings.Join([]string{"offset", strconv.FormatUint(offset, 10), "page", strconv.FormatUint(uint64(pageSize), 10)}, " ")}
-				fmt.Println(err)
-				//return err
+				//table is listed with error of its description
+				tablesDescription = Table{Name: strings.Join([]string{"offset", strconv.FormatUint(offset, 10), "page", strconv.FormatUint(uint64(pageSize), 10) + ":", err.Error()}, " ")}
+			} else {
+				readPageLists(BO, &tablesDescription)
 			}
 			tablesChan <- tablesDescription
 		}(BO.Db, chunkOffset, pageSize, dataPagesOffsets, tablesChan, wg)
@@ -647,7 +775,7 @@ func readTablesDescriptions(BO *BaseOnec, dataPagesOffsets []uint32, blocksOfRep
 	wg1.Wait()
 
 	sort.Strings(TablesName)
-	return TablesDescription, TablesName, nil
+	return TablesDescription, TablesName, err
 }
 
 // Read Root Object
@@ -665,11 +793,15 @@ func (BO *BaseOnec) RootObject(mu *sync.Mutex) error {
 	return nil
 }
 
-func DatabaseReader(db *os.File) (*BaseOnec, error) {
+func DatabaseReader(db io.ReaderAt) (BO *BaseOnec, err error) {
+	defer func() {
+		if r := recover(); r != nil { //truncated or corrupted base
+			BO, err = nil, errors.New(strings.Join([]string{"Base is corrupted:", fmt.Sprint(r)}, " "))
+		}
+	}()
 	BaseOnec := &BaseOnec{
 		Db: db,
 	}
-	var err error
 	mu := new(sync.Mutex)
 	BaseOnec.HeadDB, err = readHeadDB(BaseOnec.Db)
 	if err != nil {
@@ -677,8 +809,10 @@ func DatabaseReader(db *os.File) (*BaseOnec, error) {
 		//log.Fatal("HeadDB read failed", err)
 	}
 	if BaseOnec.HeadDB.Ver != Ver8380 {
-		//return nil, errors.New("Do not support another version, this ver: "+ string(BaseOnec.HeadDB.Ver))
-		log.Fatal("Do not support another version", BaseOnec.HeadDB.Ver)
+		return nil, errors.New(strings.Join([]string{"Do not support another version", fmt.Sprint(BaseOnec.HeadDB.Ver)}, " "))
+	}
+	if !ValidPageSize(BaseOnec.HeadDB.PageSize) {
+		return nil, errors.New(strings.Join([]string{"Do not support page size", strconv.FormatUint(uint64(BaseOnec.HeadDB.PageSize), 10)}, " "))
 	}
 	err = BaseOnec.RootObject(mu)
 	if err != nil {
@@ -688,7 +822,7 @@ func DatabaseReader(db *os.File) (*BaseOnec, error) {
 	return BaseOnec, nil
 }
 
-func OpenBaseOnec(db *os.File) (*BaseOnec, error) {
+func OpenBaseOnec(db io.ReaderAt) (*BaseOnec, error) {
 	//db, err := os.Open(path)
 	//if err != nil {
 	//	return nil, err
@@ -1,8 +1,9 @@
 package onec
 
 import (
-	"fmt"
-	"os"
+	"bytes"
+	"encoding/binary"
+	"strings"
 	"testing"
 )
 
@@ -61,7 +62,7 @@ func TestFromFormat1C(t *testing.T) {
 			DataFieldOffset: 0,
 			DataLength:      0,
 		},
-		expectedValue: "8.4723",
+		expectedValue: "84.723",
 	}, {
 		name:  "324",
 		value: []byte{16, 0, 0, 0, 0, 0, 0, 0, 0, 50, 64},
@@ -76,6 +77,16 @@ func TestFromFormat1C(t *testing.T) {
 			DataLength:      11,
 		},
 		expectedValue: "324",
+	}, {
+		name:  "-0.091",
+		value: []byte{0, 0, 0x91},
+		field: Field{
+			Name:      "n",
+			FieldType: "N",
+			Lenth:     5,
+			Precision: 3,
+		},
+		expectedValue: "-0.091",
 	},
 	}
 	for _, tc := range testCases {
@@ -92,24 +103,53 @@ func TestFromFormat1C(t *testing.T) {
 	}
 }
 
-func BenchmarkBOReader(b *testing.B) {
-	//path := "C:/Del/UTDemo/KA/1Cv8.1CD"
-	path := "C:/GO/onec/py/tests/fixtures/Platform8Demo/8-3-8_4K.1CD"
-	//path := "C:/Del/UTDemo/ERP2/1Cv8.1CD"
-
-	db, err := os.Open(path)
+func TestGetTableDescription(t *testing.T) {
+	description := `{"_REFERENCE10",0,
+{"Fields",
+{"_IDRREF","B",0,16,0,"CS"},
+{"_VERSION","RV",0,0,0,"CS"},
+{"_MARKED","L",0,0,0,"CS"},
+{"_DESCRIPTION","NVC",0,25,0,"CI"}
+},
+{"Indexes",
+{"_IDRREF","1",
+{"_IDRREF",16}
+},
+{"_DESCR","0",
+{"_DESCRIPTION",25},
+{"_IDRREF",16}
+}
+},
+{"Recordlock","0"},
+{"Files",14,0,15}
+}`
+	table, err := getTableDescription(description)
 	if err != nil {
-		fmt.Println("error ", err)
+		t.Fatal(err)
+	}
+	if table.Name != "_REFERENCE10" || table.DataOffset != 14 || table.IndexOffset != 15 || table.RowLength != 17+16+1+52 {
+		t.Error("unexpected table", table.Name, table.DataOffset, table.IndexOffset, table.RowLength)
+	}
+	if len(table.Indexes) != 2 || !table.Indexes[0].Primary || table.Indexes[1].Primary ||
+		table.Indexes[1].Name != "_DESCR" || len(table.Indexes[1].Fields) != 2 || table.Indexes[1].Fields[1] != "_IDRREF" {
+		t.Error("unexpected indexes", table.Indexes)
 	}
-	defer db.Close()
 
-	for i := 0; i < b.N; i++ {
-		BO, _ := DatabaseReader(db)
-		//_ = BO
-		//fmt.Println(len(BO.TableDescription))
-		_ = BO
+	table, err = getTableDescription(strings.Replace(description, `{"Indexes",
+{"_IDRREF","1",
+{"_IDRREF",16}
+},
+{"_DESCR","0",
+{"_DESCRIPTION",25},
+{"_IDRREF",16}
+}
+}`, `{"Indexes"}`, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Indexes) != 0 {
+		t.Error("expected no indexes", table.Indexes)
 	}
-	//fmt.Println(len(BO.TableDescription))
 }
 
 /*
@@ -138,3 +178,97 @@ v: 5
 
 
 */
+
+// testObjectFile writes header of object with length and page table at page 1, pages of page table
+// (fat level 1) follow it, data page k is 1000+k
+func testObjectFile(t *testing.T, pageSize int, fatLevel byte, length uint64) *BaseOnec {
+	count := int((length + uint64(pageSize) - 1) / uint64(pageSize))
+	tableSize := int(ObjectPageTableSize(fatLevel, length, uint64(pageSize)))
+	data := make([]byte, pageSize*(2+tableSize))
+	header := data[pageSize : 2*pageSize]
+	copy(header, []byte{0x1c, 0xfd, fatLevel, 0})
+	binary.LittleEndian.PutUint64(header[16:24], length)
+	entries := header[24:]
+	if fatLevel == 1 {
+		for n := 0; n < tableSize; n++ {
+			binary.LittleEndian.PutUint32(header[24+n*4:], uint32(2+n))
+		}
+		entries = data[2*pageSize:]
+	}
+	for k := 0; k < count; k++ {
+		binary.LittleEndian.PutUint32(entries[k*4:], uint32(1000+k))
+	}
+	for k := count * 4; k < len(entries); k += 4 { //garbage after page table must not be read
+		binary.LittleEndian.PutUint32(entries[k:], 0xdeadbeef)
+	}
+
+	return &BaseOnec{Db: bytes.NewReader(data), HeadDB: headDB{PageSize: uint32(pageSize)}}
+}
+
+func TestReadBlockOfReplacemant(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pageSize int
+		fatLevel byte
+		length   uint64
+	}{
+		{"fat level 0", 4096, 0, 4096*3 + 1},
+		{"fat level 0 empty", 4096, 0, 0},
+		{"fat level 1 one page of page table", 4096, 1, 4096 * 1024},
+		{"fat level 1 two pages of page table", 4096, 1, 4096*1500 - 10},
+		{"fat level 1 page 8K", 8192, 1, 8192*2048 + 8192*3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			BO := testObjectFile(t, tc.pageSize, tc.fatLevel, tc.length)
+			pages := ReadBlockOfReplacemant(BO, 1)
+			count := int((tc.length + uint64(tc.pageSize) - 1) / uint64(tc.pageSize))
+			if len(pages) != count {
+				t.Fatalf("got %d pages, expected %d", len(pages), count)
+			}
+			for k, v := range pages {
+				if v != uint32(1000+k) {
+					t.Fatalf("page %d is %d, expected %d", k, v, 1000+k)
+				}
+			}
+		})
+	}
+}
+
+func TestReadBlockOfReplacemantSignature(t *testing.T) {
+	BO := &BaseOnec{Db: bytes.NewReader(make([]byte, 4096*2)), HeadDB: headDB{PageSize: 4096}}
+	defer func() {
+		if _, ok := recover().(ReadError); !ok {
+			t.Error("page without signature of object is read without ReadError")
+		}
+	}()
+	ReadBlockOfReplacemant(BO, 1)
+}
+
+func TestObjectSize(t *testing.T) {
+	for _, pageSize := range []int{4096, 8192, 16384, 32768, 65536} {
+		for _, fatLevel := range []byte{0, 1} {
+			length := uint64(pageSize) * 10
+			if fatLevel == 1 {
+				length = uint64(pageSize) * uint64(pageSize)
+			}
+			BO := testObjectFile(t, pageSize, fatLevel, length)
+			size, pages := BO.objectSize(1)
+			expected := int(ObjectPageTableSize(0, length, uint64(pageSize))) + 1
+			if fatLevel == 1 {
+				expected += int(ObjectPageTableSize(1, length, uint64(pageSize)))
+			}
+			if size != length || pages != expected {
+				t.Errorf("page %d fat level %d: size %d pages %d, expected %d pages", pageSize, fatLevel, size, pages, expected)
+			}
+		}
+	}
+}
+
+func TestValidPageSize(t *testing.T) {
+	for size, expected := range map[uint32]bool{0: false, 2048: false, 4096: true, 8192: true, 12288: false, 65536: true, 131072: false} {
+		if ValidPageSize(size) != expected {
+			t.Errorf("ValidPageSize(%d) is %v", size, !expected)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+// Package onectest builds bases of format 8.3.8 in memory for tests: tables with fields of all types,
+// rows, deleted and empty records, blobs and page sizes from 4K to 64K
+package onectest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// DefaultPageSize is page size of Base without PageSize
+const DefaultPageSize = 4096
+
+var objectSignature = []byte{0x1c, 0xfd}
+
+// Base declares base: tables are written in order, each has data object and blob object when it has fields I or NT
+type Base struct {
+	PageSize int
+	Tables   []Table
+}
+
+// Table declares table: fields in order of description, Indexes is text of list after {"Indexes"
+// like `,\n{"_IDRREF","1",\n{"_IDRREF",16}\n}`, rows are records from 1 (record 0 is header of free records)
+type Table struct {
+	Name    string
+	Fields  []Field
+	Indexes string
+	Rows    []Row
+}
+
+// Field is field of table as in description {"NAME","TYPE",Null,Length,Precision,"CS"}
+type Field struct {
+	Name          string
+	Type          string
+	Null          bool
+	Length        int
+	Precision     int
+	CaseSensitive bool
+}
+
+// Row is record of table: values by names of fields, missing values are NULL (or zero bytes when field is not nullable).
+// Values: string for NVC, NC, NT and N ("-12.50"), bool for L, time.Time for DT, []byte for B, RV and I (string for I is its bytes),
+// BlobRef for I and NT. Deleted rows are written as deleted records, Empty rows as records of zero bytes
+type Row struct {
+	Values  map[string]interface{}
+	Deleted bool
+	Empty   bool
+}
+
+// BlobRef is reference to blob written to field I or NT as is, it may point out of blob object
+type BlobRef struct {
+	Chunk uint32
+	Lenth uint32
+}
+
+// Open builds base in memory and opens it
+func Open(t testing.TB, b Base) *onec.BaseOnec {
+	t.Helper()
+	data, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return OpenBytes(t, data)
+}
+
+// OpenBytes opens base from bytes of file, it is used for bases corrupted after Bytes
+func OpenBytes(t testing.TB, data []byte) *onec.BaseOnec {
+	t.Helper()
+	BO, err := onec.OpenBaseOnec(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return BO
+}
+
+// WriteFile writes base to file path, it is used for tests of commands and servers which open files
+func (b Base) WriteFile(path string) error {
+	data, err := b.Bytes()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// pages is file of base being written by pages
+type pages struct {
+	pageSize int
+	pages    [][]byte
+}
+
+func (w *pages) alloc() uint32 {
+	w.pages = append(w.pages, make([]byte, w.pageSize))
+	return uint32(len(w.pages) - 1)
+}
+
+// object writes data as object with header at page header: fat level 0 when page numbers fit in header, else 1
+func (w *pages) object(header uint32, data []byte) {
+	count := (len(data) + w.pageSize - 1) / w.pageSize
+	dataPages := make([]uint32, count)
+	var fatLevel byte
+	var table []uint32
+	if count > (w.pageSize-24)/4 {
+		fatLevel = 1
+		table = make([]uint32, int(onec.ObjectPageTableSize(1, uint64(len(data)), uint64(w.pageSize))))
+		for k := range table {
+			table[k] = w.alloc()
+		}
+	}
+	for k := range dataPages {
+		dataPages[k] = w.alloc()
+		copy(w.pages[dataPages[k]], data[k*w.pageSize:onec.Min((k+1)*w.pageSize, len(data))])
+	}
+
+	h := w.pages[header]
+	copy(h, objectSignature)
+	h[2] = fatLevel
+	binary.LittleEndian.PutUint64(h[16:24], uint64(len(data)))
+	entries := dataPages
+	if fatLevel == 1 {
+		perPage := w.pageSize / 4
+		for k, v := range dataPages {
+			binary.LittleEndian.PutUint32(w.pages[table[k/perPage]][k%perPage*4:], v)
+		}
+		entries = table
+	}
+	for k, v := range entries {
+		binary.LittleEndian.PutUint32(h[24+k*4:], v)
+	}
+}
+
+// blobs is content of blob object: chunk 0 is header of object, streams start from chunk 1
+type blobs struct {
+	data []byte
+}
+
+func newBlobs() *blobs {
+	return &blobs{data: make([]byte, onec.BlobChunkSize)}
+}
+
+// add writes stream as chain of chunks and returns number of its first chunk
+func (b *blobs) add(stream []byte) uint32 {
+	first := uint32(len(b.data)) / onec.BlobChunkSize
+	dataSize := int(onec.BlobChunkSize) - 6
+	for k := 0; k == 0 || k < len(stream); k += dataSize {
+		part := stream[k:onec.Min(k+dataSize, len(stream))]
+		chunk := make([]byte, onec.BlobChunkSize)
+		if k+dataSize < len(stream) {
+			binary.LittleEndian.PutUint32(chunk[:4], uint32(len(b.data))/onec.BlobChunkSize+1)
+		}
+		binary.LittleEndian.PutUint16(chunk[4:6], uint16(len(part)))
+		copy(chunk[6:], part)
+		b.data = append(b.data, chunk...)
+	}
+	return first
+}
+
+// Bytes builds file of base: header, free pages object, root object with descriptions of tables and objects of tables
+func (b Base) Bytes() ([]byte, error) {
+	pageSize := b.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if !onec.ValidPageSize(uint32(pageSize)) {
+		return nil, errors.New(strings.Join([]string{"Bad page size", strconv.Itoa(pageSize)}, " "))
+	}
+	w := &pages{pageSize: pageSize}
+	w.alloc() // header of base
+	free := w.alloc()
+	root := w.alloc()
+	w.object(free, nil)
+
+	descriptions := make([]string, 0, len(b.Tables))
+	for _, t := range b.Tables {
+		description, err := t.write(w)
+		if err != nil {
+			return nil, err
+		}
+		descriptions = append(descriptions, description)
+	}
+
+	// root: language, number of tables and chunks of their descriptions
+	chunks := func(length int) int {
+		return onec.Max(1, (length+int(onec.BlobChunkSize)-7)/(int(onec.BlobChunkSize)-6))
+	}
+	rootLength := 32 + 4 + 4*len(descriptions)
+	blobs := newBlobs()
+	head := make([]byte, rootLength)
+	copy(head, "ru_RU")
+	binary.LittleEndian.PutUint32(head[32:36], uint32(len(descriptions)))
+	next := uint32(1 + chunks(rootLength))
+	for k, v := range descriptions {
+		binary.LittleEndian.PutUint32(head[36+k*4:], next)
+		next += uint32(chunks(len(v)))
+	}
+	blobs.add(head)
+	for _, v := range descriptions {
+		blobs.add([]byte(v))
+	}
+	w.object(root, blobs.data)
+
+	header := w.pages[0]
+	copy(header, "1CDBMSV8")
+	copy(header[8:12], onec.Ver8380[:])
+	binary.LittleEndian.PutUint32(header[12:16], uint32(len(w.pages)))
+	binary.LittleEndian.PutUint32(header[20:24], uint32(pageSize))
+
+	data := make([]byte, 0, len(w.pages)*pageSize)
+	for _, v := range w.pages {
+		data = append(data, v...)
+	}
+	return data, nil
+}
+
+// write writes objects of table and returns its description
+func (t Table) write(w *pages) (string, error) {
+	fields := make([]string, len(t.Fields))
+	hasBlobs := false
+	for k, f := range t.Fields {
+		null, cs := "0", "CI"
+		if f.Null {
+			null = "1"
+		}
+		if f.CaseSensitive {
+			cs = "CS"
+		}
+		fields[k] = "{\"" + f.Name + "\",\"" + f.Type + "\"," + null + "," + strconv.Itoa(f.Length) + "," + strconv.Itoa(f.Precision) + ",\"" + cs + "\"}"
+		if _, err := onec.CalcFieldSize(f.Type, f.Length); err != nil {
+			return "", err
+		}
+		hasBlobs = hasBlobs || f.Type == "I" || f.Type == "NT"
+	}
+
+	// offsets as description of table is parsed: RV is the first field
+	offsets := make(map[string]int, len(t.Fields))
+	offset := 1
+	for _, f := range t.Fields {
+		if f.Type == "RV" {
+			offset += 16
+		}
+	}
+	for _, f := range t.Fields {
+		size, _ := onec.CalcFieldSize(f.Type, f.Length)
+		if f.Null {
+			size++
+		}
+		if f.Type == "RV" {
+			offsets[f.Name] = 1
+			continue
+		}
+		offsets[f.Name] = offset
+		offset += size
+	}
+	rowLength := onec.Max(5, offset)
+
+	blobs := newBlobs()
+	data := make([]byte, rowLength*(len(t.Rows)+1))
+	for n, row := range t.Rows {
+		record := data[(n+1)*rowLength : (n+2)*rowLength]
+		if row.Empty {
+			continue
+		}
+		if row.Deleted {
+			record[0] = 1
+			continue
+		}
+		for _, f := range t.Fields {
+			value, err := encode(f, row.Values[f.Name], blobs)
+			if err != nil {
+				return "", err
+			}
+			copy(record[offsets[f.Name]:], value)
+		}
+	}
+
+	dataOffset := w.alloc()
+	blobOffset := uint32(0)
+	if hasBlobs {
+		blobOffset = w.alloc()
+	}
+	w.object(dataOffset, data)
+	if hasBlobs {
+		w.object(blobOffset, blobs.data)
+	}
+
+	return "{\"" + t.Name + "\",0,\n{\"Fields\",\n" + strings.Join(fields, ",\n") + "\n},\n{\"Indexes\"" + t.Indexes + "},\n" +
+		"{\"Recordlock\",\"0\"},\n{\"Files\"," + strconv.Itoa(int(dataOffset)) + "," + strconv.Itoa(int(blobOffset)) + ",0}\n}", nil
+}
+
+// encode encodes value of field, blobs are added to blob object
+func encode(f Field, v interface{}, blobs *blobs) ([]byte, error) {
+	size, _ := onec.CalcFieldSize(f.Type, f.Length)
+	value := make([]byte, size)
+	if v == nil {
+		if f.Null {
+			return []byte{0}, nil
+		}
+		return value, nil
+	}
+
+	switch f.Type {
+	case "NVC", "NC":
+		s, _ := v.(string)
+		chars := utf16.Encode([]rune(s))
+		if len(chars) > f.Length {
+			return nil, errors.New(strings.Join([]string{"Value", strconv.Quote(s), "of", f.Name, "is longer than", strconv.Itoa(f.Length)}, " "))
+		}
+		start := 0
+		if f.Type == "NVC" {
+			binary.LittleEndian.PutUint16(value, uint16(len(chars)))
+			start = 2
+		} else {
+			for len(chars) < f.Length {
+				chars = append(chars, ' ')
+			}
+		}
+		for k, c := range chars {
+			binary.LittleEndian.PutUint16(value[start+k*2:], c)
+		}
+	case "N":
+		s, _ := v.(string)
+		digits, err := number(s, f.Length, f.Precision)
+		if err != nil {
+			return nil, errors.New(strings.Join([]string{"Value of", f.Name, err.Error()}, " "))
+		}
+		for k, d := range digits {
+			if k%2 == 0 {
+				value[k/2] |= d << 4
+			} else {
+				value[k/2] |= d
+			}
+		}
+	case "L":
+		if b, _ := v.(bool); b {
+			value[0] = 1
+		}
+	case "DT":
+		d, _ := v.(time.Time)
+		s := d.Format("20060102150405")
+		for k := 0; k < 7; k++ {
+			value[k] = (s[k*2]-'0')<<4 | (s[k*2+1] - '0')
+		}
+	case "B", "RV":
+		b, _ := v.([]byte)
+		copy(value, b)
+	case "I", "NT":
+		var b []byte
+		switch v := v.(type) {
+		case BlobRef:
+			binary.LittleEndian.PutUint32(value[:4], v.Chunk)
+			binary.LittleEndian.PutUint32(value[4:8], v.Lenth)
+		case []byte:
+			b = v
+		case string:
+			b = []byte(v)
+			if f.Type == "NT" {
+				b = nil
+				for _, c := range utf16.Encode([]rune(v)) {
+					b = binary.LittleEndian.AppendUint16(b, c)
+				}
+			}
+		}
+		if len(b) > 0 {
+			binary.LittleEndian.PutUint32(value[:4], blobs.add(b))
+			binary.LittleEndian.PutUint32(value[4:8], uint32(len(b)))
+		}
+	}
+	if f.Null {
+		return append([]byte{1}, value...), nil
+	}
+	return value, nil
+}
+
+// number returns sign and length digits of decimal s with precision digits after point
+func number(s string, length int, precision int) ([]byte, error) {
+	sign := byte(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = 0, s[1:]
+	}
+	integer, fraction, _ := strings.Cut(s, ".")
+	if len(fraction) > precision {
+		return nil, errors.New(strings.Join([]string{s, "has more than", strconv.Itoa(precision), "digits after point"}, " "))
+	}
+	fraction += strings.Repeat("0", precision-len(fraction))
+	all := strings.TrimLeft(integer, "0") + fraction
+	if len(all) > length {
+		return nil, errors.New(strings.Join([]string{s, "has more than", strconv.Itoa(length), "digits"}, " "))
+	}
+	all = strings.Repeat("0", length-len(all)) + all
+	digits := []byte{sign}
+	for _, c := range all {
+		if c < '0' || c > '9' {
+			return nil, errors.New(strings.Join([]string{s, "is not a number"}, " "))
+		}
+		digits = append(digits, byte(c-'0'))
+	}
+	return digits, nil
+}
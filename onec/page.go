@@ -0,0 +1,147 @@
+package onec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Owners of pages which are not objects of tables
+const (
+	OwnerBaseHeader = "header of base"
+	OwnerFreePages  = "free pages"
+	OwnerRoot       = "root"
+)
+
+// Index of page in PageOwner: header of object and page of page table (fat level 1)
+const (
+	PageHeader    = -1
+	PagePageTable = -2
+)
+
+// PageOwner is object which page belongs to: Object is one of owners above or "TABLE data", "TABLE blob", "TABLE index",
+// Table is TABLE for objects of tables. Header is header page of object, Index is number of page among data pages of object,
+// PageHeader or PagePageTable
+type PageOwner struct {
+	Object string `json:"object"`
+	Table  string `json:"table,omitempty"`
+	Header uint32 `json:"header"`
+	Index  int    `json:"index"`
+}
+
+// ObjectHeader is the first page of object: signature 0x1CFD, fat level, versions, length and page table.
+// Pages are data pages for fat level 0 and pages of page table for fat level 1
+type ObjectHeader struct {
+	FatLevel byte
+	Versions [3]uint32
+	Length   uint64
+	Pages    []uint32
+}
+
+// BlobChunk is a chunk of blob object: number of chunk in object, number of next chunk of blob
+// (0 for last chunk) and size of data in chunk
+type BlobChunk struct {
+	Number uint32
+	Next   uint32
+	Size   uint16
+}
+
+// ReadPage reads page n, pages out of file are errors
+func (BO *BaseOnec) ReadPage(n uint32) ([]byte, error) {
+	pageSize := uint64(BO.HeadDB.PageSize)
+	size, err := BO.Size()
+	if err != nil {
+		return nil, err
+	}
+	if pageSize == 0 || (uint64(n)+1)*pageSize > uint64(size) {
+		return nil, errors.New(strings.Join([]string{"Page", strconv.FormatUint(uint64(n), 10), "is out of base"}, " "))
+	}
+	return ReadBytes(BO.Db, uint64(n)*pageSize, uint32(pageSize), nil), nil
+}
+
+// ParseObjectHeader parses page as header of object, ok is false when page has no signature of object or fat level is unknown
+func ParseObjectHeader(page []byte) (ObjectHeader, bool) {
+	h := ObjectHeader{}
+	if len(page) < 24 || !bytes.Equal(page[:2], objectSignature) || page[2] > 1 {
+		return h, false
+	}
+	h.FatLevel = page[2]
+	for k := range h.Versions {
+		h.Versions[k] = binary.LittleEndian.Uint32(page[4+k*4 : 8+k*4])
+	}
+	h.Length = binary.LittleEndian.Uint64(page[16:24])
+	count := int(ObjectPageTableSize(h.FatLevel, h.Length, uint64(len(page))))
+	for k := 0; k < count && 24+(k+1)*4 <= len(page); k++ {
+		h.Pages = append(h.Pages, binary.LittleEndian.Uint32(page[24+k*4:24+(k+1)*4]))
+	}
+	return h, true
+}
+
+// ObjectPageTableSize is the number of entries of page table in header of object:
+// data pages for fat level 0, pages of page table for fat level 1 (each of them lists pageSize/4 data pages)
+func ObjectPageTableSize(fatLevel byte, length uint64, pageSize uint64) uint64 {
+	count := (length + pageSize - 1) / pageSize
+	if fatLevel == 1 {
+		perPage := pageSize / 4
+		return (count + perPage - 1) / perPage
+	}
+	return count
+}
+
+// PageOwners maps pages of objects of base to their objects: header of base, free pages, root and objects of tables
+func (BO *BaseOnec) PageOwners() map[uint32]PageOwner {
+	owners := map[uint32]PageOwner{0: {Object: OwnerBaseHeader, Index: PageHeader}}
+	own := func(name string, table string, header uint32, data bool) {
+		page, err := BO.ReadPage(header)
+		if err != nil {
+			return
+		}
+		h, ok := ParseObjectHeader(page)
+		if !ok {
+			return
+		}
+		owners[header] = PageOwner{name, table, header, PageHeader}
+		if !data {
+			return
+		}
+		if h.FatLevel == 1 {
+			for _, p := range h.Pages {
+				owners[p] = PageOwner{name, table, header, PagePageTable}
+			}
+		}
+		for k, p := range ReadBlockOfReplacemant(BO, int(header)) {
+			owners[p] = PageOwner{name, table, header, k}
+		}
+	}
+	own(OwnerFreePages, "", FreePagesOffset, false)
+	own(OwnerRoot, "", uint32(RootObjectOffset), true)
+	for _, v := range BO.TablesName {
+		t := BO.TableDescription[v]
+		for _, o := range []struct {
+			name   string
+			offset int
+		}{{"data", t.DataOffset}, {"blob", t.BlobOffset}, {"index", t.IndexOffset}} {
+			if o.offset > 0 {
+				own(v+" "+o.name, v, uint32(o.offset), true)
+			}
+		}
+	}
+	return owners
+}
+
+// BlobChunks parses chunks of page which is data page index of blob object (or root object)
+func BlobChunks(page []byte, index int) []BlobChunk {
+	perPage := len(page) / int(BlobChunkSize)
+	chunks := make([]BlobChunk, 0, perPage)
+	for k := 0; k < perPage; k++ {
+		chunk := page[k*int(BlobChunkSize) : (k+1)*int(BlobChunkSize)]
+		chunks = append(chunks, BlobChunk{
+			Number: uint32(index*perPage + k),
+			Next:   binary.LittleEndian.Uint32(chunk[:4]),
+			Size:   binary.LittleEndian.Uint16(chunk[4:6]),
+		})
+	}
+	return chunks
+}
@@ -0,0 +1,52 @@
+package onec
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseObjectHeader(t *testing.T) {
+	page := make([]byte, 4096)
+	copy(page, []byte{0x1c, 0xfd, 0, 0})
+	binary.LittleEndian.PutUint32(page[4:], 7)
+	binary.LittleEndian.PutUint64(page[16:], 4096*2+1)
+	for k, v := range []uint32{10, 11, 12} {
+		binary.LittleEndian.PutUint32(page[24+k*4:], v)
+	}
+	h, ok := ParseObjectHeader(page)
+	if !ok || h.FatLevel != 0 || h.Versions[0] != 7 || h.Length != 4096*2+1 || len(h.Pages) != 3 || h.Pages[2] != 12 {
+		t.Errorf("ParseObjectHeader: got %v %v", h, ok)
+	}
+
+	page[2] = 1
+	binary.LittleEndian.PutUint64(page[16:], 4096*1025)
+	h, ok = ParseObjectHeader(page)
+	if !ok || len(h.Pages) != 2 || h.Pages[1] != 11 {
+		t.Errorf("ParseObjectHeader fat level 1: got %v %v", h, ok)
+	}
+
+	page[2] = 2
+	if _, ok = ParseObjectHeader(page); ok {
+		t.Error("ParseObjectHeader: unknown fat level is parsed")
+	}
+	if _, ok = ParseObjectHeader(make([]byte, 4096)); ok {
+		t.Error("ParseObjectHeader: page without signature is parsed")
+	}
+
+	if n := ObjectPageTableSize(0, 0, 4096); n != 0 {
+		t.Errorf("ObjectPageTableSize of empty object: got %d", n)
+	}
+	if n := ObjectPageTableSize(1, 4096*1024, 4096); n != 1 {
+		t.Errorf("ObjectPageTableSize fat level 1: got %d", n)
+	}
+}
+
+func TestBlobChunks(t *testing.T) {
+	page := make([]byte, 4096)
+	binary.LittleEndian.PutUint32(page[256:], 40)
+	binary.LittleEndian.PutUint16(page[256+4:], 250)
+	chunks := BlobChunks(page, 2)
+	if len(chunks) != 16 || chunks[1].Number != 33 || chunks[1].Next != 40 || chunks[1].Size != 250 {
+		t.Errorf("BlobChunks: got %v", chunks[:2])
+	}
+}
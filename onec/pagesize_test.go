@@ -0,0 +1,162 @@
+package onec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPageSizeBase has table of records crossing pages, blob larger than page of 64K and so many tables
+// that list of tables in root object takes several chunks
+func testPageSizeBase(pageSize int, rows int, blob []byte) onectest.Base {
+	table := onectest.Table{
+		Name: "_REFERENCE1",
+		Fields: []onectest.Field{
+			{Name: "_IDRREF", Type: "B", Length: 16},
+			{Name: "_VERSION", Type: "RV"},
+			{Name: "_DESCRIPTION", Type: "NVC", Length: 50},
+			{Name: "_CODE", Type: "NC", Length: 9},
+			{Name: "_SUM", Type: "N", Null: true, Length: 15, Precision: 2},
+			{Name: "_DATE", Type: "DT"},
+			{Name: "_MARKED", Type: "L"},
+			{Name: "_TEXT", Type: "NT", Null: true},
+			{Name: "_DATA", Type: "I", Null: true},
+		},
+		Indexes: ",\n{\"_IDRREF\",\"1\",\n{\"_IDRREF\",16}\n}",
+	}
+	for n := 1; n <= rows; n++ {
+		if n%7 == 0 {
+			table.Rows = append(table.Rows, onectest.Row{Deleted: true})
+			continue
+		}
+		values := map[string]interface{}{
+			"_IDRREF":      []byte{byte(n >> 8), byte(n)},
+			"_DESCRIPTION": "Элемент " + strconv.Itoa(n),
+			"_CODE":        strconv.Itoa(n),
+			"_SUM":         "-" + strconv.Itoa(n) + ".25",
+			"_DATE":        time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(n) * time.Hour),
+			"_MARKED":      n%2 == 0,
+			"_TEXT":        strings.Repeat("текст ", n%50),
+		}
+		if n == 1 {
+			values["_DATA"] = blob
+		}
+		table.Rows = append(table.Rows, onectest.Row{Values: values})
+	}
+
+	b := onectest.Base{PageSize: pageSize, Tables: []onectest.Table{table}}
+	for k := 1; k <= 70; k++ {
+		b.Tables = append(b.Tables, onectest.Table{
+			Name:   "_CONST" + strconv.Itoa(k),
+			Fields: []onectest.Field{{Name: "_FLD" + strconv.Itoa(k), Type: "N", Length: 10}},
+			Rows:   []onectest.Row{{Values: map[string]interface{}{"_FLD" + strconv.Itoa(k): strconv.Itoa(k)}}},
+		})
+	}
+	return b
+}
+
+func TestPageSizes(t *testing.T) {
+	blob := make([]byte, 70000)
+	for k := range blob {
+		blob[k] = byte(k * 7)
+	}
+	rows := 500
+	for _, pageSize := range []int{4096, 8192, 16384, 32768, 65536} {
+		t.Run(strconv.Itoa(pageSize), func(t *testing.T) {
+			BO := onectest.Open(t, testPageSizeBase(pageSize, rows, blob))
+			if BO.HeadDB.PageSize != uint32(pageSize) {
+				t.Fatalf("page size %d, expected %d", BO.HeadDB.PageSize, pageSize)
+			}
+			if len(BO.TablesName) != 71 {
+				t.Fatalf("got %d tables, expected 71: %v", len(BO.TablesName), BO.TablesName)
+			}
+			if p := BO.Verify(nil); len(p) != 0 {
+				t.Fatal("unexpected problems", p)
+			}
+
+			table := BO.TableDescription["_REFERENCE1"]
+			if len(table.Indexes) != 1 || table.Fields["_VERSION"].DataFieldOffset != 1 {
+				t.Fatalf("unexpected description %+v", table)
+			}
+			ts := BO.TableStats("_REFERENCE1")
+			if ts.Rows != rows+1 || ts.Deleted != rows/7 || ts.Live != rows-rows/7 || ts.LastRow != rows {
+				t.Fatalf("unexpected stats %+v", ts)
+			}
+			if ts.DataPages < 2 && pageSize < 65536 {
+				t.Fatalf("records should take several pages %+v", ts)
+			}
+
+			for _, n := range []int{1, 2, 7, 100, rows - 1, rows} {
+				obj := BO.Rows("_REFERENCE1", n, true)
+				if n%7 == 0 {
+					if !obj.Deleted {
+						t.Fatalf("row %d should be deleted", n)
+					}
+					continue
+				}
+				if v := BO.Value(obj, "_DESCRIPTION", false); v != "Элемент "+strconv.Itoa(n) {
+					t.Errorf("row %d: description %v", n, v)
+				}
+				if v := BO.Value(obj, "_SUM", false); v != json.Number("-"+strconv.Itoa(n)+".25") {
+					t.Errorf("row %d: sum %v", n, v)
+				}
+				if v, _ := BO.Value(obj, "_DATE", false).(time.Time); v.Hour() != n%24 {
+					t.Errorf("row %d: date %v", n, v)
+				}
+				if v := BO.Value(obj, "_MARKED", false); v != (n%2 == 0) {
+					t.Errorf("row %d: marked %v", n, v)
+				}
+				if v, _ := BO.Value(obj, "_TEXT", true).(string); v != strings.Repeat("текст ", n%50) {
+					t.Errorf("row %d: text %q", n, v)
+				}
+			}
+			raw, err := BO.BlobOf(BO.Rows("_REFERENCE1", 1, false), "_DATA")
+			if err != nil || !bytes.Equal(raw, blob) {
+				t.Fatalf("blob of %d bytes, expected %d: %v", len(raw), len(blob), err)
+			}
+			if v := BO.Value(BO.Rows("_CONST70", 1, false), "_FLD70", false); v != json.Number("70") {
+				t.Errorf("constant %v", v)
+			}
+
+			owners := BO.PageOwners()
+			if owners[uint32(onec.RootObjectOffset)].Object != onec.OwnerRoot || owners[uint32(table.BlobOffset)].Object != "_REFERENCE1 blob" {
+				t.Fatalf("unexpected owners of pages %v", owners)
+			}
+			if len(owners) != int(BO.HeadDB.NumberOfPages) {
+				t.Fatalf("%d pages have owners, base has %d", len(owners), BO.HeadDB.NumberOfPages)
+			}
+		})
+	}
+}
+
+func TestPageSizeFatLevel1(t *testing.T) {
+	blob := make([]byte, 5<<20)
+	for k := range blob {
+		blob[k] = byte(k / 251)
+	}
+	BO := onectest.Open(t, testPageSizeBase(4096, 10, blob))
+	table := BO.TableDescription["_REFERENCE1"]
+	page, err := BO.ReadPage(uint32(table.BlobOffset))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := onec.ParseObjectHeader(page); h.FatLevel != 1 {
+		t.Fatalf("blob object has fat level %d", h.FatLevel)
+	}
+	if p := BO.Verify(nil); len(p) != 0 {
+		t.Fatal("unexpected problems", p)
+	}
+	raw, err := BO.BlobOf(BO.Rows("_REFERENCE1", 1, false), "_DATA")
+	if err != nil || !bytes.Equal(raw, blob) {
+		t.Fatalf("blob of %d bytes, expected %d: %v", len(raw), len(blob), err)
+	}
+	ts := BO.TableStats("_REFERENCE1")
+	if ts.BlobPages != int(onec.ObjectPageTableSize(0, ts.BlobSize, 4096)+onec.ObjectPageTableSize(1, ts.BlobSize, 4096))+1 {
+		t.Fatalf("unexpected stats %+v", ts)
+	}
+}
@@ -0,0 +1,30 @@
+package onec
+
+// MaskedValue represents value of masked field
+const MaskedValue = "***"
+
+// Restrict returns view of base with tables for which allow is true, fields for which mask is true are masked.
+// View shares file of base, tables of view are copies
+func (BO *BaseOnec) Restrict(allow func(table string) bool, mask func(table string, field string) bool) *BaseOnec {
+	view := &BaseOnec{
+		Db:               BO.Db,
+		HeadDB:           BO.HeadDB,
+		TableDescription: make(map[string]Table, len(BO.TablesName)),
+		TablesName:       make([]string, 0, len(BO.TablesName)),
+	}
+	for _, v := range BO.TablesName {
+		if !allow(v) {
+			continue
+		}
+		t := BO.TableDescription[v]
+		fields := make(map[string]Field, len(t.Fields))
+		for name, f := range t.Fields {
+			f.Masked = f.Masked || mask(v, name)
+			fields[name] = f
+		}
+		t.Fields = fields
+		view.TableDescription[v] = t
+		view.TablesName = append(view.TablesName, v)
+	}
+	return view
+}
@@ -0,0 +1,38 @@
+package onec
+
+import "testing"
+
+func TestRestrict(t *testing.T) {
+	b := &BaseOnec{TableDescription: map[string]Table{
+		"V8USERS":     {Name: "V8USERS", Fields: map[string]Field{"NAME": {Name: "NAME", FieldType: "NVC"}, "DATA": {Name: "DATA", FieldType: "I"}}},
+		"_REFERENCE1": {Name: "_REFERENCE1", Fields: map[string]Field{"_DESCRIPTION": {Name: "_DESCRIPTION", FieldType: "NVC"}}},
+		"CONFIG":      {Name: "CONFIG"},
+	}, TablesName: []string{"CONFIG", "V8USERS", "_REFERENCE1"}}
+
+	view := b.Restrict(func(table string) bool {
+		return table != "CONFIG"
+	}, func(table string, field string) bool {
+		return table == "V8USERS" && field == "DATA"
+	})
+	if len(view.TablesName) != 2 || view.TablesName[0] != "V8USERS" {
+		t.Fatalf("Restrict: got tables %v", view.TablesName)
+	}
+	if _, ok := view.TableDescription["CONFIG"]; ok {
+		t.Errorf("Restrict: denied table is in view")
+	}
+	users := view.TableDescription["V8USERS"]
+	if !users.Fields["DATA"].Masked || users.Fields["NAME"].Masked {
+		t.Errorf("Restrict: got fields %v", users.Fields)
+	}
+	if b.TableDescription["V8USERS"].Fields["DATA"].Masked {
+		t.Errorf("Restrict changed fields of base")
+	}
+
+	obj := Object{Table: &users, ValueObject: map[string][]byte{"DATA": make([]byte, 8)}}
+	if v := view.Value(obj, "DATA", true); v != nil {
+		t.Errorf("Value of masked field: got %v", v)
+	}
+	if v := view.BlobRaw(obj, "DATA"); v != nil {
+		t.Errorf("BlobRaw of masked field: got %v", v)
+	}
+}
@@ -0,0 +1,171 @@
+package onec_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDecodersTable has fields of all types, row 1 - values, row 2 - NULL and zero values, row 3 - deleted, row 4 - empty
+func testDecodersTable() onectest.Table {
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	w.Write([]byte(strings.Repeat("text of blob ", 10)))
+	w.Close()
+	return onectest.Table{
+		Name: "_DOCUMENT5",
+		Fields: []onectest.Field{
+			{Name: "_IDRREF", Type: "B", Length: 16},
+			{Name: "_VERSION", Type: "RV"},
+			{Name: "_NUMBER", Type: "NVC", Length: 11, CaseSensitive: true},
+			{Name: "_CODE", Type: "NC", Length: 5},
+			{Name: "_SUM", Type: "N", Null: true, Length: 10, Precision: 3},
+			{Name: "_QTY", Type: "N", Length: 5},
+			{Name: "_DATE", Type: "DT"},
+			{Name: "_POSTED", Type: "L"},
+			{Name: "_DESCR", Type: "NVC", Null: true, Length: 10},
+			{Name: "_COMMENT", Type: "NT", Null: true},
+			{Name: "_DATA", Type: "I", Null: true},
+		},
+		Indexes: ",\n{\"_IDRREF\",\"1\",\n{\"_IDRREF\",16}\n}",
+		Rows: []onectest.Row{{Values: map[string]interface{}{
+			"_IDRREF":  []byte{0xa1, 0xb2, 0xc3},
+			"_VERSION": []byte{0, 0, 0, 7},
+			"_NUMBER":  "0000-000001",
+			"_CODE":    "AB",
+			"_SUM":     "-1234567.125",
+			"_QTY":     "42",
+			"_DATE":    time.Date(2023, 5, 17, 13, 45, 9, 0, time.UTC),
+			"_POSTED":  true,
+			"_DESCR":   "Описание",
+			"_COMMENT": "Комментарий",
+			"_DATA":    deflated.Bytes(),
+		}}, {Values: map[string]interface{}{
+			"_QTY": "0",
+		}}, {Deleted: true}, {Empty: true}},
+	}
+}
+
+func TestDecoders(t *testing.T) {
+	BO := onectest.Open(t, onectest.Base{Tables: []onectest.Table{testDecodersTable()}})
+	table := BO.TableDescription["_DOCUMENT5"]
+	if !table.Fields["_NUMBER"].CaseSensitive || table.Fields["_CODE"].CaseSensitive || table.Fields["_SUM"].Precision != 3 {
+		t.Fatalf("unexpected description %+v", table.Fields)
+	}
+	if BO.RowsCount("_DOCUMENT5") != 5 {
+		t.Fatalf("got %d rows, expected 5", BO.RowsCount("_DOCUMENT5"))
+	}
+
+	obj := BO.Rows("_DOCUMENT5", 1, true)
+	represent := map[string]string{
+		"_NUMBER":  "0000-000001",
+		"_CODE":    "AB   ",
+		"_SUM":     "-1234567.125",
+		"_QTY":     "42",
+		"_DATE":    "2023.05.17 13:45:09",
+		"_POSTED":  "true",
+		"_DESCR":   "Описание",
+		"_COMMENT": "Комментарий",
+		"_DATA":    strings.Repeat("text of blob ", 10),
+		"_IDRREF":  " 0xa1 0xb2 0xc3" + strings.Repeat(" 0x00", 13),
+	}
+	for k, v := range represent {
+		if obj.RepresentObject[k] != v {
+			t.Errorf("%s is %q, expected %q", k, obj.RepresentObject[k], v)
+		}
+	}
+	values := map[string]interface{}{
+		"_NUMBER":  "0000-000001",
+		"_SUM":     json.Number("-1234567.125"),
+		"_DATE":    time.Date(2023, 5, 17, 13, 45, 9, 0, time.UTC),
+		"_POSTED":  true,
+		"_COMMENT": "Комментарий",
+		"_DATA":    strings.Repeat("text of blob ", 10),
+		"_IDRREF":  "a1b2c3" + strings.Repeat("00", 13),
+		"_VERSION": "00000007" + strings.Repeat("00", 12),
+	}
+	for k, v := range values {
+		if value := BO.Value(obj, k, true); value != v {
+			t.Errorf("value of %s is %#v, expected %#v", k, value, v)
+		}
+	}
+	if link := BO.Rows("_DOCUMENT5", 1, false).RepresentObject["_DATA"]; !strings.HasPrefix(link, "/blob/"+strconv.Itoa(table.BlobOffset)+"/") {
+		t.Errorf("link to blob %q", link)
+	}
+
+	obj = BO.Rows("_DOCUMENT5", 2, true)
+	for _, k := range []string{"_SUM", "_DESCR", "_COMMENT", "_DATA"} {
+		if v := BO.Value(obj, k, true); v != nil || obj.RepresentObject[k] != "" {
+			t.Errorf("%s of NULL is %#v %q", k, v, obj.RepresentObject[k])
+		}
+	}
+	if v := BO.Value(obj, "_QTY", false); v != json.Number("0") {
+		t.Errorf("zero is %#v", v)
+	}
+	if v := BO.Value(obj, "_POSTED", false); v != false {
+		t.Errorf("false is %#v", v)
+	}
+	if v := BO.Value(obj, "_DATE", false); v != "0000.00.00 00:00:00" {
+		t.Errorf("empty date is %#v", v)
+	}
+	if obj := BO.Rows("_DOCUMENT5", 3, true); !obj.Deleted {
+		t.Error("row 3 should be deleted")
+	}
+	if obj := BO.Rows("_DOCUMENT5", 4, true); !obj.NotExist {
+		t.Error("row 4 should be empty")
+	}
+	if ts := BO.TableStats("_DOCUMENT5"); ts.Live != 2 || ts.Deleted != 1 || ts.Empty != 1 || ts.LastRow != 2 {
+		t.Errorf("unexpected stats %+v", ts)
+	}
+}
+
+// TestConcurrentReads reads rows, blobs and stats of one base from several goroutines, it is run with -race
+func TestConcurrentReads(t *testing.T) {
+	blob := bytes.Repeat([]byte("blob of concurrent reads "), 1000)
+	BO := onectest.Open(t, testPageSizeBase(4096, 100, blob))
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 1; n <= 100; n += 1 + g {
+				obj := BO.Rows("_REFERENCE1", n, true)
+				if n%7 != 0 && BO.Value(obj, "_DESCRIPTION", false) != "Элемент "+strconv.Itoa(n) {
+					t.Errorf("goroutine %d: row %d", g, n)
+				}
+			}
+			if raw, err := BO.BlobOf(BO.Rows("_REFERENCE1", 1, false), "_DATA"); err != nil || !bytes.Equal(raw, blob) {
+				t.Errorf("goroutine %d: blob of %d bytes: %v", g, len(raw), err)
+			}
+			BO.TableStats("_CONST" + strconv.Itoa(g+1))
+			BO.Rows("_CONST"+strconv.Itoa(g+1), 1, false)
+			if obj := BO.Rows("_UNKNOWN", 1, false); obj.Table != nil { //unknown table must not be added to map of tables
+				t.Errorf("goroutine %d: row of unknown table %+v", g, obj)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if _, ok := BO.TableDescription["_UNKNOWN"]; ok {
+		t.Error("unknown table is added to base")
+	}
+}
+
+func BenchmarkBOReader(b *testing.B) {
+	data, err := testPageSizeBase(4096, 1000, []byte("blob")).Bytes()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := onec.DatabaseReader(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
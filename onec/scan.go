@@ -0,0 +1,172 @@
+package onec
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Operations of Condition
+const (
+	OpEquals   = "eq"
+	OpContains = "contains"
+	OpRange    = "range"
+	OpNull     = "null"
+	OpNotNull  = "notnull"
+)
+
+// Condition on one field. For OpRange Value is lower bound and To is upper bound, empty bound is open
+type Condition struct {
+	Field string
+	Op    string
+	Value string
+	To    string
+}
+
+// Filter selects records: all conditions match and, if Search is set, any string field contains it
+type Filter struct {
+	Conditions []Condition
+	Search     string
+}
+
+// Scan calls fn for every live record of table s from row number from, stops when fn returns false.
+// Records are read one by one, so memory does not depend on size of table
+func (BO *BaseOnec) Scan(s string, from int, blobValue bool, fn func(Object) bool) {
+	count := BO.RowsCount(s)
+	for n := Max(from, 0); n < count; n++ {
+		obj := BO.Rows(s, n, blobValue)
+		if obj.Table == nil {
+			return
+		}
+		if obj.NotExist || obj.Deleted {
+			continue
+		}
+		if !fn(obj) {
+			return
+		}
+	}
+}
+
+// ScanFilter calls fn for every live record of table s which matches filter
+func (BO *BaseOnec) ScanFilter(s string, from int, filter Filter, fn func(Object) bool) {
+	BO.Scan(s, from, false, func(obj Object) bool {
+		if !filter.Match(obj) {
+			return true
+		}
+		return fn(obj)
+	})
+}
+
+// ParseCondition parses expression of column filter:
+// "null", "!null", "=value", "from..to" (N and DT), otherwise contains
+func ParseCondition(field string, expr string) Condition {
+	switch {
+	case expr == "null":
+		return Condition{Field: field, Op: OpNull}
+	case expr == "!null":
+		return Condition{Field: field, Op: OpNotNull}
+	case strings.HasPrefix(expr, "="):
+		return Condition{Field: field, Op: OpEquals, Value: expr[1:]}
+	case strings.Contains(expr, ".."):
+		bounds := strings.SplitN(expr, "..", 2)
+		return Condition{Field: field, Op: OpRange, Value: strings.TrimSpace(bounds[0]), To: strings.TrimSpace(bounds[1])}
+	}
+	return Condition{Field: field, Op: OpContains, Value: expr}
+}
+
+func (f Filter) IsEmpty() bool {
+	return len(f.Conditions) == 0 && f.Search == ""
+}
+
+func (f Filter) Match(obj Object) bool {
+	for _, c := range f.Conditions {
+		if !c.Match(obj) {
+			return false
+		}
+	}
+	if f.Search == "" {
+		return true
+	}
+	search := strings.ToLower(f.Search)
+	for _, name := range obj.Table.FieldsName {
+		switch obj.Table.Fields[name].FieldType {
+		case "NVC", "NC":
+			if strings.Contains(strings.ToLower(obj.RepresentObject[name]), search) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c Condition) Match(obj Object) bool {
+	field, ok := obj.Table.Fields[c.Field]
+	if !ok {
+		return false
+	}
+	raw := obj.ValueObject[c.Field]
+	isNull := field.NullExist && len(raw) > 0 && raw[0] == 0
+	value := obj.RepresentObject[c.Field]
+
+	switch c.Op {
+	case OpNull:
+		return isNull
+	case OpNotNull:
+		return !isNull
+	}
+	if isNull {
+		return false
+	}
+
+	switch c.Op {
+	case OpEquals:
+		if field.FieldType == "N" {
+			return compareNumbers(value, c.Value) == 0
+		}
+		if field.FieldType == "DT" {
+			return compareDates(value, c.Value) == 0
+		}
+		return strings.EqualFold(strings.TrimRight(value, " "), c.Value)
+	case OpContains:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(c.Value))
+	case OpRange:
+		cmp := compareStrings
+		switch field.FieldType {
+		case "N":
+			cmp = compareNumbers
+		case "DT":
+			cmp = compareDates
+		}
+		if c.Value != "" && cmp(value, c.Value) < 0 {
+			return false
+		}
+		if c.To != "" && cmp(value, c.To) > 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func compareStrings(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// compareNumbers compares exact decimals, N fields may have more digits than float64 keeps
+func compareNumbers(a, b string) int {
+	x, ok1 := new(big.Rat).SetString(a)
+	y, ok2 := new(big.Rat).SetString(strings.Replace(b, ",", ".", 1))
+	if !ok1 || !ok2 {
+		return strings.Compare(a, b)
+	}
+	return x.Cmp(y)
+}
+
+// compareDates compares date of DT field (2013.04.03 14:41:21) with bound,
+// bound may be shorter (2013.04.03) and use "-" as separator
+func compareDates(a, b string) int {
+	b = strings.ReplaceAll(b, "-", ".")
+	if len(b) < len(a) {
+		a = a[:len(b)]
+	}
+	return strings.Compare(a, b)
+}
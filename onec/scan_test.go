@@ -0,0 +1,75 @@
+package onec
+
+import "testing"
+
+func TestFilterMatch(t *testing.T) {
+	table := Table{
+		Fields: map[string]Field{
+			"_DESCRIPTION": {Name: "_DESCRIPTION", FieldType: "NVC"},
+			"_DATE_TIME":   {Name: "_DATE_TIME", FieldType: "DT"},
+			"_SUM":         {Name: "_SUM", FieldType: "N", NullExist: true},
+		},
+		FieldsName: []string{"_DATE_TIME", "_DESCRIPTION", "_SUM"},
+	}
+	object := Object{
+		Table:       &table,
+		ValueObject: map[string][]byte{"_SUM": {1, 0x10, 0x05}},
+		RepresentObject: map[string]string{
+			"_DESCRIPTION": "ООО Ромашка   ",
+			"_DATE_TIME":   "2013.04.03 14:41:21",
+			"_SUM":         "105",
+		},
+	}
+	nullObject := Object{
+		Table:           &table,
+		ValueObject:     map[string][]byte{"_SUM": {0, 0, 0}},
+		RepresentObject: map[string]string{"_SUM": ""},
+	}
+
+	testCases := []struct {
+		name     string
+		filter   Filter
+		object   Object
+		expected bool
+	}{
+		{"empty", Filter{}, object, true},
+		{"contains", Filter{Conditions: []Condition{ParseCondition("_DESCRIPTION", "ромаш")}}, object, true},
+		{"equals", Filter{Conditions: []Condition{ParseCondition("_DESCRIPTION", "=ооо ромашка")}}, object, true},
+		{"equals number", Filter{Conditions: []Condition{ParseCondition("_SUM", "=105.0")}}, object, true},
+		{"range number", Filter{Conditions: []Condition{ParseCondition("_SUM", "100..200")}}, object, true},
+		{"range number out", Filter{Conditions: []Condition{ParseCondition("_SUM", "..100")}}, object, false},
+		{"range date", Filter{Conditions: []Condition{ParseCondition("_DATE_TIME", "2013-01-01..2013-04-03")}}, object, true},
+		{"range date out", Filter{Conditions: []Condition{ParseCondition("_DATE_TIME", "2013.04.04..")}}, object, false},
+		{"null", Filter{Conditions: []Condition{ParseCondition("_SUM", "null")}}, nullObject, true},
+		{"not null", Filter{Conditions: []Condition{ParseCondition("_SUM", "!null")}}, nullObject, false},
+		{"null excluded from range", Filter{Conditions: []Condition{ParseCondition("_SUM", "..100")}}, nullObject, false},
+		{"search", Filter{Search: "РОМАШКА"}, object, true},
+		{"search miss", Filter{Search: "лютик"}, object, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.Match(tc.object); got != tc.expected {
+				t.Error("For", tc.filter, "expected", tc.expected, "got", got)
+			}
+		})
+	}
+}
+
+func TestCompareNumbers(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"105", "105.0", 0},
+		{"105", "100,5", 1},
+		{"-0.091", "0", -1},
+		{"12345678901234567891", "12345678901234567890", 1},
+		{"0.10000000000000000001", "0.1", 1},
+		{"99999999999999999999.99", "99999999999999999999.99", 0},
+	}
+	for _, tc := range testCases {
+		if got := compareNumbers(tc.a, tc.b); got != tc.expected {
+			t.Errorf("compareNumbers(%s, %s) = %d, expected %d", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
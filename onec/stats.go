@@ -0,0 +1,90 @@
+package onec
+
+import "encoding/binary"
+
+// TableStats: records and sizes of objects of table. Record 0 is the header of free records list,
+// so Live + Deleted + Empty = Rows - 1. Pages of object include its header page
+type TableStats struct {
+	Rows       int
+	Live       int
+	Deleted    int
+	Empty      int
+	LastRow    int // number of last live record, -1 when there is none
+	DataSize   uint64
+	BlobSize   uint64
+	IndexSize  uint64
+	DataPages  int
+	BlobPages  int
+	IndexPages int
+}
+
+// Size is the size of all objects of table in bytes
+func (ts TableStats) Size() uint64 {
+	return ts.DataSize + ts.BlobSize + ts.IndexSize
+}
+
+// TableStats reads all records of table s page by page, only deleted flag of record is checked
+func (BO *BaseOnec) TableStats(s string) TableStats {
+	t := BO.TableDescription[s]
+	ts := TableStats{LastRow: -1}
+	ts.DataSize, ts.DataPages = BO.objectSize(t.DataOffset)
+	ts.BlobSize, ts.BlobPages = BO.objectSize(t.BlobOffset)
+	ts.IndexSize, ts.IndexPages = BO.objectSize(t.IndexOffset)
+	if t.RowLength == 0 || t.DataOffset == 0 {
+		return ts
+	}
+	ts.Rows = int(ts.DataSize / uint64(t.RowLength))
+
+	BO.CheckBlockOfReplacemant(s)
+	BO.readRecords(BO.TableDescription[s], ts.Rows, func(n int, record []byte) {
+		switch {
+		case n == 0:
+		case allZero(record):
+			ts.Empty++
+		case record[0] == 1:
+			ts.Deleted++
+		default:
+			ts.Live++
+			ts.LastRow = n
+		}
+	})
+	return ts
+}
+
+// objectSize returns length of object and number of its pages with header page and pages of page table
+func (BO *BaseOnec) objectSize(offset int) (uint64, int) {
+	if offset == 0 {
+		return 0, 0
+	}
+	pageSize := uint64(BO.HeadDB.PageSize)
+	header := ReadBytes(BO.Db, pageSize*uint64(offset), 24, nil)
+	size := binary.LittleEndian.Uint64(header[16:24])
+	pages := int((size+pageSize-1)/pageSize) + 1
+	if header[2] == 1 {
+		pages += int(ObjectPageTableSize(1, size, pageSize))
+	}
+	return size, pages
+}
+
+// readRecords calls fn for first count records of table, data is read by whole pages
+func (BO *BaseOnec) readRecords(t Table, count int, fn func(n int, record []byte)) {
+	pageSize := int(BO.HeadDB.PageSize)
+	record := make([]byte, 0, t.RowLength)
+	n := 0
+	for _, block := range t.BlockOfReplacemant {
+		if n >= count {
+			return
+		}
+		page := ReadBytes(BO.Db, uint64(block)*uint64(pageSize), uint32(pageSize), nil)
+		for len(page) > 0 && n < count {
+			need := Min(t.RowLength-len(record), len(page))
+			record = append(record, page[:need]...)
+			page = page[need:]
+			if len(record) == t.RowLength {
+				fn(n, record)
+				n++
+				record = record[:0]
+			}
+		}
+	}
+}
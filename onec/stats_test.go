@@ -0,0 +1,33 @@
+package onec_test
+
+import (
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"testing"
+)
+
+func TestTableStats(t *testing.T) {
+	live := func(code string) onectest.Row {
+		return onectest.Row{Values: map[string]interface{}{"_CODE": code}}
+	}
+	b := onectest.Open(t, onectest.Base{Tables: []onectest.Table{{
+		Name:   "_REFERENCE1",
+		Fields: []onectest.Field{{Name: "_CODE", Type: "NC", Length: 5}, {Name: "_NOTE", Type: "NT", Null: true}},
+		Rows: []onectest.Row{live("1"), {Deleted: true}, {Empty: true}, live("4"), {Deleted: true},
+			{Values: map[string]interface{}{"_CODE": "6", "_NOTE": "заметка"}}, {Empty: true}},
+	}}})
+
+	table := b.TableDescription["_REFERENCE1"]
+	ts := b.TableStats("_REFERENCE1")
+	expected := onec.TableStats{Rows: 8, Live: 3, Deleted: 2, Empty: 2, LastRow: 6,
+		DataSize: uint64(8 * table.RowLength), DataPages: 2, BlobSize: ts.BlobSize, BlobPages: 2}
+	if ts != expected {
+		t.Errorf("got %+v, expected %+v", ts, expected)
+	}
+	if ts.BlobSize == 0 {
+		t.Error("blob object is not counted")
+	}
+	if empty := b.TableStats("_REFERENCE9"); empty != (onec.TableStats{LastRow: -1}) {
+		t.Errorf("unknown table: %+v", empty)
+	}
+}
@@ -0,0 +1,190 @@
+package onec
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SummaryLargest is the number of largest tables in Summary
+const SummaryLargest = 10
+
+// FreePagesOffset is the page of object which lists free pages, its length is the number of free pages
+const FreePagesOffset = 1
+
+var (
+	uuidPattern    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	versionPattern = regexp.MustCompile(`^\d+(\.\d+)+$`)
+)
+
+// Summary of base: format, pages, tables and configuration
+type Summary struct {
+	Version                 string      `json:"version"`
+	PageSize                uint32      `json:"pageSize"`
+	Pages                   int32       `json:"pages"`
+	FreePages               uint64      `json:"freePages"`
+	Tables                  int         `json:"tables"`
+	LargestTables           []TableSize `json:"largestTables"`
+	IBVersion               string      `json:"ibVersion,omitempty"`
+	PlatformVersionRequired string      `json:"platformVersionRequired,omitempty"`
+	ConfigName              string      `json:"configName,omitempty"`
+	ConfigVersion           string      `json:"configVersion,omitempty"`
+	Users                   int         `json:"users"`
+}
+
+type TableSize struct {
+	Name      string `json:"name"`
+	Size      uint64 `json:"size"`
+	DataSize  uint64 `json:"dataSize"`
+	BlobSize  uint64 `json:"blobSize"`
+	IndexSize uint64 `json:"indexSize"`
+}
+
+// Summary reads header of base, sizes of tables, IBVERSION, configuration from CONFIG and number of users in V8USERS
+func (BO *BaseOnec) Summary() Summary {
+	ver := BO.HeadDB.Ver
+	s := Summary{
+		Version:   strings.Join([]string{strconv.Itoa(int(ver[0])), strconv.Itoa(int(ver[1])), strconv.Itoa(int(ver[2])), strconv.Itoa(int(ver[3]))}, "."),
+		PageSize:  BO.HeadDB.PageSize,
+		Pages:     BO.HeadDB.NumberOfPages,
+		FreePages: ObjectLength(BO, FreePagesOffset),
+		Tables:    len(BO.TablesName),
+	}
+
+	for _, v := range BO.TablesName {
+		t := BO.TableDescription[v]
+		ts := TableSize{Name: v}
+		ts.DataSize, _ = BO.objectSize(t.DataOffset)
+		ts.BlobSize, _ = BO.objectSize(t.BlobOffset)
+		ts.IndexSize, _ = BO.objectSize(t.IndexOffset)
+		ts.Size = ts.DataSize + ts.BlobSize + ts.IndexSize
+		s.LargestTables = append(s.LargestTables, ts)
+	}
+	sort.SliceStable(s.LargestTables, func(i, j int) bool {
+		return s.LargestTables[i].Size > s.LargestTables[j].Size
+	})
+	if len(s.LargestTables) > SummaryLargest {
+		s.LargestTables = s.LargestTables[:SummaryLargest]
+	}
+
+	if _, ok := BO.TableDescription["IBVERSION"]; ok {
+		BO.Scan("IBVERSION", 0, false, func(obj Object) bool {
+			s.IBVersion = obj.RepresentObject["IBVERSION"]
+			s.PlatformVersionRequired = FormatPlatformVersion(obj.RepresentObject["PLATFORMVERSIONREQ"])
+			return false
+		})
+	}
+	s.ConfigName, s.ConfigVersion = BO.ConfigInfo()
+	if _, ok := BO.TableDescription["V8USERS"]; ok {
+		BO.Scan("V8USERS", 0, false, func(obj Object) bool {
+			s.Users++
+			return true
+		})
+	}
+	return s
+}
+
+// FormatPlatformVersion formats PLATFORMVERSIONREQ: 80316 - 8.3.16
+func FormatPlatformVersion(s string) string {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 10000 {
+		return s
+	}
+	return strconv.Itoa(v/10000) + "." + strconv.Itoa(v/100%100) + "." + strconv.Itoa(v%100)
+}
+
+// ConfigFile reads file of configuration from table CONFIG, text files are decoded
+func (BO *BaseOnec) ConfigFile(name string) (Blob, bool) {
+	return BO.tableFile("CONFIG", name)
+}
+
+// tableFile reads file name of table of files (CONFIG, PARAMS): FILENAME and BINARYDATA
+func (BO *BaseOnec) tableFile(table string, name string) (Blob, bool) {
+	var blob Blob
+	found := false
+	if _, ok := BO.TableDescription[table]; !ok {
+		return blob, false
+	}
+	BO.Scan(table, 0, false, func(obj Object) bool {
+		if !strings.EqualFold(strings.TrimSpace(obj.RepresentObject["FILENAME"]), name) {
+			return true
+		}
+		blob = DecodeBlob("I", BO.BlobRaw(obj, "BINARYDATA"))
+		found = true
+		return false
+	})
+	return blob, found
+}
+
+// ConfigInfo finds name and version of configuration: file "root" refers to file of main metadata object,
+// name is the string after {0,0,uuid} of its properties, version is the next string like 1.2.3
+func (BO *BaseOnec) ConfigInfo() (string, string) {
+	root, ok := BO.ConfigFile("root")
+	if !ok {
+		return "", ""
+	}
+	list, err := ParseInternal(root.Text)
+	if err != nil || len(list.List) < 2 || list.List[1].IsList() {
+		return "", ""
+	}
+	metadata, ok := BO.ConfigFile(list.List[1].Value)
+	if !ok {
+		return "", ""
+	}
+	tree, err := ParseInternal(metadata.Text)
+	if err != nil {
+		return "", ""
+	}
+
+	var texts []string
+	collectStrings(tree, &texts)
+	name, n := findConfigName(tree)
+	if n < 0 {
+		return "", ""
+	}
+	for _, v := range texts[n+1:] {
+		if versionPattern.MatchString(v) {
+			return name, v
+		}
+	}
+	return name, ""
+}
+
+func collectStrings(v InternalValue, result *[]string) {
+	if v.Quoted {
+		*result = append(*result, v.Value)
+	}
+	for _, item := range v.List {
+		collectStrings(item, result)
+	}
+}
+
+// findConfigName returns first string after {0,0,uuid} and its number among strings of tree
+func findConfigName(tree InternalValue) (string, int) {
+	n := 0
+	var walk func(v InternalValue) (string, bool)
+	walk = func(v InternalValue) (string, bool) {
+		for k, item := range v.List {
+			if item.Quoted {
+				if k > 0 && isMetadataID(v.List[k-1]) && item.Value != "" {
+					return item.Value, true
+				}
+				n++
+				continue
+			}
+			if name, ok := walk(item); ok {
+				return name, true
+			}
+		}
+		return "", false
+	}
+	if name, ok := walk(tree); ok {
+		return name, n
+	}
+	return "", -1
+}
+
+func isMetadataID(v InternalValue) bool {
+	return len(v.List) == 3 && v.List[0].Value == "0" && v.List[1].Value == "0" && uuidPattern.MatchString(v.List[2].Value)
+}
@@ -0,0 +1,67 @@
+package onec
+
+import "testing"
+
+func TestConfigName(t *testing.T) {
+	tree, err := ParseInternal(`{2,{1,{"Вложенный"},{0,0,9cd510cd-abfc-11d4-9434-004095e12fc7},"БухгалтерияПредприятия",{1,"ru","Бухгалтерия предприятия"},"",0,"Фирма 1С","3.0.150.23"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, n := findConfigName(tree)
+	if name != "БухгалтерияПредприятия" || n != 1 {
+		t.Errorf("findConfigName: got %s %d", name, n)
+	}
+
+	var texts []string
+	collectStrings(tree, &texts)
+	if len(texts) != 7 || texts[n+5] != "3.0.150.23" {
+		t.Errorf("collectStrings: got %v", texts)
+	}
+
+	if v := FormatPlatformVersion("80316"); v != "8.3.16" {
+		t.Errorf("FormatPlatformVersion: got %s", v)
+	}
+}
+
+func TestMetadataNames(t *testing.T) {
+	dbNames, err := ParseDBNames(`{1,
+{3,
+{00000000-0000-0000-0000-000000000000,"SystemSettings",1},
+{3b1a5a9e-8c1f-4b8e-9d2a-1f0c7d1e2a11,"Reference",45},
+{6a7c2d10-6f2e-4a1b-8f3d-0b9e1c2d3e4f,"Fld",123}
+}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dbNames) != 3 || dbNames[1].Name() != "_REFERENCE45" || dbNames[2].Name() != "_FLD123" {
+		t.Fatalf("ParseDBNames: got %v", dbNames)
+	}
+
+	tree, err := ParseInternal(`{1,{0,{0,0,3b1a5a9e-8c1f-4b8e-9d2a-1f0c7d1e2a11},"Номенклатура",{1,"ru","Номенклатура"}},` +
+		`{{0,{0,0,6a7c2d10-6f2e-4a1b-8f3d-0b9e1c2d3e4f},"Артикул",{0}}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string)
+	collectMetadataNames(tree, names)
+	m := MetadataNames{Names: make(map[string]string)}
+	for _, v := range dbNames {
+		if name, ok := names[v.UUID]; ok {
+			m.Names[v.Name()] = name
+		}
+	}
+
+	for _, v := range []struct{ name, expected string }{
+		{"_REFERENCE45", "Номенклатура"},
+		{"_Fld123", "Артикул"},
+		{"_FLD123RREF", "Артикул"},
+		{"_FLD123_TYPE", "Артикул"},
+		{"_REFERENCE45_VT67", ""},
+		{"_IDRREF", ""},
+	} {
+		if got := m.Name(v.name); got != v.expected {
+			t.Errorf("Name(%s): got %q, expected %q", v.name, got, v.expected)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package onec
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value returns typed value of field name of object:
+// nil for NULL, string (NVC, NC, NT), json.Number (N), bool (L), time.Time (DT), hex string (B, RV).
+// Blobs (I, NT) are read only when blobs is true: text as string, binary as []byte, else nil
+func (BO *BaseOnec) Value(obj Object, name string, blobs bool) interface{} {
+	field, ok := obj.Table.Fields[name]
+	if !ok || field.Masked {
+		return nil
+	}
+	value := obj.ValueObject[name]
+	if field.NullExist {
+		if len(value) == 0 || value[0] == 0 {
+			return nil
+		}
+		value = value[1:]
+	}
+
+	switch field.FieldType {
+	case "NVC", "NC":
+		return obj.RepresentObject[name]
+	case "N":
+		return json.Number(DecodeNumber(value, field))
+	case "L":
+		return value[0] != 0
+	case "DT":
+		if t, ok := DecodeDate(value); ok {
+			return t
+		}
+		return obj.RepresentObject[name]
+	case "I", "NT":
+		if !blobs {
+			return nil
+		}
+		blob := DecodeBlob(field.FieldType, BO.BlobRaw(obj, name))
+		if blob.Text != "" || blob.Kind == BlobText {
+			return blob.Text
+		}
+		return blob.Data
+	}
+	return hex.EncodeToString(value)
+}
+
+// BlobRaw reads blob of field name (I, NT) of object as stored in base, nil for NULL
+func (BO *BaseOnec) BlobRaw(obj Object, name string) []byte {
+	field, ok := obj.Table.Fields[name]
+	if !ok || field.Masked || field.FieldType != "I" && field.FieldType != "NT" {
+		return nil
+	}
+	value := obj.ValueObject[name]
+	if field.NullExist {
+		if len(value) == 0 || value[0] == 0 {
+			return nil
+		}
+		value = value[1:]
+	}
+	return BO.ReadBlob(*obj.Table, binary.LittleEndian.Uint32(value[:4]), binary.LittleEndian.Uint32(value[4:8]))
+}
+
+// BlobOf reads blob of field name (I, NT) of object as stored in base, nil for NULL.
+// Unlike BlobRaw the field and the reference to blob are validated
+func (BO *BaseOnec) BlobOf(obj Object, name string) ([]byte, error) {
+	field, ok := obj.Table.Fields[name]
+	if !ok {
+		return nil, errors.New(strings.Join([]string{"Field not found", name}, " "))
+	}
+	if field.FieldType != "I" && field.FieldType != "NT" {
+		return nil, errors.New(strings.Join([]string{"Field", name, "of type", field.FieldType, "is not a blob"}, " "))
+	}
+	if field.Masked {
+		return nil, errors.New(strings.Join([]string{"Field", name, "is masked"}, " "))
+	}
+	value := obj.ValueObject[name]
+	if field.NullExist {
+		if len(value) == 0 || value[0] == 0 {
+			return nil, nil
+		}
+		value = value[1:]
+	}
+	chunkOffset, lenth := binary.LittleEndian.Uint32(value[:4]), binary.LittleEndian.Uint32(value[4:8])
+	if err := BO.CheckBlob(*obj.Table, chunkOffset, lenth); err != nil {
+		return nil, err
+	}
+	return BO.ReadBlob(*obj.Table, chunkOffset, lenth), nil
+}
+
+// CheckBlob validates reference to blob of table: chunk is in blob object after its header chunk
+// and lenth fits in the rest of blob object
+func (BO *BaseOnec) CheckBlob(t Table, chunkOffset uint32, lenth uint32) error {
+	if lenth == 0 {
+		return nil
+	}
+	if t.BlobOffset == 0 || len(t.BlockOfReplacemantBlob) == 0 {
+		return errors.New(strings.Join([]string{"Table", t.Name, "has no blob object"}, " "))
+	}
+	chunks := ObjectLength(BO, t.BlobOffset) / uint64(BlobChunkSize)
+	if pageChunks := uint64(len(t.BlockOfReplacemantBlob)) * uint64(BO.HeadDB.PageSize) / uint64(BlobChunkSize); pageChunks < chunks {
+		chunks = pageChunks
+	}
+	if chunkOffset < BlobChunkOffset || uint64(chunkOffset) >= chunks {
+		return errors.New(strings.Join([]string{"Blob chunk", strconv.FormatUint(uint64(chunkOffset), 10), "is out of blob object of", strconv.FormatUint(chunks, 10), "chunks"}, " "))
+	}
+	if uint64(lenth) > (chunks-uint64(chunkOffset))*uint64(BlobChunkSize-6) {
+		return errors.New(strings.Join([]string{"Blob length", strconv.FormatUint(uint64(lenth), 10), "is larger than blob object"}, " "))
+	}
+	return nil
+}
+
+// DecodeDate decodes field type DT, ok is false for values which are not a date
+func DecodeDate(value []byte) (time.Time, bool) {
+	if len(value) < 7 {
+		return time.Time{}, false
+	}
+	d := make([]int, 7)
+	for i, b := range value[:7] {
+		if b>>4 > 9 || b&0x0f > 9 {
+			return time.Time{}, false
+		}
+		d[i] = int(b>>4)*10 + int(b&0x0f)
+	}
+	year := d[0]*100 + d[1]
+	if d[2] < 1 || d[2] > 12 || d[3] < 1 || d[3] > 31 || d[4] > 23 || d[5] > 59 || d[6] > 59 {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month(d[2]), d[3], d[4], d[5], d[6], 0, time.UTC), true
+}
+
+// FormatValue formats typed value from Value as text
+func FormatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format("2006-01-02T15:04:05")
+	case []byte:
+		return hex.EncodeToString(v)
+	}
+	return ""
+}
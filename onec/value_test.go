@@ -0,0 +1,61 @@
+package onec_test
+
+import (
+	"bytes"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"strings"
+	"testing"
+)
+
+func TestBlobOf(t *testing.T) {
+	blob := bytes.Repeat([]byte{1, 2, 3}, 300)
+	BO := onectest.Open(t, onectest.Base{Tables: []onectest.Table{{
+		Name: "_REFERENCE2",
+		Fields: []onectest.Field{
+			{Name: "_DESCRIPTION", Type: "NVC", Length: 10},
+			{Name: "_DATA", Type: "I", Null: true},
+		},
+		Rows: []onectest.Row{
+			{Values: map[string]interface{}{"_DATA": blob}},
+			{},
+			{Values: map[string]interface{}{"_DATA": onectest.BlobRef{Chunk: 0, Lenth: 10}}},
+			{Values: map[string]interface{}{"_DATA": onectest.BlobRef{Chunk: 100, Lenth: 10}}},
+			{Values: map[string]interface{}{"_DATA": onectest.BlobRef{Chunk: 1, Lenth: 100000}}},
+		},
+	}}})
+
+	testCases := []struct {
+		name  string
+		row   int
+		field string
+		data  []byte
+		err   string
+	}{
+		{"blob", 1, "_DATA", blob, ""},
+		{"NULL", 2, "_DATA", nil, ""},
+		{"unknown field", 1, "_CODE", nil, "Field not found _CODE"},
+		{"not a blob", 1, "_DESCRIPTION", nil, "Field _DESCRIPTION of type NVC is not a blob"},
+		{"header chunk", 3, "_DATA", nil, "Blob chunk 0 is out of blob object"},
+		{"chunk out of object", 4, "_DATA", nil, "Blob chunk 100 is out of blob object"},
+		{"too long", 5, "_DATA", nil, "Blob length 100000 is larger than blob object"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := BO.BlobOf(BO.Rows("_REFERENCE2", tc.row, false), tc.field)
+			if tc.err == "" && err != nil || tc.err != "" && (err == nil || !strings.HasPrefix(err.Error(), tc.err)) {
+				t.Fatalf("error %v, expected %q", err, tc.err)
+			}
+			if !bytes.Equal(data, tc.data) {
+				t.Errorf("got %d bytes, expected %d", len(data), len(tc.data))
+			}
+		})
+	}
+
+	if err := BO.CheckBlob(onec.Table{Name: "_REFERENCE2"}, 1, 10); err == nil {
+		t.Error("table without blob object should be an error")
+	}
+	if err := BO.CheckBlob(onec.Table{}, 100, 0); err != nil {
+		t.Error("empty blob should not be an error", err)
+	}
+}
@@ -0,0 +1,156 @@
+package onec
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ValueStorage is an unwrapped value of ХранилищеЗначения
+type ValueStorage struct {
+	TypeID string // uuid of inner type from {"#",<uuid>,...}
+	Kind   string // kind of Data by SniffBlob
+	Data   []byte // inner bytes: picture, binary data or text of structure
+	Nested int    // number of unwrapped storages around Data
+}
+
+// InternalValue is a node of 1C internal format: {"#",uuid,{...}}
+// List is nil for scalar values
+type InternalValue struct {
+	Value  string
+	Quoted bool
+	List   []InternalValue
+}
+
+func (v InternalValue) IsList() bool {
+	return v.List != nil
+}
+
+// DecodeValueStorage unwraps serialized ValueStorage from raw blob of I field
+func DecodeValueStorage(raw []byte) (ValueStorage, error) {
+	vs := ValueStorage{}
+	data := raw
+	for depth := 0; depth < 16; depth++ {
+		blob := DecodeBlob("I", data)
+		if blob.Kind != BlobValueStorage {
+			if depth == 0 {
+				return vs, errors.New(strings.Join([]string{"Blob is not a value storage, kind", blob.Kind}, " "))
+			}
+			vs.Kind = blob.Kind
+			vs.Data = blob.Data
+			return vs, nil
+		}
+
+		root, err := ParseInternal(blob.Text)
+		if err != nil {
+			return vs, err
+		}
+		if !root.IsList() || len(root.List) < 2 || root.List[0].Value != "#" {
+			return vs, errors.New("The format of value storage is not valid")
+		}
+		vs.TypeID = root.List[1].Value
+		vs.Nested = depth
+
+		payload, ok := findBase64(root.List[2:])
+		if !ok { //structure without binary data
+			vs.Kind = BlobText
+			vs.Data = []byte(blob.Text)
+			return vs, nil
+		}
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return vs, err
+		}
+	}
+	return vs, errors.New("Value storage nesting is too deep")
+}
+
+// findBase64 returns first {#base64:...} value
+func findBase64(list []InternalValue) (string, bool) {
+	for _, v := range list {
+		if v.IsList() {
+			if s, ok := findBase64(v.List); ok {
+				return s, ok
+			}
+			continue
+		}
+		if !v.Quoted && strings.HasPrefix(v.Value, "#base64:") {
+			s := strings.TrimPrefix(v.Value, "#base64:")
+			s = strings.NewReplacer("\r", "", "\n", "", " ", "").Replace(s)
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// ParseInternal parses text in 1C internal format (brace lists)
+func ParseInternal(s string) (InternalValue, error) {
+	p := internalParser{s: strings.TrimPrefix(s, "\ufeff")}
+	p.skipSpace()
+	return p.value()
+}
+
+type internalParser struct {
+	s   string
+	pos int
+}
+
+func (p *internalParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *internalParser) value() (InternalValue, error) {
+	if p.pos >= len(p.s) {
+		return InternalValue{}, errors.New("Unexpected end of internal format")
+	}
+	switch p.s[p.pos] {
+	case '{':
+		p.pos++
+		list := make([]InternalValue, 0, 4)
+		for {
+			p.skipSpace()
+			if p.pos < len(p.s) && p.s[p.pos] == '}' {
+				p.pos++
+				return InternalValue{List: list}, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return v, err
+			}
+			list = append(list, v)
+			p.skipSpace()
+			if p.pos >= len(p.s) {
+				return InternalValue{}, errors.New("Unexpected end of internal format")
+			}
+			switch p.s[p.pos] {
+			case ',':
+				p.pos++
+			case '}':
+			default:
+				return InternalValue{}, errors.New(strings.Join([]string{"Unexpected symbol in internal format", string(p.s[p.pos])}, " "))
+			}
+		}
+	case '"':
+		var b strings.Builder
+		for p.pos++; p.pos < len(p.s); p.pos++ {
+			if p.s[p.pos] == '"' {
+				if p.pos+1 < len(p.s) && p.s[p.pos+1] == '"' {
+					b.WriteByte('"')
+					p.pos++
+					continue
+				}
+				p.pos++
+				return InternalValue{Value: b.String(), Quoted: true}, nil
+			}
+			b.WriteByte(p.s[p.pos])
+		}
+		return InternalValue{}, errors.New("Unterminated string in internal format")
+	}
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != ',' && p.s[p.pos] != '}' {
+		p.pos++
+	}
+	return InternalValue{Value: strings.TrimSpace(p.s[start:p.pos])}, nil
+}
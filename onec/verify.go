@@ -0,0 +1,213 @@
+package onec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// VerifyMaxProblems is the limit of problems reported for one table
+const VerifyMaxProblems = 100
+
+var objectSignature = []byte{0x1c, 0xfd}
+
+// Problem found by Verify, Table is empty for problems of base header
+type Problem struct {
+	Table   string `json:"table,omitempty"`
+	Object  string `json:"object,omitempty"` // data, blob or index
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	parts := make([]string, 0, 3)
+	if p.Table != "" {
+		parts = append(parts, p.Table)
+	}
+	if p.Object != "" {
+		parts = append(parts, p.Object)
+	}
+	return strings.Join(append(parts, p.Message), ": ")
+}
+
+// Verify checks header of base and objects of tables (all tables if empty): signature, fat level and page list
+// of object header, no page belongs to two objects, length of data is a multiple of record length
+// and blob references of live records point into blob object
+func (BO *BaseOnec) Verify(tables []string) []Problem {
+	var problems []Problem
+	pageSize := uint64(BO.HeadDB.PageSize)
+	pages := uint64(0)
+	if BO.HeadDB.NumberOfPages > 0 {
+		pages = uint64(BO.HeadDB.NumberOfPages)
+	}
+
+	if string(BO.HeadDB.Cd[:]) != "1CDBMSV8" {
+		problems = append(problems, Problem{Message: "bad signature of base " + strconv.Quote(string(BO.HeadDB.Cd[:]))})
+	}
+	if !ValidPageSize(BO.HeadDB.PageSize) {
+		problems = append(problems, Problem{Message: "bad page size " + strconv.FormatUint(pageSize, 10)})
+		return problems
+	}
+	if size, err := BO.Size(); err == nil && uint64(size) != pages*pageSize {
+		problems = append(problems, Problem{Message: strings.Join([]string{"size of file", strconv.FormatInt(size, 10),
+			"does not match number of pages", strconv.FormatUint(pages, 10)}, " ")})
+		if uint64(size)/pageSize < pages {
+			pages = uint64(size) / pageSize
+		}
+	}
+
+	if len(tables) == 0 {
+		tables = BO.TablesName
+	}
+	owners := make(map[uint32]string)
+	for _, s := range tables {
+		t, ok := BO.TableDescription[s]
+		if !ok {
+			problems = append(problems, Problem{Table: s, Message: "table not found"})
+			continue
+		}
+		v := tableVerifier{BO: BO, table: s, pages: pages, owners: owners}
+		v.verify(t)
+		problems = append(problems, v.problems...)
+	}
+	return problems
+}
+
+type tableVerifier struct {
+	BO       *BaseOnec
+	table    string
+	pages    uint64
+	owners   map[uint32]string // page - table and object which it belongs to
+	problems []Problem
+}
+
+func (v *tableVerifier) add(object string, message ...string) {
+	if len(v.problems) == VerifyMaxProblems {
+		v.problems = append(v.problems, Problem{Table: v.table, Message: "too many problems, rest are skipped"})
+	}
+	if len(v.problems) >= VerifyMaxProblems {
+		return
+	}
+	v.problems = append(v.problems, Problem{Table: v.table, Object: object, Message: strings.Join(message, " ")})
+}
+
+func (v *tableVerifier) verify(t Table) {
+	dataPages, dataLength, ok := v.object("data", t.DataOffset)
+	blobPages, blobLength, blobOk := v.object("blob", t.BlobOffset)
+	v.object("index", t.IndexOffset)
+	if !ok || t.DataOffset == 0 || t.RowLength == 0 {
+		return
+	}
+	if dataLength%uint64(t.RowLength) != 0 {
+		v.add("data", "length", strconv.FormatUint(dataLength, 10), "is not a multiple of record length", strconv.Itoa(t.RowLength))
+	}
+
+	var blobFields []Field
+	for _, name := range t.FieldsName {
+		if f := t.Fields[name]; f.FieldType == "I" || f.FieldType == "NT" {
+			blobFields = append(blobFields, f)
+		}
+	}
+	if len(blobFields) == 0 {
+		return
+	}
+	if t.BlobOffset == 0 || !blobOk {
+		if t.BlobOffset == 0 {
+			v.add("blob", "table has blob fields but no blob object")
+		}
+		return
+	}
+
+	chunks := blobLength / uint64(BlobChunkSize)
+	pageSize := uint64(v.BO.HeadDB.PageSize)
+	t.BlockOfReplacemant = dataPages
+	v.BO.readRecords(t, int(dataLength/uint64(t.RowLength)), func(n int, record []byte) {
+		if n == 0 || record[0] == 1 || allZero(record) {
+			return
+		}
+		for _, f := range blobFields {
+			value := record[f.DataFieldOffset : f.DataFieldOffset+f.DataLength]
+			if f.NullExist {
+				if value[0] == 0 {
+					continue
+				}
+				value = value[1:]
+			}
+			chunk := binary.LittleEndian.Uint32(value[:4])
+			lenth := binary.LittleEndian.Uint32(value[4:8])
+			if chunk == 0 && lenth == 0 {
+				continue
+			}
+			if uint64(chunk) >= chunks || uint64(chunk)*uint64(BlobChunkSize)/pageSize >= uint64(len(blobPages)) {
+				v.add("blob", "record", strconv.Itoa(n), "field", f.Name, "refers to chunk", strconv.FormatUint(uint64(chunk), 10),
+					"out of", strconv.FormatUint(chunks, 10))
+			}
+		}
+	})
+}
+
+// object checks header of object at page offset and its pages, returns pages and length of object
+func (v *tableVerifier) object(object string, offset int) ([]uint32, uint64, bool) {
+	if offset == 0 {
+		return nil, 0, true
+	}
+	if offset < 0 || uint64(offset) >= v.pages {
+		v.add(object, "header page", strconv.Itoa(offset), "is out of base")
+		return nil, 0, false
+	}
+	pageSize := v.BO.HeadDB.PageSize
+	header := ReadBytes(v.BO.Db, uint64(offset)*uint64(pageSize), pageSize, nil)
+	if !bytes.Equal(header[:2], objectSignature) {
+		v.add(object, "bad signature of header page", strconv.Itoa(offset))
+		return nil, 0, false
+	}
+	fatLevel := header[2]
+	length := binary.LittleEndian.Uint64(header[16:24])
+	count := (length + uint64(pageSize) - 1) / uint64(pageSize)
+	if fatLevel > 1 {
+		v.add(object, "unknown fat level", strconv.Itoa(int(fatLevel)))
+		return nil, length, false
+	}
+	if fatLevel == 0 && count > uint64(pageSize-24)/4 {
+		v.add(object, "length", strconv.FormatUint(length, 10), "does not fit fat level 0")
+		return nil, length, false
+	}
+	if count > v.pages {
+		v.add(object, "length", strconv.FormatUint(length, 10), "is larger than base")
+		return nil, length, false
+	}
+
+	name := v.table + " " + object
+	if h, _ := ParseObjectHeader(header); fatLevel == 1 {
+		for _, p := range h.Pages {
+			if p == 0 || uint64(p) >= v.pages {
+				v.add(object, "page of page table", strconv.FormatUint(uint64(p), 10), "is out of base")
+				return nil, length, false
+			}
+			v.own(name, p)
+		}
+	}
+	pages := ReadBlockOfReplacemant(v.BO, offset)
+	if uint64(len(pages)) != count {
+		v.add(object, "has", strconv.Itoa(len(pages)), "pages, length", strconv.FormatUint(length, 10), "needs", strconv.FormatUint(count, 10))
+	}
+	ok := true
+	v.own(name, uint32(offset))
+	for _, p := range pages {
+		if p == 0 || uint64(p) >= v.pages {
+			v.add(object, "page", strconv.FormatUint(uint64(p), 10), "is out of base")
+			ok = false
+			continue
+		}
+		v.own(name, p)
+	}
+	return pages, length, ok
+}
+
+func (v *tableVerifier) own(name string, page uint32) {
+	if owner, ok := v.owners[page]; ok {
+		v.add("", "page", strconv.FormatUint(uint64(page), 10), "belongs to", owner, "and", name)
+		return
+	}
+	v.owners[page] = name
+}
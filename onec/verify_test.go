@@ -0,0 +1,73 @@
+package onec_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	table := testDecodersTable()
+	table.Rows = append(table.Rows, onectest.Row{Values: map[string]interface{}{"_DATA": onectest.BlobRef{Chunk: 1000, Lenth: 10}}})
+	b := onectest.Base{Tables: []onectest.Table{table, {
+		Name:   "_CONST1",
+		Fields: []onectest.Field{{Name: "_FLD1", Type: "N", Length: 10}},
+		Rows:   []onectest.Row{{Values: map[string]interface{}{"_FLD1": "1"}}},
+	}}}
+	if p := onectest.Open(t, b).Verify(nil); len(p) != 1 || p[0].Table != "_DOCUMENT5" || p[0].Object != "blob" ||
+		!strings.Contains(p[0].Message, "record 5 field _DATA refers to chunk 1000") {
+		t.Fatalf("unexpected problems %v", p)
+	}
+
+	data, err := b.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := onectest.OpenBytes(t, data).TableDescription["_CONST1"].DataOffset
+	data[header*4096] = 0
+	pages := binary.LittleEndian.Uint32(data[12:16])
+	binary.LittleEndian.PutUint32(data[12:16], pages+1)
+	expected := []string{
+		"size of file " + strconv.Itoa(len(data)) + " does not match number of pages " + strconv.Itoa(int(pages)+1),
+		"_CONST1: data: bad signature of header page " + strconv.Itoa(header),
+		"_UNKNOWN: table not found",
+	}
+	p := onectest.OpenBytes(t, data).Verify([]string{"_CONST1", "_UNKNOWN"})
+	if len(p) != len(expected) {
+		t.Fatalf("unexpected problems %v", p)
+	}
+	for k, v := range expected {
+		if p[k].String() != v {
+			t.Errorf("problem %q, expected %q", p[k], v)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	data, err := onectest.Base{Tables: []onectest.Table{testDecodersTable()}}.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 10, 4096, 3*4096 + 100, len(data) / 2, len(data) - 4096} {
+		if _, err := onec.OpenBaseOnec(bytes.NewReader(data[:size])); err == nil {
+			t.Errorf("base truncated to %d bytes is opened", size)
+		}
+	}
+
+	BO := onectest.OpenBytes(t, data)
+	BO.Db = bytes.NewReader(data[:3*4096]) //file is truncated after base is opened
+	err = func() (err error) {
+		defer onec.CatchReadError(&err)
+		BO.Rows("_DOCUMENT5", 1, true)
+		return nil
+	}()
+	var readErr onec.ReadError
+	if !errors.As(err, &readErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
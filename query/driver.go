@@ -0,0 +1,146 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"io"
+	"os"
+)
+
+// DriverName is the name of database/sql driver: sql.Open("onec", "/path/1Cv8.1CD")
+const DriverName = "onec"
+
+var errReadOnly = errors.New("Base is read-only, only SELECT is supported")
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// Driver opens 1CD file named by data source name, every connection has its own file handle
+type Driver struct{}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	db, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := onec.OpenBaseOnec(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &conn{catalog: newBaseCatalog(b), db: db}, nil
+}
+
+// NewConnector returns connector to already opened base for sql.OpenDB.
+// Opened base is only read, so connections may run queries concurrently
+func NewConnector(b *onec.BaseOnec) driver.Connector {
+	return &connector{b: b}
+}
+
+type connector struct {
+	b *onec.BaseOnec
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{catalog: newBaseCatalog(c.b)}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+type conn struct {
+	catalog catalog
+	db      *os.File // nil for connector of opened base
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	stmt, params, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	p, err := compile(c.catalog, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt1C{plan: p, params: params}, nil
+}
+
+func (c *conn) Close() error {
+	if c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errReadOnly
+}
+
+type stmt1C struct {
+	plan   *plan
+	params int
+}
+
+func (s *stmt1C) Close() error {
+	return nil
+}
+
+func (s *stmt1C) NumInput() int {
+	return s.params
+}
+
+func (s *stmt1C) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errReadOnly
+}
+
+func (s *stmt1C) Query(args []driver.Value) (driver.Rows, error) {
+	values := make([]value, len(args))
+	for k, v := range args {
+		values[k] = fromDriver(v)
+	}
+	c, err := s.plan.run(values, &limits{ctx: context.Background()})
+	if err != nil {
+		return nil, err
+	}
+	return &rows{plan: s.plan, cursor: c}, nil
+}
+
+// rows returns N as int64 when it is integer, otherwise as exact decimal text,
+// DT as time.Time, B and RV as hex text, I and NT blobs as text or []byte
+type rows struct {
+	plan   *plan
+	cursor cursor
+}
+
+func (r *rows) Columns() []string {
+	return r.plan.columns
+}
+
+// ColumnTypeDatabaseTypeName returns type of 1C field (NVC, N, DT...), empty for expressions
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.plan.types[index]
+}
+
+func (r *rows) Close() error {
+	r.cursor = &sliceCursor{}
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	row, err := r.cursor.next()
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return io.EOF
+	}
+	for k, v := range row {
+		dest[k] = toDriver(v)
+	}
+	return nil
+}
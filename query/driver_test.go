@@ -0,0 +1,38 @@
+package query
+
+import (
+	"database/sql"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestConnectorConcurrent runs queries of one base on several connections, it is run with -race
+func TestConnectorConcurrent(t *testing.T) {
+	table := onectest.Table{
+		Name:   "_REFERENCE1",
+		Fields: []onectest.Field{{Name: "_CODE", Type: "NVC", Length: 5}},
+	}
+	for n := 1; n <= 50; n++ {
+		table.Rows = append(table.Rows, onectest.Row{Values: map[string]interface{}{"_CODE": strconv.Itoa(n)}})
+	}
+	db := sql.OpenDB(NewConnector(onectest.Open(t, onectest.Base{Tables: []onectest.Table{table}})))
+	defer db.Close()
+	db.SetMaxOpenConns(8)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			var count int
+			var code string
+			err := db.QueryRow("SELECT COUNT(*), MAX(_CODE) FROM _REFERENCE1 WHERE _CODE <> ?", strconv.Itoa(g+1)).Scan(&count, &code)
+			if err != nil || count != 49 || code != "9" {
+				t.Errorf("goroutine %d: %d %q %v", g, count, code, err)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,436 @@
+package query
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// env is context of evaluation: row of joined tables, results of aggregates of group and parameters
+type env struct {
+	row  []value
+	aggs []value
+	args []value
+}
+
+// truth converts value to three-valued logic, ok is false for NULL
+func truth(v value) (result bool, ok bool) {
+	switch v := v.(type) {
+	case nil:
+		return false, false
+	case bool:
+		return v, true
+	case number:
+		return v.r.Sign() != 0, true
+	}
+	return false, true
+}
+
+func (e *env) eval(expr Expr) (value, error) {
+	switch x := expr.(type) {
+	case *Literal:
+		return x.Value, nil
+	case *Param:
+		if x.N >= len(e.args) {
+			return nil, errors.New("Not enough parameters")
+		}
+		return e.args[x.N], nil
+	case *Column:
+		return resolve(e.row[x.index]), nil
+	case *Unary:
+		v, err := e.eval(x.X)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		if x.Op == "NOT" {
+			b, _ := truth(v)
+			return !b, nil
+		}
+		n, ok := toNumber(v)
+		if !ok {
+			return nil, errors.New(strings.Join([]string{"Not a number", text(v)}, " "))
+		}
+		return number{new(big.Rat).Neg(n.r), n.scale}, nil
+	case *Binary:
+		return e.binary(x)
+	case *IsNull:
+		v, err := e.eval(x.X)
+		return (v == nil) != x.Not, err
+	case *Like:
+		v, err := e.eval(x.X)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		pattern, err := e.eval(x.Pattern)
+		if err != nil || pattern == nil {
+			return nil, err
+		}
+		return like(strings.TrimRight(text(v), " "), text(pattern)) != x.Not, nil
+	case *In:
+		v, err := e.eval(x.X)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		hasNull := false
+		for _, item := range x.List {
+			w, err := e.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			if w == nil {
+				hasNull = true
+			} else if compare(v, w) == 0 {
+				return !x.Not, nil
+			}
+		}
+		if hasNull {
+			return nil, nil
+		}
+		return x.Not, nil
+	case *Between:
+		v, err := e.eval(x.X)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		from, err := e.eval(x.From)
+		if err != nil || from == nil {
+			return nil, err
+		}
+		to, err := e.eval(x.To)
+		if err != nil || to == nil {
+			return nil, err
+		}
+		return (compare(v, from) >= 0 && compare(v, to) <= 0) != x.Not, nil
+	case *Call:
+		if x.agg >= 0 {
+			return e.aggs[x.agg], nil
+		}
+		args := make([]value, len(x.Args))
+		for k, arg := range x.Args {
+			v, err := e.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args[k] = v
+		}
+		return call(x.Name, args)
+	}
+	return nil, errors.New("Unknown expression")
+}
+
+func (e *env) binary(x *Binary) (value, error) {
+	l, err := e.eval(x.L)
+	if err != nil {
+		return nil, err
+	}
+
+	if x.Op == "AND" || x.Op == "OR" {
+		lb, lok := truth(l)
+		if lok && lb == (x.Op == "OR") { // short circuit: FALSE AND ..., TRUE OR ...
+			return lb, nil
+		}
+		r, err := e.eval(x.R)
+		if err != nil {
+			return nil, err
+		}
+		rb, rok := truth(r)
+		switch {
+		case rok && rb == (x.Op == "OR"):
+			return rb, nil
+		case !lok || !rok:
+			return nil, nil
+		}
+		return rb, nil
+	}
+
+	r, err := e.eval(x.R)
+	if err != nil || l == nil || r == nil {
+		return nil, err
+	}
+
+	switch x.Op {
+	case "=":
+		return compare(l, r) == 0, nil
+	case "<>":
+		return compare(l, r) != 0, nil
+	case "<":
+		return compare(l, r) < 0, nil
+	case "<=":
+		return compare(l, r) <= 0, nil
+	case ">":
+		return compare(l, r) > 0, nil
+	case ">=":
+		return compare(l, r) >= 0, nil
+	case "||":
+		return text(l) + text(r), nil
+	}
+
+	a, ok := toNumber(l)
+	if !ok {
+		return nil, errors.New(strings.Join([]string{"Not a number", text(l)}, " "))
+	}
+	b, ok := toNumber(r)
+	if !ok {
+		return nil, errors.New(strings.Join([]string{"Not a number", text(r)}, " "))
+	}
+	result := new(big.Rat)
+	switch x.Op {
+	case "+":
+		return number{result.Add(a.r, b.r), maxScale(a, b)}, nil
+	case "-":
+		return number{result.Sub(a.r, b.r), maxScale(a, b)}, nil
+	case "*":
+		return number{result.Mul(a.r, b.r), a.scale + b.scale}, nil
+	case "/", "%":
+		if b.r.Sign() == 0 {
+			return nil, nil
+		}
+		result.Quo(a.r, b.r)
+		if x.Op == "/" {
+			return number{result, divScale + maxScale(a, b)}, nil
+		}
+		// remainder of truncated division
+		q := new(big.Int).Quo(result.Num(), result.Denom())
+		result.Sub(a.r, new(big.Rat).Mul(b.r, new(big.Rat).SetInt(q)))
+		return number{result, maxScale(a, b)}, nil
+	}
+	return nil, errors.New(strings.Join([]string{"Unknown operator", x.Op}, " "))
+}
+
+func maxScale(a, b number) int {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// like matches s with pattern: % - any string, _ - any character
+func like(s, pattern string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if like(s[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		if s == "" {
+			return false
+		}
+		_, n := utf8.DecodeRuneInString(s)
+		return like(s[n:], pattern[1:])
+	}
+	if s == "" || s[0] != pattern[0] {
+		return false
+	}
+	return like(s[1:], pattern[1:])
+}
+
+// call evaluates scalar function
+func call(name string, args []value) (value, error) {
+	argc := map[string][2]int{
+		"UPPER": {1, 1}, "LOWER": {1, 1}, "LENGTH": {1, 1}, "TRIM": {1, 1}, "LTRIM": {1, 1}, "RTRIM": {1, 1},
+		"SUBSTR": {2, 3}, "SUBSTRING": {2, 3}, "COALESCE": {1, -1}, "IFNULL": {2, 2}, "ABS": {1, 1},
+		"ROUND": {1, 2}, "YEAR": {1, 1}, "MONTH": {1, 1}, "DAY": {1, 1}, "DATETIME": {3, 6},
+	}
+	count, ok := argc[name]
+	if !ok {
+		return nil, errors.New(strings.Join([]string{"Unknown function", name}, " "))
+	}
+	if len(args) < count[0] || count[1] >= 0 && len(args) > count[1] {
+		return nil, errors.New(strings.Join([]string{"Wrong number of arguments of", name}, " "))
+	}
+
+	if name == "COALESCE" || name == "IFNULL" {
+		for _, v := range args {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	}
+	for _, v := range args {
+		if v == nil {
+			return nil, nil
+		}
+	}
+
+	switch name {
+	case "UPPER":
+		return strings.ToUpper(text(args[0])), nil
+	case "LOWER":
+		return strings.ToLower(text(args[0])), nil
+	case "LENGTH":
+		return intNumber(int64(utf8.RuneCountInString(text(args[0])))), nil
+	case "TRIM":
+		return strings.TrimSpace(text(args[0])), nil
+	case "LTRIM":
+		return strings.TrimLeft(text(args[0]), " "), nil
+	case "RTRIM":
+		return strings.TrimRight(text(args[0]), " "), nil
+	case "SUBSTR", "SUBSTRING":
+		runes := []rune(text(args[0]))
+		start, ok := toInt(args[1])
+		if !ok {
+			return nil, errors.New("Wrong start of SUBSTR")
+		}
+		end := len(runes)
+		if len(args) == 3 {
+			n, ok := toInt(args[2])
+			if !ok {
+				return nil, errors.New("Wrong length of SUBSTR")
+			}
+			end = start - 1 + n
+		}
+		start--
+		if start < 0 {
+			start = 0
+		}
+		if end > len(runes) {
+			end = len(runes)
+		}
+		if start >= end {
+			return "", nil
+		}
+		return string(runes[start:end]), nil
+	case "ABS":
+		n, ok := toNumber(args[0])
+		if !ok {
+			return nil, errors.New(strings.Join([]string{"Not a number", text(args[0])}, " "))
+		}
+		return number{new(big.Rat).Abs(n.r), n.scale}, nil
+	case "ROUND":
+		n, ok := toNumber(args[0])
+		if !ok {
+			return nil, errors.New(strings.Join([]string{"Not a number", text(args[0])}, " "))
+		}
+		places := 0
+		if len(args) == 2 {
+			places, ok = toInt(args[1])
+			if !ok || places < 0 {
+				return nil, errors.New("Wrong places of ROUND")
+			}
+		}
+		r, _ := new(big.Rat).SetString(n.r.FloatString(places))
+		return number{r, places}, nil
+	case "YEAR", "MONTH", "DAY":
+		t, ok := toTime(args[0])
+		if !ok {
+			return nil, errors.New(strings.Join([]string{"Not a date", text(args[0])}, " "))
+		}
+		return intNumber(int64(datePart(t, name))), nil
+	case "DATETIME":
+		parts := make([]int, 6)
+		for k, v := range args {
+			n, ok := toInt(v)
+			if !ok {
+				return nil, errors.New(strings.Join([]string{"Not a number", text(v)}, " "))
+			}
+			parts[k] = n
+		}
+		return time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, time.UTC), nil
+	}
+	return nil, nil
+}
+
+func datePart(t time.Time, name string) int {
+	switch name {
+	case "YEAR":
+		return t.Year()
+	case "MONTH":
+		return int(t.Month())
+	}
+	return t.Day()
+}
+
+func toInt(v value) (int, bool) {
+	n, ok := toNumber(v)
+	if !ok || !n.r.IsInt() || !n.r.Num().IsInt64() {
+		return 0, false
+	}
+	return int(n.r.Num().Int64()), true
+}
+
+// accumulator computes aggregate of one group
+type accumulator struct {
+	call  *Call
+	count int64
+	sum   *big.Rat
+	scale int
+	best  value
+	seen  map[string]bool
+}
+
+func newAccumulator(c *Call) *accumulator {
+	a := &accumulator{call: c, sum: new(big.Rat)}
+	if c.Distinct {
+		a.seen = make(map[string]bool)
+	}
+	return a
+}
+
+func (a *accumulator) add(e *env) error {
+	if a.call.Star {
+		a.count++
+		return nil
+	}
+	if len(a.call.Args) != 1 {
+		return errors.New(strings.Join([]string{"Wrong number of arguments of", a.call.Name}, " "))
+	}
+	v, err := e.eval(a.call.Args[0])
+	if err != nil || v == nil {
+		return err
+	}
+	if a.seen != nil {
+		if a.seen[key(v)] {
+			return nil
+		}
+		a.seen[key(v)] = true
+	}
+	a.count++
+
+	switch a.call.Name {
+	case "SUM", "AVG":
+		n, ok := toNumber(v)
+		if !ok {
+			return errors.New(strings.Join([]string{"Not a number", text(v)}, " "))
+		}
+		a.sum.Add(a.sum, n.r)
+		if n.scale > a.scale {
+			a.scale = n.scale
+		}
+	case "MIN":
+		if a.best == nil || compare(v, a.best) < 0 {
+			a.best = v
+		}
+	case "MAX":
+		if a.best == nil || compare(v, a.best) > 0 {
+			a.best = v
+		}
+	}
+	return nil
+}
+
+func (a *accumulator) result() value {
+	switch a.call.Name {
+	case "COUNT":
+		return intNumber(a.count)
+	case "SUM":
+		if a.count == 0 {
+			return nil
+		}
+		return number{new(big.Rat).Set(a.sum), a.scale}
+	case "AVG":
+		if a.count == 0 {
+			return nil
+		}
+		return number{new(big.Rat).Quo(a.sum, new(big.Rat).SetInt64(a.count)), a.scale + divScale}
+	}
+	return a.best
+}
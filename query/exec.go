@@ -0,0 +1,748 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// catalog is the source of tables, rows have value for every field of table, not loaded fields are nil
+type catalog interface {
+	table(name string) (onec.Table, bool)
+	rows(t onec.Table, load []bool) rowReader
+}
+
+// rowReader returns next row, nil at the end
+type rowReader interface {
+	next() ([]value, error)
+}
+
+// baseCatalog reads tables of 1CD base
+type baseCatalog struct {
+	b     *onec.BaseOnec
+	names map[string]string // upper case name to name of table
+}
+
+func newBaseCatalog(b *onec.BaseOnec) *baseCatalog {
+	c := &baseCatalog{b: b, names: make(map[string]string, len(b.TablesName))}
+	for _, v := range b.TablesName {
+		c.names[strings.ToUpper(v)] = v
+	}
+	return c
+}
+
+func (c *baseCatalog) table(name string) (onec.Table, bool) {
+	t, ok := c.b.TableDescription[c.names[strings.ToUpper(name)]]
+	return t, ok
+}
+
+func (c *baseCatalog) rows(t onec.Table, load []bool) rowReader {
+	return &baseReader{b: c.b, t: t, load: load, count: c.b.RowsCount(t.Name)}
+}
+
+// baseReader reads live records of table one by one
+type baseReader struct {
+	b     *onec.BaseOnec
+	t     onec.Table
+	load  []bool
+	n     int
+	count int
+}
+
+func (r *baseReader) next() ([]value, error) {
+	for r.n < r.count {
+		obj := r.b.Rows(r.t.Name, r.n, false)
+		r.n++
+		if obj.Table == nil {
+			break
+		}
+		if obj.NotExist || obj.Deleted {
+			continue
+		}
+		row := make([]value, len(r.t.FieldsName))
+		for k, name := range r.t.FieldsName {
+			if !r.load[k] {
+				continue
+			}
+			f := r.t.Fields[name]
+			if (f.FieldType == "I" || f.FieldType == "NT") && !f.Masked {
+				if raw := obj.ValueObject[name]; !f.NullExist || len(raw) > 0 && raw[0] != 0 {
+					row[k] = &lazyBlob{b: r.b, obj: obj, field: f}
+				}
+				continue
+			}
+			row[k] = fromOnec(r.b.Value(obj, name, false), f)
+		}
+		return row, nil
+	}
+	return nil, nil
+}
+
+// limits of run of plan: context and number of rows kept in memory
+type limits struct {
+	ctx     context.Context
+	maxRows int
+	rows    int
+}
+
+// keep counts row kept in memory
+func (l *limits) keep() error {
+	l.rows++
+	if l.maxRows > 0 && l.rows > l.maxRows {
+		return errors.New(strings.Join([]string{"Query keeps more than", strconv.Itoa(l.maxRows), "rows in memory, use WHERE or fewer tables"}, " "))
+	}
+	return nil
+}
+
+// contextReader stops reading of rows when context is done
+type contextReader struct {
+	rows rowReader
+	ctx  context.Context
+}
+
+func (r *contextReader) next() ([]value, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.rows.next()
+}
+
+// source is table of FROM or JOIN, its fields are at offset of joined row
+type source struct {
+	alias  string
+	table  onec.Table
+	offset int
+	load   []bool
+}
+
+// plan is compiled statement
+type plan struct {
+	stmt    *Select
+	catalog catalog
+	sources []source
+	width   int
+	outputs []Expr
+	columns []string
+	types   []string
+	aggs    []*Call
+	grouped bool
+	order   []int // index of output for ORDER BY item, -1 for expression
+}
+
+func compile(c catalog, stmt *Select) (*plan, error) {
+	p := &plan{stmt: stmt, catalog: c}
+	refs := []TableRef{}
+	if stmt.From != nil {
+		refs = append(refs, *stmt.From)
+	}
+	for _, j := range stmt.Joins {
+		refs = append(refs, j.Table)
+	}
+	for _, ref := range refs {
+		t, ok := c.table(ref.Name)
+		if !ok {
+			return nil, errors.New(strings.Join([]string{"Table not found", ref.Name}, " "))
+		}
+		alias := ref.Alias
+		if alias == "" {
+			alias = ref.Name
+		}
+		for _, s := range p.sources {
+			if strings.EqualFold(s.alias, alias) {
+				return nil, errors.New(strings.Join([]string{"Duplicate table alias", alias}, " "))
+			}
+		}
+		p.sources = append(p.sources, source{alias: alias, table: t, offset: p.width, load: make([]bool, len(t.FieldsName))})
+		p.width += len(t.FieldsName)
+	}
+
+	for _, item := range stmt.Items {
+		if !item.Star {
+			p.outputs = append(p.outputs, item.Expr)
+			p.columns = append(p.columns, item.Text)
+			if item.Alias != "" {
+				p.columns[len(p.columns)-1] = item.Alias
+			}
+			continue
+		}
+		found := false
+		for _, s := range p.sources {
+			if item.Table != "" && !strings.EqualFold(item.Table, s.alias) {
+				continue
+			}
+			found = true
+			for _, name := range s.table.FieldsName {
+				p.outputs = append(p.outputs, &Column{Table: s.alias, Name: name})
+				p.columns = append(p.columns, name)
+			}
+		}
+		if !found {
+			return nil, errors.New(strings.Join([]string{"Table not found", item.Table + ".*"}, " "))
+		}
+	}
+
+	for k, j := range stmt.Joins {
+		if err := p.bind(j.On, k+2, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range p.outputs {
+		if err := p.bind(e, len(p.sources), true); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.bind(stmt.Where, len(p.sources), false); err != nil {
+		return nil, err
+	}
+	for _, e := range stmt.GroupBy {
+		if err := p.bind(e, len(p.sources), false); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.bind(stmt.Having, len(p.sources), true); err != nil {
+		return nil, err
+	}
+	for _, item := range stmt.OrderBy {
+		index := p.outputIndex(item.Expr)
+		p.order = append(p.order, index)
+		if index >= 0 {
+			continue
+		}
+		if err := p.bind(item.Expr, len(p.sources), true); err != nil {
+			return nil, err
+		}
+	}
+	p.grouped = len(stmt.GroupBy) > 0 || len(p.aggs) > 0
+	if stmt.Having != nil && !p.grouped {
+		return nil, errors.New("HAVING without GROUP BY")
+	}
+
+	for _, e := range p.outputs {
+		p.types = append(p.types, "")
+		if column, ok := e.(*Column); ok {
+			p.types[len(p.types)-1] = p.sourceOf(column.index).table.Fields[column.field].FieldType
+		}
+	}
+	return p, nil
+}
+
+// outputIndex resolves ORDER BY item which is alias or position of output column
+func (p *plan) outputIndex(e Expr) int {
+	switch x := e.(type) {
+	case *Column:
+		if x.Table != "" {
+			return -1
+		}
+		for k, item := range p.stmt.Items {
+			if item.Alias != "" && strings.EqualFold(item.Alias, x.Name) {
+				return p.itemOutput(k)
+			}
+		}
+	case *Literal:
+		if n, ok := toInt(x.Value); ok && n >= 1 && n <= len(p.outputs) {
+			return n - 1
+		}
+	}
+	return -1
+}
+
+// itemOutput returns index of first output column of select item k
+func (p *plan) itemOutput(k int) int {
+	index := 0
+	for _, item := range p.stmt.Items[:k] {
+		if !item.Star {
+			index++
+			continue
+		}
+		for _, s := range p.sources {
+			if item.Table == "" || strings.EqualFold(item.Table, s.alias) {
+				index += len(s.table.FieldsName)
+			}
+		}
+	}
+	return index
+}
+
+func (p *plan) sourceOf(index int) source {
+	for k := len(p.sources) - 1; k >= 0; k-- {
+		if index >= p.sources[k].offset {
+			return p.sources[k]
+		}
+	}
+	return source{}
+}
+
+// bind resolves columns in first visible sources, registers aggregates when they are allowed
+func (p *plan) bind(e Expr, visible int, allowAgg bool) error {
+	var err error
+	walk(e, func(e Expr, inAgg bool) bool {
+		switch x := e.(type) {
+		case *Column:
+			err = p.bindColumn(x, visible)
+		case *Call:
+			if x.agg >= 0 || !aggregates[x.Name] {
+				return err == nil
+			}
+			if !allowAgg {
+				err = errors.New(strings.Join([]string{"Aggregate", x.Name, "is not allowed here"}, " "))
+			} else if inAgg {
+				err = errors.New(strings.Join([]string{"Nested aggregate", x.Name}, " "))
+			} else {
+				x.agg = len(p.aggs)
+				p.aggs = append(p.aggs, x)
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+func (p *plan) bindColumn(c *Column, visible int) error {
+	found := false
+	for _, s := range p.sources[:visible] {
+		if c.Table != "" && !strings.EqualFold(c.Table, s.alias) {
+			continue
+		}
+		for k, name := range s.table.FieldsName {
+			if !strings.EqualFold(name, c.Name) {
+				continue
+			}
+			if found {
+				return errors.New(strings.Join([]string{"Ambiguous column", c.Name}, " "))
+			}
+			found = true
+			c.index = s.offset + k
+			c.field = name
+			s.load[k] = true
+		}
+	}
+	if !found {
+		name := c.Name
+		if c.Table != "" {
+			name = c.Table + "." + c.Name
+		}
+		return errors.New(strings.Join([]string{"Column not found", name}, " "))
+	}
+	return nil
+}
+
+// walk calls fn for e and its children until fn returns false, inAgg is true inside of aggregate
+func walk(e Expr, fn func(e Expr, inAgg bool) bool) bool {
+	var visit func(e Expr, inAgg bool) bool
+	visit = func(e Expr, inAgg bool) bool {
+		if e == nil {
+			return true
+		}
+		if !fn(e, inAgg) {
+			return false
+		}
+		var children []Expr
+		switch x := e.(type) {
+		case *Unary:
+			children = []Expr{x.X}
+		case *Binary:
+			children = []Expr{x.L, x.R}
+		case *Like:
+			children = []Expr{x.X, x.Pattern}
+		case *In:
+			children = append([]Expr{x.X}, x.List...)
+		case *Between:
+			children = []Expr{x.X, x.From, x.To}
+		case *IsNull:
+			children = []Expr{x.X}
+		case *Call:
+			children = x.Args
+			inAgg = inAgg || aggregates[x.Name]
+		}
+		for _, child := range children {
+			if !visit(child, inAgg) {
+				return false
+			}
+		}
+		return true
+	}
+	return visit(e, false)
+}
+
+// run executes plan, rows are read while cursor is advanced when result does not need sorting or grouping
+func (p *plan) run(args []value, l *limits) (cursor, error) {
+	e := &env{args: args}
+	var rows rowReader = &singleRow{}
+	if len(p.sources) > 0 {
+		rows = &contextReader{rows: p.catalog.rows(p.sources[0].table, p.sources[0].load), ctx: l.ctx}
+	}
+	for k, j := range p.stmt.Joins {
+		join, err := p.join(rows, k+1, j, e, l)
+		if err != nil {
+			return nil, err
+		}
+		rows = join
+	}
+	if p.stmt.Where != nil {
+		rows = &filterReader{rows: rows, cond: p.stmt.Where, env: e}
+	}
+
+	if !p.grouped && len(p.stmt.OrderBy) == 0 && !p.stmt.Distinct {
+		return &streamCursor{plan: p, rows: rows, env: e, skip: p.stmt.Offset, limit: p.stmt.Limit}, nil
+	}
+
+	var contexts []*env
+	var err error
+	if p.grouped {
+		contexts, err = p.group(rows, e, l)
+	} else {
+		for {
+			row, err := rows.next()
+			if err != nil {
+				return nil, err
+			}
+			if row == nil {
+				break
+			}
+			if err := l.keep(); err != nil {
+				return nil, err
+			}
+			contexts = append(contexts, &env{row: row, args: args})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		values []value
+		keys   []value
+	}
+	results := make([]result, 0, len(contexts))
+	seen := make(map[string]bool)
+	for _, c := range contexts {
+		r := result{}
+		r.values, err = p.project(c)
+		if err != nil {
+			return nil, err
+		}
+		if p.stmt.Distinct {
+			k := rowKey(r.values)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		for k, item := range p.stmt.OrderBy {
+			if p.order[k] >= 0 {
+				r.keys = append(r.keys, r.values[p.order[k]])
+				continue
+			}
+			v, err := c.eval(item.Expr)
+			if err != nil {
+				return nil, err
+			}
+			r.keys = append(r.keys, v)
+		}
+		results = append(results, r)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		for k, item := range p.stmt.OrderBy {
+			cmp := compareNullsFirst(results[i].keys[k], results[j].keys[k])
+			if cmp != 0 {
+				return cmp < 0 != item.Desc
+			}
+		}
+		return false
+	})
+
+	rest := results[onec.Min(p.stmt.Offset, len(results)):]
+	if p.stmt.Limit >= 0 && p.stmt.Limit < len(rest) {
+		rest = rest[:p.stmt.Limit]
+	}
+	c := &sliceCursor{}
+	for _, r := range rest {
+		c.rows = append(c.rows, r.values)
+	}
+	return c, nil
+}
+
+// project evaluates outputs, blobs of columns are left to be read when they are returned
+func (p *plan) project(e *env) ([]value, error) {
+	values := make([]value, len(p.outputs))
+	for k, expr := range p.outputs {
+		if c, ok := expr.(*Column); ok && e.row != nil {
+			values[k] = e.row[c.index]
+			continue
+		}
+		v, err := e.eval(expr)
+		if err != nil {
+			return nil, err
+		}
+		values[k] = v
+	}
+	return values, nil
+}
+
+// group reads all rows into groups, without GROUP BY there is one group even for no rows
+func (p *plan) group(rows rowReader, e *env, l *limits) ([]*env, error) {
+	type group struct {
+		row  []value
+		accs []*accumulator
+	}
+	newGroup := func(row []value) *group {
+		g := &group{row: row}
+		for _, c := range p.aggs {
+			g.accs = append(g.accs, newAccumulator(c))
+		}
+		return g
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	if len(p.stmt.GroupBy) == 0 {
+		groups = append(groups, newGroup(nil))
+		index[""] = groups[0]
+	}
+
+	keys := make([]value, len(p.stmt.GroupBy))
+	for {
+		row, err := rows.next()
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			break
+		}
+		c := &env{row: row, args: e.args}
+		for k, expr := range p.stmt.GroupBy {
+			keys[k], err = c.eval(expr)
+			if err != nil {
+				return nil, err
+			}
+		}
+		k := rowKey(keys)
+		g, ok := index[k]
+		if !ok {
+			if err := l.keep(); err != nil {
+				return nil, err
+			}
+			g = newGroup(row)
+			index[k] = g
+			groups = append(groups, g)
+		} else if g.row == nil {
+			g.row = row
+		}
+		for _, acc := range g.accs {
+			err = acc.add(c)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	contexts := make([]*env, 0, len(groups))
+	for _, g := range groups {
+		if g.row == nil {
+			g.row = make([]value, p.width)
+		}
+		c := &env{row: g.row, args: e.args}
+		for _, acc := range g.accs {
+			c.aggs = append(c.aggs, acc.result())
+		}
+		if p.stmt.Having != nil {
+			v, err := c.eval(p.stmt.Having)
+			if err != nil {
+				return nil, err
+			}
+			if ok, _ := truth(v); !ok {
+				continue
+			}
+		}
+		contexts = append(contexts, c)
+	}
+	return contexts, nil
+}
+
+// join returns rows of left joined with source k. Right table is read into memory,
+// equality of columns of left and right tables is joined by hash
+func (p *plan) join(left rowReader, k int, j Join, e *env, l *limits) (rowReader, error) {
+	s := p.sources[k]
+	r := &joinReader{left: left, on: j.On, leftJoin: j.Left, env: e, leftKey: -1, rightWidth: len(s.table.FieldsName)}
+	reader := &contextReader{rows: p.catalog.rows(s.table, s.load), ctx: l.ctx}
+	for {
+		row, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			break
+		}
+		if err := l.keep(); err != nil {
+			return nil, err
+		}
+		r.right = append(r.right, row)
+	}
+
+	if b, ok := j.On.(*Binary); ok && b.Op == "=" {
+		l, lok := b.L.(*Column)
+		rc, rok := b.R.(*Column)
+		if lok && rok {
+			if l.index >= s.offset {
+				l, rc = rc, l
+			}
+			if l.index < s.offset && rc.index >= s.offset {
+				r.leftKey = l.index
+				r.hash = make(map[string][]int)
+				for n, row := range r.right {
+					if v := row[rc.index-s.offset]; v != nil {
+						r.hash[key(v)] = append(r.hash[key(v)], n)
+					}
+				}
+			}
+		}
+	}
+	return r, nil
+}
+
+type joinReader struct {
+	left       rowReader
+	right      [][]value
+	rightWidth int
+	hash       map[string][]int
+	leftKey    int
+	on         Expr
+	leftJoin   bool
+	env        *env
+	pending    [][]value
+}
+
+func (r *joinReader) next() ([]value, error) {
+	for len(r.pending) == 0 {
+		row, err := r.left.next()
+		if err != nil || row == nil {
+			return nil, err
+		}
+		match := func(right []value) error {
+			joined := append(append(make([]value, 0, len(row)+r.rightWidth), row...), right...)
+			if r.hash == nil {
+				v, err := (&env{row: joined, args: r.env.args}).eval(r.on)
+				if err != nil {
+					return err
+				}
+				if ok, _ := truth(v); !ok {
+					return nil
+				}
+			}
+			r.pending = append(r.pending, joined)
+			return nil
+		}
+		if r.hash != nil {
+			if v := row[r.leftKey]; v != nil {
+				for _, n := range r.hash[key(v)] {
+					match(r.right[n])
+				}
+			}
+		} else {
+			for _, right := range r.right {
+				if err := match(right); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(r.pending) == 0 && r.leftJoin {
+			r.pending = append(r.pending, append(row, make([]value, r.rightWidth)...))
+		}
+	}
+	row := r.pending[0]
+	r.pending = r.pending[1:]
+	return row, nil
+}
+
+type filterReader struct {
+	rows rowReader
+	cond Expr
+	env  *env
+}
+
+func (r *filterReader) next() ([]value, error) {
+	for {
+		row, err := r.rows.next()
+		if err != nil || row == nil {
+			return nil, err
+		}
+		v, err := (&env{row: row, args: r.env.args}).eval(r.cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok, _ := truth(v); ok {
+			return row, nil
+		}
+	}
+}
+
+// singleRow is the row of SELECT without FROM
+type singleRow struct {
+	done bool
+}
+
+func (r *singleRow) next() ([]value, error) {
+	if r.done {
+		return nil, nil
+	}
+	r.done = true
+	return []value{}, nil
+}
+
+func rowKey(values []value) string {
+	var b strings.Builder
+	for _, v := range values {
+		k := key(v)
+		b.WriteString(strconv.Itoa(len(k)))
+		b.WriteByte(':')
+		b.WriteString(k)
+	}
+	return b.String()
+}
+
+// cursor returns next row of result, nil at the end
+type cursor interface {
+	next() ([]value, error)
+}
+
+type streamCursor struct {
+	plan  *plan
+	rows  rowReader
+	env   *env
+	skip  int
+	limit int
+}
+
+func (c *streamCursor) next() ([]value, error) {
+	for c.limit != 0 {
+		row, err := c.rows.next()
+		if err != nil || row == nil {
+			return nil, err
+		}
+		if c.skip > 0 {
+			c.skip--
+			continue
+		}
+		if c.limit > 0 {
+			c.limit--
+		}
+		return c.plan.project(&env{row: row, args: c.env.args})
+	}
+	return nil, nil
+}
+
+type sliceCursor struct {
+	rows [][]value
+}
+
+func (c *sliceCursor) next() ([]value, error) {
+	if len(c.rows) == 0 {
+		return nil, nil
+	}
+	row := c.rows[0]
+	c.rows = c.rows[1:]
+	return row, nil
+}
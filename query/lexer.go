@@ -0,0 +1,163 @@
+package query
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Kinds of tokens
+const (
+	tokenEOF = iota
+	tokenIdent
+	tokenQuoted // "name" or [name], never a keyword
+	tokenNumber
+	tokenString
+	tokenParam
+	tokenOp
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// is reports whether token is keyword or operator s (keywords are case insensitive)
+func (t token) is(s string) bool {
+	return (t.kind == tokenIdent || t.kind == tokenOp) && strings.EqualFold(t.text, s)
+}
+
+var operators = []string{"<>", "!=", "<=", ">=", "||", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", ".", ";"}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(s[i:], "--"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '\'':
+			text, n, err := lexQuoted(s[i:], '\'', '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i += n
+		case c == '"':
+			text, n, err := lexQuoted(s[i:], '"', '"')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenQuoted, text, i})
+			i += n
+		case c == '[':
+			text, n, err := lexQuoted(s[i:], '[', ']')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenQuoted, text, i})
+			i += n
+		case c == '?':
+			tokens = append(tokens, token{tokenParam, "?", i})
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, s[i:j], i})
+			i = j
+		case isIdentByte(c):
+			j := i
+			for j < len(s) && (isIdentByte(s[j]) || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, s[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, v := range operators {
+				if strings.HasPrefix(s[i:], v) {
+					op = v
+					break
+				}
+			}
+			if op == "" {
+				return nil, errors.New(strings.Join([]string{"Unexpected character", strconv.Quote(string(c)), "at", strconv.Itoa(i)}, " "))
+			}
+			tokens = append(tokens, token{tokenOp, op, i})
+			i += len(op)
+		}
+	}
+	return translate(s, append(tokens, token{tokenEOF, "", len(s)})), nil
+}
+
+// keywords1C are keywords of 1C query language, ПО is BY after СГРУППИРОВАТЬ and УПОРЯДОЧИТЬ, else ON
+var keywords1C = map[string]string{
+	"ВЫБРАТЬ": "SELECT", "РАЗЛИЧНЫЕ": "DISTINCT", "ВСЕ": "ALL", "ПЕРВЫЕ": "TOP", "ИЗ": "FROM", "ГДЕ": "WHERE",
+	"СГРУППИРОВАТЬ": "GROUP", "УПОРЯДОЧИТЬ": "ORDER", "ИМЕЮЩИЕ": "HAVING", "КАК": "AS", "И": "AND", "ИЛИ": "OR",
+	"НЕ": "NOT", "В": "IN", "МЕЖДУ": "BETWEEN", "ПОДОБНО": "LIKE", "ЕСТЬ": "IS", "ИСТИНА": "TRUE", "ЛОЖЬ": "FALSE",
+	"ЛЕВОЕ": "LEFT", "ВНУТРЕННЕЕ": "INNER", "ВНЕШНЕЕ": "OUTER", "СОЕДИНЕНИЕ": "JOIN", "УБЫВ": "DESC", "ВОЗР": "ASC",
+}
+
+// functions1C are functions of 1C query language, translated only before "("
+var functions1C = map[string]string{
+	"КОЛИЧЕСТВО": "COUNT", "СУММА": "SUM", "СРЕДНЕЕ": "AVG", "МИНИМУМ": "MIN", "МАКСИМУМ": "MAX",
+	"ГОД": "YEAR", "МЕСЯЦ": "MONTH", "ДЕНЬ": "DAY", "ПОДСТРОКА": "SUBSTR", "ВРЕГ": "UPPER", "НРЕГ": "LOWER",
+	"ДЛИНАСТРОКИ": "LENGTH", "СОКРЛП": "TRIM", "СОКРЛ": "LTRIM", "СОКРП": "RTRIM", "ЕСТЬNULL": "IFNULL",
+	"ДАТАВРЕМЯ": "DATETIME",
+}
+
+// translate replaces keywords and functions of 1C query language by SQL ones,
+// in query starting with ВЫБРАТЬ "text" is string as in 1C
+func translate(s string, tokens []token) []token {
+	query1C := strings.EqualFold(tokens[0].text, "ВЫБРАТЬ")
+	for k, t := range tokens {
+		if query1C && t.kind == tokenQuoted && s[t.pos] == '"' {
+			tokens[k].kind = tokenString
+		}
+		if t.kind != tokenIdent {
+			continue
+		}
+		word := strings.ToUpper(t.text)
+		if word == "ПО" {
+			tokens[k].text = "ON"
+			if k > 0 && (tokens[k-1].is("GROUP") || tokens[k-1].is("ORDER")) {
+				tokens[k].text = "BY"
+			}
+		} else if v, ok := keywords1C[word]; ok {
+			tokens[k].text = v
+		} else if v, ok := functions1C[word]; ok && tokens[k+1].is("(") {
+			tokens[k].text = v
+		}
+	}
+	return tokens
+}
+
+// isIdentByte allows letters of any alphabet: bytes of UTF-8 sequences are >= 0x80
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// lexQuoted reads quoted text, closing quote is escaped by doubling, returns text and length with quotes
+func lexQuoted(s string, open, close byte) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != close {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == close && open == close {
+			b.WriteByte(close)
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, errors.New(strings.Join([]string{"Unterminated", string(open), "quote"}, " "))
+}
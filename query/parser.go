@@ -0,0 +1,557 @@
+package query
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Select is parsed statement:
+// SELECT [DISTINCT] [TOP n] items FROM table [alias] {[INNER|LEFT] JOIN table [alias] ON expr}
+// [WHERE expr] [GROUP BY exprs [HAVING expr]] [ORDER BY expr [ASC|DESC], ...] [LIMIT n [OFFSET m]]
+type Select struct {
+	Distinct bool
+	Items    []SelectItem
+	From     *TableRef
+	Joins    []Join
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []OrderItem
+	Limit    int // -1 without LIMIT
+	Offset   int
+}
+
+// SelectItem is expression with alias, Star for * and alias.*
+type SelectItem struct {
+	Expr  Expr
+	Alias string
+	Star  bool
+	Table string // qualifier of alias.*
+	Text  string // source text of expression, name of column without alias
+}
+
+type TableRef struct {
+	Name  string
+	Alias string
+}
+
+type Join struct {
+	Table TableRef
+	Left  bool
+	On    Expr
+}
+
+type OrderItem struct {
+	Expr Expr
+	Desc bool
+}
+
+// Expr is node of expression tree
+type Expr interface{}
+
+type (
+	Literal struct{ Value value }
+	Param   struct{ N int }
+	Column  struct {
+		Table string
+		Name  string
+		index int // position in row of joined tables, set by bind
+		field string
+	}
+	Unary struct {
+		Op string // "-", "NOT"
+		X  Expr
+	}
+	Binary struct {
+		Op   string // OR AND = <> < <= > >= + - * / % ||
+		L, R Expr
+	}
+	Like struct {
+		X, Pattern Expr
+		Not        bool
+	}
+	In struct {
+		X    Expr
+		List []Expr
+		Not  bool
+	}
+	Between struct {
+		X, From, To Expr
+		Not         bool
+	}
+	IsNull struct {
+		X   Expr
+		Not bool
+	}
+	Call struct {
+		Name     string // upper case
+		Args     []Expr
+		Star     bool // COUNT(*)
+		Distinct bool
+		agg      int // index of aggregate, -1 for scalar function
+	}
+)
+
+var aggregates = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+// keywords which end expression or table reference and can not be alias without AS
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "WHERE": true, "GROUP": true, "BY": true, "HAVING": true,
+	"ORDER": true, "ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true, "JOIN": true, "INNER": true,
+	"LEFT": true, "OUTER": true, "ON": true, "AS": true, "AND": true, "OR": true, "NOT": true, "IN": true,
+	"LIKE": true, "BETWEEN": true, "IS": true, "NULL": true, "TRUE": true, "FALSE": true, "UNION": true,
+	"TOP": true, "ALL": true,
+}
+
+type parser struct {
+	s      string
+	tokens []token
+	pos    int
+	params int
+}
+
+// Parse parses SELECT statement, parameters are "?"
+func Parse(s string) (*Select, int, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	p := &parser{s: s, tokens: tokens}
+	stmt, err := p.parseSelect()
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.peek().is(";") {
+		p.pos++
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, 0, p.unexpected()
+	}
+	return stmt, p.params, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept skips keywords or operators if all of them are next
+func (p *parser) accept(words ...string) bool {
+	for k, v := range words {
+		if p.pos+k >= len(p.tokens) || !p.tokens[p.pos+k].is(v) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *parser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return errors.New(strings.Join([]string{"Expected", strings.Join(words, " "), "at", strconv.Itoa(p.peek().pos)}, " "))
+	}
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return errors.New("Unexpected end of query")
+	}
+	return errors.New(strings.Join([]string{"Unexpected", strconv.Quote(t.text), "at", strconv.Itoa(t.pos)}, " "))
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind == tokenQuoted || t.kind == tokenIdent && !keywords[strings.ToUpper(t.text)] {
+		p.pos++
+		return t.text, nil
+	}
+	return "", p.unexpected()
+}
+
+// alias reads optional [AS] alias
+func (p *parser) alias() (string, error) {
+	if p.accept("AS") {
+		return p.ident()
+	}
+	t := p.peek()
+	if t.kind == tokenQuoted || t.kind == tokenIdent && !keywords[strings.ToUpper(t.text)] {
+		p.pos++
+		return t.text, nil
+	}
+	return "", nil
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	if !p.accept("SELECT") {
+		return nil, errors.New("Only SELECT is supported, base is read-only")
+	}
+	stmt := &Select{Limit: -1}
+	stmt.Distinct = p.accept("DISTINCT")
+	if !stmt.Distinct {
+		p.accept("ALL")
+	}
+	if p.accept("TOP") {
+		var err error
+		stmt.Limit, err = p.parseCount()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Items = append(stmt.Items, item)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if p.accept("FROM") {
+		table, err := p.parseTableRef()
+		if err != nil {
+			return nil, err
+		}
+		stmt.From = &table
+	joins:
+		for {
+			join := Join{}
+			switch {
+			case p.accept("JOIN"), p.accept("INNER", "JOIN"):
+			case p.accept("LEFT", "JOIN"), p.accept("LEFT", "OUTER", "JOIN"):
+				join.Left = true
+			default:
+				break joins
+			}
+			join.Table, err = p.parseTableRef()
+			if err != nil {
+				return nil, err
+			}
+			err = p.expect("ON")
+			if err != nil {
+				return nil, err
+			}
+			join.On, err = p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			stmt.Joins = append(stmt.Joins, join)
+		}
+	}
+
+	var err error
+	if p.accept("WHERE") {
+		stmt.Where, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("GROUP", "BY") {
+		stmt.GroupBy, err = p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("HAVING") {
+		stmt.Having, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("ORDER", "BY") {
+		for {
+			item := OrderItem{}
+			item.Expr, err = p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if p.accept("DESC") {
+				item.Desc = true
+			} else {
+				p.accept("ASC")
+			}
+			stmt.OrderBy = append(stmt.OrderBy, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if p.accept("LIMIT") {
+		stmt.Limit, err = p.parseCount()
+		if err != nil {
+			return nil, err
+		}
+		if p.accept("OFFSET") {
+			stmt.Offset, err = p.parseCount()
+		} else if p.accept(",") { // LIMIT offset, count
+			stmt.Offset = stmt.Limit
+			stmt.Limit, err = p.parseCount()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+func (p *parser) parseCount() (int, error) {
+	t := p.next()
+	n, err := strconv.Atoi(t.text)
+	if t.kind != tokenNumber || err != nil || n < 0 {
+		return 0, errors.New(strings.Join([]string{"Expected number at", strconv.Itoa(t.pos)}, " "))
+	}
+	return n, nil
+}
+
+func (p *parser) parseSelectItem() (SelectItem, error) {
+	if p.accept("*") {
+		return SelectItem{Star: true}, nil
+	}
+	t := p.peek()
+	if (t.kind == tokenIdent || t.kind == tokenQuoted) && p.tokens[p.pos+1].is(".") && p.tokens[p.pos+2].is("*") {
+		p.pos += 3
+		return SelectItem{Star: true, Table: t.text}, nil
+	}
+
+	start := t.pos
+	expr, err := p.parseExpr()
+	if err != nil {
+		return SelectItem{}, err
+	}
+	item := SelectItem{Expr: expr, Text: strings.TrimSpace(p.s[start:p.peek().pos])}
+	if column, ok := expr.(*Column); ok {
+		item.Text = column.Name
+	}
+	item.Alias, err = p.alias()
+	return item, err
+}
+
+func (p *parser) parseTableRef() (TableRef, error) {
+	name, err := p.ident()
+	if err != nil {
+		return TableRef{}, err
+	}
+	alias, err := p.alias()
+	return TableRef{Name: name, Alias: alias}, err
+}
+
+func (p *parser) parseExprList() ([]Expr, error) {
+	var list []Expr
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expr)
+		if !p.accept(",") {
+			return list, nil
+		}
+	}
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	l, err := p.parseAnd()
+	for err == nil && p.accept("OR") {
+		var r Expr
+		r, err = p.parseAnd()
+		l = &Binary{Op: "OR", L: l, R: r}
+	}
+	return l, err
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	l, err := p.parseNot()
+	for err == nil && p.accept("AND") {
+		var r Expr
+		r, err = p.parseNot()
+		l = &Binary{Op: "AND", L: l, R: r}
+	}
+	return l, err
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.accept("NOT") {
+		x, err := p.parseNot()
+		return &Unary{Op: "NOT", X: x}, err
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	l, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "<>", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			r, err := p.parseAdditive()
+			if op == "!=" {
+				op = "<>"
+			}
+			return &Binary{Op: op, L: l, R: r}, err
+		}
+	}
+
+	if p.accept("IS") {
+		not := p.accept("NOT")
+		return &IsNull{X: l, Not: not}, p.expect("NULL")
+	}
+	not := p.accept("NOT")
+	switch {
+	case p.accept("LIKE"):
+		pattern, err := p.parseAdditive()
+		return &Like{X: l, Pattern: pattern, Not: not}, err
+	case p.accept("IN"):
+		err = p.expect("(")
+		if err != nil {
+			return nil, err
+		}
+		list, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		return &In{X: l, List: list, Not: not}, p.expect(")")
+	case p.accept("BETWEEN"):
+		from, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		err = p.expect("AND")
+		if err != nil {
+			return nil, err
+		}
+		to, err := p.parseAdditive()
+		return &Between{X: l, From: from, To: to, Not: not}, err
+	}
+	if not {
+		return nil, p.unexpected()
+	}
+	return l, nil
+}
+
+func (p *parser) parseAdditive() (Expr, error) {
+	l, err := p.parseMultiplicative()
+	for err == nil {
+		op := p.peek()
+		if !op.is("+") && !op.is("-") && !op.is("||") {
+			break
+		}
+		p.pos++
+		var r Expr
+		r, err = p.parseMultiplicative()
+		l = &Binary{Op: op.text, L: l, R: r}
+	}
+	return l, err
+}
+
+func (p *parser) parseMultiplicative() (Expr, error) {
+	l, err := p.parseUnary()
+	for err == nil {
+		op := p.peek()
+		if !op.is("*") && !op.is("/") && !op.is("%") {
+			break
+		}
+		p.pos++
+		var r Expr
+		r, err = p.parseUnary()
+		l = &Binary{Op: op.text, L: l, R: r}
+	}
+	return l, err
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		return &Unary{Op: "-", X: x}, err
+	}
+	p.accept("+")
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber:
+		p.pos++
+		n, ok := parseNumber(t.text)
+		if !ok {
+			return nil, errors.New(strings.Join([]string{"Wrong number", t.text, "at", strconv.Itoa(t.pos)}, " "))
+		}
+		return &Literal{n}, nil
+	case tokenString:
+		p.pos++
+		return &Literal{t.text}, nil
+	case tokenParam:
+		p.pos++
+		p.params++
+		return &Param{N: p.params - 1}, nil
+	case tokenOp:
+		if p.accept("(") {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expect(")")
+		}
+		return nil, p.unexpected()
+	case tokenIdent:
+		switch {
+		case p.accept("NULL"):
+			return &Literal{nil}, nil
+		case p.accept("TRUE"):
+			return &Literal{true}, nil
+		case p.accept("FALSE"):
+			return &Literal{false}, nil
+		}
+		if p.tokens[p.pos+1].is("(") {
+			return p.parseCall()
+		}
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept(".") {
+		field, err := p.ident()
+		return &Column{Table: name, Name: field}, err
+	}
+	return &Column{Name: name}, nil
+}
+
+func (p *parser) parseCall() (Expr, error) {
+	call := &Call{Name: strings.ToUpper(p.next().text), agg: -1}
+	p.next() // (
+	if aggregates[call.Name] {
+		if call.Name == "COUNT" && p.accept("*") {
+			call.Star = true
+			return call, p.expect(")")
+		}
+		call.Distinct = p.accept("DISTINCT")
+	}
+	if p.accept(")") {
+		return call, nil
+	}
+	var err error
+	call.Args, err = p.parseExprList()
+	if err != nil {
+		return nil, err
+	}
+	return call, p.expect(")")
+}
@@ -0,0 +1,65 @@
+package query
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/AlekseySP/onec/onec"
+)
+
+// Result of Query, values are typed as onec.Value returns them: nil, string, json.Number, bool, time.Time, []byte
+type Result struct {
+	Columns []string
+	Types   []string // type of 1C field (NVC, N, DT...), empty for expressions
+	cursor  cursor
+}
+
+// Query runs SELECT in SQL or 1C query language (ВЫБРАТЬ ... ИЗ ...) on opened base,
+// rows are read from base while Next is called when result does not need sorting or grouping
+func Query(b *onec.BaseOnec, q string, args ...interface{}) (*Result, error) {
+	return QueryContext(context.Background(), b, q, 0, args...)
+}
+
+// QueryContext runs query which stops with error of ctx when it is done. Rows kept in memory for sorting, grouping,
+// DISTINCT and right side of joins are limited by maxRows, 0 - no limit
+func QueryContext(ctx context.Context, b *onec.BaseOnec, q string, maxRows int, args ...interface{}) (*Result, error) {
+	stmt, _, err := Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	p, err := compile(newBaseCatalog(b), stmt)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]value, len(args))
+	for k, v := range args {
+		arg, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return nil, err
+		}
+		values[k] = fromDriver(arg)
+	}
+	c, err := p.run(values, &limits{ctx: ctx, maxRows: maxRows})
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Columns: p.columns, Types: p.types, cursor: c}, nil
+}
+
+// Next returns next row, nil at the end
+func (r *Result) Next() ([]interface{}, error) {
+	row, err := r.cursor.next()
+	if err != nil || row == nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(row))
+	for k, v := range row {
+		v = resolve(v)
+		if n, ok := v.(number); ok {
+			values[k] = json.Number(n.String())
+			continue
+		}
+		values[k] = v
+	}
+	return values, nil
+}
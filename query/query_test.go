@@ -0,0 +1,242 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryCatalog map[string]memoryTable
+
+type memoryTable struct {
+	table onec.Table
+	rows  [][]value
+}
+
+func (c memoryCatalog) table(name string) (onec.Table, bool) {
+	t, ok := c[strings.ToUpper(name)]
+	return t.table, ok
+}
+
+func (c memoryCatalog) rows(t onec.Table, load []bool) rowReader {
+	return &sliceCursor{rows: c[t.Name].rows}
+}
+
+func memoryTableOf(name string, fields []onec.Field, rows ...[]value) memoryTable {
+	t := onec.Table{Name: name, Fields: map[string]onec.Field{}}
+	for _, f := range fields {
+		t.Fields[f.Name] = f
+		t.FieldsName = append(t.FieldsName, f.Name)
+	}
+	return memoryTable{table: t, rows: rows}
+}
+
+func num(s string) number {
+	n, _ := parseNumber(s)
+	return n
+}
+
+func testCatalog() memoryCatalog {
+	date := time.Date(2013, 4, 3, 14, 41, 21, 0, time.UTC)
+	return memoryCatalog{
+		"_REFERENCE1": memoryTableOf("_REFERENCE1", []onec.Field{
+			{Name: "_IDRREF", FieldType: "B", Lenth: 16},
+			{Name: "_DESCRIPTION", FieldType: "NVC", Lenth: 25},
+		},
+			[]value{"01", "Ромашка"},
+			[]value{"02", "Лютик"},
+			[]value{"03", "Василек"},
+		),
+		"_DOCUMENT2": memoryTableOf("_DOCUMENT2", []onec.Field{
+			{Name: "_NUMBER", FieldType: "NC", Lenth: 5},
+			{Name: "_DATE_TIME", FieldType: "DT"},
+			{Name: "_FLD3RREF", FieldType: "B", Lenth: 16},
+			{Name: "_FLD4", FieldType: "N", Lenth: 15, Precision: 2, NullExist: true},
+		},
+			[]value{"00001", date, "01", num("100.50")},
+			[]value{"00002", date.AddDate(0, 1, 0), "02", num("20")},
+			[]value{"00003", date.AddDate(0, 1, 0), "01", num("5.25")},
+			[]value{"00004", date.AddDate(1, 0, 0), "09", nil},
+		),
+	}
+}
+
+func TestQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		args     []value
+		expected [][]interface{}
+	}{
+		{"constant", "SELECT 1 + 2 * 3, 'a' || 'b', 7 / 2, 7 % 2", nil,
+			[][]interface{}{{int64(7), "ab", "3.5", int64(1)}}},
+		{"where", "SELECT _NUMBER FROM _Document2 WHERE _FLD4 > 10 ORDER BY _NUMBER DESC", nil,
+			[][]interface{}{{"00002"}, {"00001"}}},
+		{"null", "SELECT _NUMBER FROM _DOCUMENT2 WHERE _FLD4 IS NULL", nil,
+			[][]interface{}{{"00004"}}},
+		{"date and parameter", "SELECT _NUMBER FROM _DOCUMENT2 WHERE _DATE_TIME >= '2013-05-01' AND _DATE_TIME < ?", []value{"2014-01-01"},
+			[][]interface{}{{"00002"}, {"00003"}}},
+		{"limit offset", "SELECT _NUMBER FROM _DOCUMENT2 LIMIT 2 OFFSET 1", nil,
+			[][]interface{}{{"00002"}, {"00003"}}},
+		{"like in between", "SELECT _NUMBER FROM _DOCUMENT2 WHERE _NUMBER LIKE '%0_' AND _FLD3RREF IN ('01', '02') AND _FLD4 BETWEEN 5 AND 50", nil,
+			[][]interface{}{{"00002"}, {"00003"}}},
+		{"aggregates", "SELECT COUNT(*), COUNT(_FLD4), SUM(_FLD4), MIN(_FLD4), MAX(_NUMBER), AVG(_FLD4), COUNT(DISTINCT _FLD3RREF) FROM _DOCUMENT2", nil,
+			[][]interface{}{{int64(4), int64(3), "125.75", "5.25", "00004", "41.91666667", int64(3)}}},
+		{"aggregate of no rows", "SELECT COUNT(*), SUM(_FLD4) FROM _DOCUMENT2 WHERE 1 = 0", nil,
+			[][]interface{}{{int64(0), nil}}},
+		{"group by", "SELECT YEAR(_DATE_TIME) y, COUNT(*) n FROM _DOCUMENT2 GROUP BY YEAR(_DATE_TIME) HAVING COUNT(*) > 1 ORDER BY y", nil,
+			[][]interface{}{{int64(2013), int64(3)}}},
+		{"join", "SELECT d._NUMBER, r._DESCRIPTION FROM _DOCUMENT2 d JOIN _REFERENCE1 r ON d._FLD3RREF = r._IDRREF ORDER BY 1", nil,
+			[][]interface{}{{"00001", "Ромашка"}, {"00002", "Лютик"}, {"00003", "Ромашка"}}},
+		{"left join", "SELECT d._NUMBER, r._DESCRIPTION FROM _DOCUMENT2 AS d LEFT JOIN _REFERENCE1 AS r ON r._IDRREF = d._FLD3RREF WHERE r._IDRREF IS NULL", nil,
+			[][]interface{}{{"00004", nil}}},
+		{"join group", "SELECT r._DESCRIPTION, SUM(d._FLD4) s FROM _REFERENCE1 r LEFT JOIN _DOCUMENT2 d ON d._FLD3RREF = r._IDRREF GROUP BY r._DESCRIPTION ORDER BY s DESC", nil,
+			[][]interface{}{{"Ромашка", "105.75"}, {"Лютик", int64(20)}, {"Василек", nil}}},
+		{"distinct", "SELECT DISTINCT _FLD3RREF FROM _DOCUMENT2 ORDER BY _FLD3RREF", nil,
+			[][]interface{}{{"01"}, {"02"}, {"09"}}},
+		{"1C query language", "ВЫБРАТЬ ПЕРВЫЕ 1 Р._DESCRIPTION КАК Имя, КОЛИЧЕСТВО(*) ИЗ _REFERENCE1 КАК Р ЛЕВОЕ СОЕДИНЕНИЕ _DOCUMENT2 КАК Д ПО Д._FLD3RREF = Р._IDRREF " +
+			"ГДЕ Р._DESCRIPTION <> \"Лютик\" И Д._DATE_TIME < ДАТАВРЕМЯ(2014, 1, 1) СГРУППИРОВАТЬ ПО Р._DESCRIPTION УПОРЯДОЧИТЬ ПО Имя УБЫВ", nil,
+			[][]interface{}{{"Ромашка", int64(2)}}},
+		{"star", "SELECT r.* FROM _REFERENCE1 r WHERE UPPER(_DESCRIPTION) = 'ЛЮТИК'", nil,
+			[][]interface{}{{"02", "Лютик"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stmt, _, err := Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := compile(testCatalog(), stmt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := p.run(tc.args, &limits{ctx: context.Background()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result [][]interface{}
+			for {
+				row, err := c.next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if row == nil {
+					break
+				}
+				values := make([]interface{}, len(row))
+				for k, v := range row {
+					values[k] = toDriver(v)
+				}
+				result = append(result, values)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("%s: got %v, expected %v", tc.query, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"DELETE FROM _DOCUMENT2", "Only SELECT is supported, base is read-only"},
+		{"SELECT * FROM _DOCUMENT9", "Table not found _DOCUMENT9"},
+		{"SELECT _CODE FROM _DOCUMENT2", "Column not found _CODE"},
+		{"SELECT _NUMBER FROM _DOCUMENT2 WHERE COUNT(*) > 1", "Aggregate COUNT is not allowed here"},
+		{"SELECT _IDRREF FROM _REFERENCE1 a JOIN _REFERENCE1 b ON a._IDRREF = b._IDRREF", "Ambiguous column _IDRREF"},
+		{"SELECT 'a", "Unterminated ' quote"},
+	}
+
+	for _, tc := range testCases {
+		stmt, _, err := Parse(tc.query)
+		if err == nil {
+			_, err = compile(testCatalog(), stmt)
+		}
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%s: got error %v, expected %s", tc.query, err, tc.expected)
+		}
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	testCases := []struct {
+		query    string
+		maxRows  int
+		expected string
+	}{
+		{"SELECT _NUMBER FROM _DOCUMENT2 ORDER BY _NUMBER", 3, "Query keeps more than 3 rows in memory, use WHERE or fewer tables"},
+		{"SELECT DISTINCT _FLD3RREF FROM _DOCUMENT2", 2, "Query keeps more than 2 rows in memory, use WHERE or fewer tables"},
+		{"SELECT _FLD3RREF, COUNT(*) FROM _DOCUMENT2 GROUP BY _FLD3RREF", 2, "Query keeps more than 2 rows in memory, use WHERE or fewer tables"},
+		{"SELECT _NUMBER FROM _DOCUMENT2 d JOIN _REFERENCE1 r ON d._FLD3RREF = r._IDRREF", 2, "Query keeps more than 2 rows in memory, use WHERE or fewer tables"},
+		{"SELECT _FLD3RREF, COUNT(*) FROM _DOCUMENT2 GROUP BY _FLD3RREF", 3, ""},
+		{"SELECT _NUMBER FROM _DOCUMENT2", 1, ""},
+	}
+
+	for _, tc := range testCases {
+		stmt, _, err := Parse(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := compile(testCatalog(), stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := p.run(nil, &limits{ctx: context.Background(), maxRows: tc.maxRows})
+		for err == nil {
+			var row []value
+			if row, err = c.next(); row == nil {
+				break
+			}
+		}
+		if tc.expected == "" && err != nil || tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+			t.Errorf("%s: got error %v, expected %q", tc.query, err, tc.expected)
+		}
+	}
+}
+
+func TestQueryContext(t *testing.T) {
+	table := onectest.Table{
+		Name:   "_REFERENCE1",
+		Fields: []onectest.Field{{Name: "_CODE", Type: "NVC", Length: 5}, {Name: "_NOTE", Type: "NT", Null: true}},
+		Rows: []onectest.Row{
+			{Values: map[string]interface{}{"_CODE": "2", "_NOTE": "Лютик"}},
+			{Values: map[string]interface{}{"_CODE": "1"}},
+			{Values: map[string]interface{}{"_CODE": "3", "_NOTE": "Ромашка"}},
+		},
+	}
+	b := onectest.Open(t, onectest.Base{Tables: []onectest.Table{table}})
+
+	r, err := Query(b, "SELECT _CODE, _NOTE FROM _REFERENCE1 WHERE _NOTE IS NULL OR _NOTE <> 'Ромашка' ORDER BY _NOTE DESC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]interface{}
+	for {
+		row, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row == nil {
+			break
+		}
+		rows = append(rows, row)
+	}
+	expected := [][]interface{}{{"2", "Лютик"}, {"1", nil}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("got %v, expected %v", rows, expected)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := QueryContext(ctx, b, "SELECT _CODE FROM _REFERENCE1 ORDER BY _CODE", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled query: got error %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package query
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/AlekseySP/onec/onec"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// value is nil, string, bool, time.Time, []byte, number or *lazyBlob which is read by resolve
+type value = interface{}
+
+// lazyBlob is not NULL field I or NT of row, blob is read when value is used
+type lazyBlob struct {
+	b      *onec.BaseOnec
+	obj    onec.Object
+	field  onec.Field
+	loaded bool
+	v      value
+}
+
+// resolve reads value of lazy blob
+func resolve(v value) value {
+	l, ok := v.(*lazyBlob)
+	if !ok {
+		return v
+	}
+	if !l.loaded {
+		l.v, l.loaded = fromOnec(l.b.Value(l.obj, l.field.Name, true), l.field), true
+	}
+	return l.v
+}
+
+// number is exact decimal, scale is the number of digits after point to format it
+type number struct {
+	r     *big.Rat
+	scale int
+}
+
+// divScale is the minimal scale of quotient and average
+const divScale = 6
+
+var dateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02", "2006.01.02 15:04:05", "2006.01.02"}
+
+func parseNumber(s string) (number, bool) {
+	s = strings.TrimSpace(s)
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsAny(s, "/eE") {
+		return number{}, false
+	}
+	scale := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = len(s) - i - 1
+	}
+	return number{r, scale}, true
+}
+
+func intNumber(n int64) number {
+	return number{new(big.Rat).SetInt64(n), 0}
+}
+
+func (n number) String() string {
+	s := n.r.FloatString(n.scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// fromOnec converts typed value of onec.Value to value
+func fromOnec(v interface{}, field onec.Field) value {
+	if n, ok := v.(json.Number); ok {
+		r, ok := new(big.Rat).SetString(string(n))
+		if !ok {
+			return nil
+		}
+		return number{r, field.Precision}
+	}
+	return v
+}
+
+// fromDriver converts parameter of statement to value
+func fromDriver(v driver.Value) value {
+	switch v := v.(type) {
+	case int64:
+		return intNumber(v)
+	case float64:
+		n, _ := parseNumber(strconv.FormatFloat(v, 'f', -1, 64))
+		return n
+	}
+	return v
+}
+
+// toDriver converts value to driver.Value: integers are int64, other numbers are exact decimal text
+func toDriver(v value) driver.Value {
+	v = resolve(v)
+	if n, ok := v.(number); ok {
+		if n.r.IsInt() && n.r.Num().IsInt64() {
+			return n.r.Num().Int64()
+		}
+		return n.String()
+	}
+	return v
+}
+
+// text formats value as onec.FormatValue does
+func text(v value) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format("2006-01-02T15:04:05")
+	case []byte:
+		return hex.EncodeToString(v)
+	}
+	return ""
+}
+
+// key is the value as map key for joins, groups and DISTINCT, "" for NULL
+func key(v value) string {
+	switch v := resolve(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return "s" + strings.TrimRight(v, " ")
+	case number:
+		return "n" + v.r.RatString()
+	case bool:
+		return "l" + strconv.FormatBool(v)
+	case time.Time:
+		return "t" + v.Format(time.RFC3339)
+	case []byte:
+		return "b" + string(v)
+	}
+	return ""
+}
+
+func toNumber(v value) (number, bool) {
+	switch v := v.(type) {
+	case number:
+		return v, true
+	case string:
+		return parseNumber(v)
+	case bool:
+		if v {
+			return intNumber(1), true
+		}
+		return intNumber(0), true
+	}
+	return number{}, false
+}
+
+func toTime(v value) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// compare compares not NULL values, string is converted to type of other operand when possible.
+// Trailing spaces of strings are ignored as in fixed length NC fields
+func compare(a, b value) int {
+	a, b = resolve(a), resolve(b)
+	switch a.(type) {
+	case number, bool:
+		if x, ok := toNumber(a); ok {
+			if y, ok := toNumber(b); ok {
+				return x.r.Cmp(y.r)
+			}
+		}
+	case time.Time:
+		if y, ok := toTime(b); ok {
+			x := a.(time.Time)
+			return x.Compare(y)
+		}
+	case []byte:
+		if y, ok := b.([]byte); ok {
+			return bytes.Compare(a.([]byte), y)
+		}
+	case string:
+		switch b.(type) {
+		case number, bool, time.Time:
+			return -compare(b, a)
+		}
+	}
+	return strings.Compare(strings.TrimRight(text(a), " "), strings.TrimRight(text(b), " "))
+}
+
+// compareNullsFirst orders NULL before any value
+func compareNullsFirst(a, b value) int {
+	a, b = resolve(a), resolve(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return compare(a, b)
+}
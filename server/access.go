@@ -0,0 +1,107 @@
+package server
+
+import (
+	"crypto/subtle"
+	"github.com/AlekseySP/onec/onec"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DefaultMask hides password hashes of users
+const DefaultMask = "V8USERS.DATA"
+
+// Access restricts web viewer: basic authentication by User and Password or bearer Token,
+// tables are filtered by Allow and Deny patterns, fields matching Mask patterns TABLE.FIELD are masked.
+// Patterns are path.Match patterns, names are compared in upper case
+type Access struct {
+	User     string
+	Password string
+	Token    string
+	Allow    []string // empty - all tables
+	Deny     []string
+	Mask     []string
+}
+
+// Config of web server, Addr is host:port
+type Config struct {
+	Addr   string
+	Access Access
+	TLS    TLS
+}
+
+// ParsePatterns splits comma separated list of patterns
+func ParsePatterns(s string) []string {
+	var patterns []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			patterns = append(patterns, v)
+		}
+	}
+	return patterns
+}
+
+func matchAny(patterns []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, v := range patterns {
+		if ok, _ := path.Match(v, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowTable is true when table matches Allow (or Allow is empty) and does not match Deny
+func (a Access) AllowTable(table string) bool {
+	return (len(a.Allow) == 0 || matchAny(a.Allow, table)) && !matchAny(a.Deny, table)
+}
+
+// MaskField is true when TABLE.FIELD matches Mask
+func (a Access) MaskField(table string, field string) bool {
+	return matchAny(a.Mask, table+"."+field)
+}
+
+// Restrict returns view of base with allowed tables and masked fields
+func (a Access) Restrict(b *onec.BaseOnec) *onec.BaseOnec {
+	if len(a.Allow) == 0 && len(a.Deny) == 0 && len(a.Mask) == 0 {
+		return b
+	}
+	return b.Restrict(a.AllowTable, a.MaskField)
+}
+
+// Handler checks credentials of request before h, without User and Token all requests are allowed
+func (a Access) Handler(h http.Handler) http.Handler {
+	if a.User == "" && a.Token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.authorized(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if a.User != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="onec", charset="UTF-8"`)
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+func (a Access) authorized(r *http.Request) bool {
+	if a.Token != "" {
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") && equal(strings.TrimPrefix(auth, "Bearer "), a.Token) == 1 {
+			return true
+		}
+	}
+	if a.User != "" {
+		user, password, ok := r.BasicAuth()
+		// both are compared to take the same time for wrong user and wrong password
+		if ok && equal(user, a.User)&equal(password, a.Password) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(s string, t string) int {
+	return subtle.ConstantTimeCompare([]byte(s), []byte(t))
+}
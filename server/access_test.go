@@ -0,0 +1,139 @@
+package server
+
+import (
+	"github.com/AlekseySP/onec/onec/onectest"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParsePatterns(t *testing.T) {
+	a := Access{Allow: ParsePatterns(" _reference*, V8USERS ,"), Deny: ParsePatterns("_REFERENCE1")}
+	for table, expected := range map[string]bool{"_REFERENCE2": true, "_reference3": true, "V8USERS": true, "_REFERENCE1": false, "_DOCUMENT1": false} {
+		if a.AllowTable(table) != expected {
+			t.Errorf("AllowTable(%s) is %v", table, !expected)
+		}
+	}
+	if a := (Access{Mask: ParsePatterns(DefaultMask)}); !a.MaskField("v8users", "data") || a.MaskField("V8USERS", "NAME") {
+		t.Error("unexpected masked fields")
+	}
+}
+
+func TestAccessAuthentication(t *testing.T) {
+	path := writeTestBase(t, "base.1CD", testBase())
+	bs := NewBases([]string{path}, Access{User: "admin", Password: "secret", Token: "token"})
+	testCases := []struct {
+		name   string
+		auth   func(r *http.Request)
+		status int
+	}{
+		{"no credentials", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }, http.StatusUnauthorized},
+		{"wrong user", func(r *http.Request) { r.SetBasicAuth("user", "secret") }, http.StatusUnauthorized},
+		{"wrong token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+		{"password", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, http.StatusOK},
+		{"token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") }, http.StatusOK},
+	}
+	for _, tc := range testCases {
+		for _, path := range []string{"/base/base/", "/base/base" + apiPrefix + "/tables", "/base/base/export/_REFERENCE1.csv"} {
+			r := httptest.NewRequest("GET", path, nil)
+			tc.auth(r)
+			w := httptest.NewRecorder()
+			bs.ServeHTTP(w, r)
+			if w.Code != tc.status {
+				t.Errorf("%s %s: status %d, expected %d", tc.name, path, w.Code, tc.status)
+			}
+			if w.Code == http.StatusUnauthorized && !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic") {
+				t.Errorf("%s %s: no WWW-Authenticate", tc.name, path)
+			}
+		}
+	}
+
+	bs = NewBases([]string{path}, Access{Token: "token"})
+	if w := get(bs, "/base/base/"); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "" {
+		t.Errorf("token only: status %d, WWW-Authenticate %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestAccessDeny(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{Deny: []string{"V8USERS"}})
+	for _, path := range []string{
+		"/table/V8USERS", "/table/V8USERS/row/1", "/tabledescription/V8USERS", "/table/V8USERS/row/1/field/DATA/blob",
+		"/export/V8USERS.csv", "/export/V8USERS.parquet",
+		apiPrefix + "/tables/V8USERS", apiPrefix + "/tables/V8USERS/rows", apiPrefix + "/tables/V8USERS/rows/1",
+		apiPrefix + "/tables/V8USERS/rows/1/fields/DATA/blob",
+	} {
+		if w := get(bs, "/base/base"+path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, expected 404", path, w.Code)
+		}
+	}
+	if w := get(bs, "/base/base/query/export.csv?q=SELECT+*+FROM+V8USERS"); w.Code != http.StatusBadRequest {
+		t.Errorf("query of denied table: status %d", w.Code)
+	}
+	for _, path := range []string{"/", apiPrefix + "/tables", "/summary", "/query?q=SELECT+*+FROM+V8USERS"} {
+		w := get(bs, "/base/base"+path)
+		if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Admin") || path != "/query?q=SELECT+*+FROM+V8USERS" && strings.Contains(w.Body.String(), "V8USERS") {
+			t.Errorf("%s: status %d shows denied table", path, w.Code)
+		}
+	}
+	if w := get(bs, "/base/base/table/_REFERENCE1"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Первый") {
+		t.Errorf("allowed table: status %d", w.Code)
+	}
+}
+
+func TestAccessMask(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{Mask: []string{DefaultMask, "V8USERS.NAME"}})
+	for _, path := range []string{
+		"/table/V8USERS", "/table/V8USERS/row/1", apiPrefix + "/tables/V8USERS/rows", apiPrefix + "/tables/V8USERS/rows/1",
+		"/export/V8USERS.csv?blobs=1", "/export/V8USERS.jsonl?blobs=1", "/query?q=SELECT+*+FROM+V8USERS", "/query/export.jsonl?q=SELECT+*+FROM+V8USERS",
+	} {
+		w := get(bs, "/base/base"+path)
+		body := w.Body.String()
+		if w.Code != http.StatusOK || strings.Contains(body, "SECRETHASH") || strings.Contains(body, "Admin") {
+			t.Errorf("%s: status %d shows masked values: %s", path, w.Code, body)
+		}
+		if !strings.Contains(body, "01") {
+			t.Errorf("%s: unmasked field ID is not shown: %s", path, body)
+		}
+	}
+	for _, path := range []string{"/table/V8USERS/row/1", apiPrefix + "/tables/V8USERS/rows/1"} {
+		if body := get(bs, "/base/base"+path).Body.String(); strings.Count(body, "***") < 2 {
+			t.Errorf("%s: masked fields are not marked: %s", path, body)
+		}
+	}
+	for _, path := range []string{"/table/V8USERS/row/1/field/DATA/blob", apiPrefix + "/tables/V8USERS/rows/1/fields/DATA/blob"} {
+		if w := get(bs, "/base/base"+path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, expected 404", path, w.Code)
+		}
+	}
+}
+
+func TestAccessBlobByOffset(t *testing.T) {
+	path := writeTestBase(t, "base.1CD", testBase())
+	full := onectest.Open(t, testBase())
+	blob := strconv.Itoa(full.TableDescription["V8USERS"].BlobOffset) + "/1/10"
+	testCases := []struct {
+		name   string
+		access Access
+		status int
+	}{
+		{"no restrictions", Access{}, http.StatusOK},
+		{"deny", Access{Deny: []string{"V8USERS"}}, http.StatusNotFound},
+		{"allow", Access{Allow: []string{"_REFERENCE*"}}, http.StatusNotFound},
+		{"mask", Access{Mask: []string{DefaultMask}}, http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		bs := NewBases([]string{path}, tc.access)
+		for _, path := range []string{"/blob/" + blob, "/blob/" + blob + "/raw", apiPrefix + "/blob/" + blob} {
+			w := get(bs, "/base/base"+path)
+			if w.Code != tc.status {
+				t.Errorf("%s %s: status %d, expected %d", tc.name, path, w.Code, tc.status)
+			}
+			if tc.status == http.StatusNotFound && strings.Contains(w.Body.String(), "SECRETHASH") {
+				t.Errorf("%s %s: blob is shown", tc.name, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const apiPrefix = "/api/v1"
+const apiDefaultLimit = 100
+const apiMaxLimit = 10000
+
+type ApiTable struct {
+	Name           string `json:"name"`
+	NumberOfFields int    `json:"numberOfFields"`
+	RowLength      int    `json:"rowLength"`
+	DataOffset     int    `json:"dataOffset"`
+	BlobOffset     int    `json:"blobOffset"`
+	IndexOffset    int    `json:"indexOffset"`
+	RecordLock     bool   `json:"recordLock"`
+}
+
+type ApiField struct {
+	Name            string `json:"name"`
+	FieldType       string `json:"type"`
+	NullExist       bool   `json:"nullExist"`
+	Length          int    `json:"length"`
+	Precision       int    `json:"precision"`
+	CaseSensitive   bool   `json:"caseSensitive"`
+	DataFieldOffset int    `json:"dataFieldOffset"`
+	DataLength      int    `json:"dataLength"`
+}
+
+type ApiSchema struct {
+	ApiTable
+	Fields []ApiField `json:"fields"`
+}
+
+// ApiRow has values of fields: numbers, booleans, dates (RFC 3339), strings (hex for B), null,
+// links to blobs for I and NT and *** for masked fields
+type ApiRow struct {
+	Number int                    `json:"number"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+type ApiRows struct {
+	Table  string   `json:"table"`
+	Offset int      `json:"offset"`
+	Limit  int      `json:"limit"`
+	Next   int      `json:"next"` //row number to continue from, -1 at end of table
+	Rows   []ApiRow `json:"rows"`
+}
+
+type ApiBlob struct {
+	Kind       string `json:"kind"`
+	Compressed string `json:"compressed,omitempty"`
+	Length     int    `json:"length"`
+	Text       string `json:"text,omitempty"`
+	Data       []byte `json:"data,omitempty"` //base64
+}
+
+type ApiError struct {
+	Error string `json:"error"`
+}
+
+func (s *server) configureApiRouter() {
+	api := s.router.PathPrefix(apiPrefix).Subrouter()
+	api.Handle("/summary", s.apiSummary())
+	api.Handle("/tables", s.apiTables())
+	api.Handle("/tables/{table}", s.apiSchema())
+	api.Handle("/tables/{table}/rows", s.apiRows())
+	api.Handle("/tables/{table}/rows/{n}", s.apiRow())
+	api.Handle("/tables/{table}/rows/{n}/fields/{field}/blob", s.apiFieldBlob())
+	api.Handle("/blob/{blobOffset}/{chunkOffset}/{lenth}", s.apiBlob())
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, ApiError{err.Error()})
+}
+
+func ApiTableData(t onec.Table) ApiTable {
+	return ApiTable{
+		Name:           t.Name,
+		NumberOfFields: len(t.FieldsName),
+		RowLength:      t.RowLength,
+		DataOffset:     t.DataOffset,
+		BlobOffset:     t.BlobOffset,
+		IndexOffset:    t.IndexOffset,
+		RecordLock:     t.RecordLock,
+	}
+}
+
+func ApiSchemaData(t onec.Table) ApiSchema {
+	data := ApiSchema{ApiTable: ApiTableData(t), Fields: make([]ApiField, 0, len(t.FieldsName))}
+	for _, v := range t.FieldsName {
+		f := t.Fields[v]
+		data.Fields = append(data.Fields, ApiField{
+			Name:            f.Name,
+			FieldType:       f.FieldType,
+			NullExist:       f.NullExist,
+			Length:          f.Lenth,
+			Precision:       f.Precision,
+			CaseSensitive:   f.CaseSensitive,
+			DataFieldOffset: f.DataFieldOffset,
+			DataLength:      f.DataLength,
+		})
+	}
+	return data
+}
+
+// ApiRowData makes row of table with typed values, blob links start with prefix, path of api
+func ApiRowData(b *onec.BaseOnec, t onec.Table, obj onec.Object, prefix string) ApiRow {
+	row := ApiRow{Number: obj.Number, Fields: make(map[string]interface{}, len(t.FieldsName))}
+	for _, v := range t.FieldsName {
+		f := t.Fields[v]
+		switch {
+		case f.Masked:
+			row.Fields[v] = onec.MaskedValue
+		case f.FieldType == "NT" || f.FieldType == "I":
+			row.Fields[v] = nil
+			if obj.RepresentObject[v] != "" {
+				row.Fields[v] = prefix + "/" + strings.Join([]string{"tables", url.PathEscape(t.Name), "rows", strconv.Itoa(obj.Number), "fields", url.PathEscape(v), "blob"}, "/")
+			}
+		default:
+			row.Fields[v] = b.Value(obj, v, false)
+		}
+	}
+	return row
+}
+
+// ApiRowsData reads up to limit live rows starting from row number offset
+func ApiRowsData(b *onec.BaseOnec, table string, offset int, limit int, prefix string) ApiRows {
+	data := ApiRows{Table: table, Offset: offset, Limit: limit, Next: -1, Rows: []ApiRow{}}
+	count := b.RowsCount(table)
+	for n := offset; n < count; n++ {
+		obj := b.Rows(table, n, false)
+		if obj.NotExist || obj.Deleted { //empty records are holes, rows after them are read
+			continue
+		}
+		if len(data.Rows) == limit {
+			data.Next = n
+			break
+		}
+		data.Rows = append(data.Rows, ApiRowData(b, b.TableDescription[table], obj, prefix))
+	}
+	return data
+}
+
+func (s *server) apiTable(w http.ResponseWriter, r *http.Request) (onec.Table, bool) {
+	t, ok := s.base.TableDescription[mux.Vars(r)["table"]]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, errors.New("table not found"))
+	}
+	return t, ok
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.New("bad parameter " + name)
+	}
+	return n, nil
+}
+
+func (s *server) apiSummary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.base.Summary())
+	}
+}
+
+func (s *server) apiTables() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := make([]ApiTable, 0, len(s.base.TablesName))
+		for _, v := range s.base.TablesName {
+			data = append(data, ApiTableData(s.base.TableDescription[v]))
+		}
+		writeJSON(w, http.StatusOK, data)
+	}
+}
+
+func (s *server) apiSchema() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := s.apiTable(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, ApiSchemaData(t))
+	}
+}
+
+func (s *server) apiRows() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := s.apiTable(w, r)
+		if !ok {
+			return
+		}
+		offset, err := queryInt(r, "offset", 0)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		limit, err := queryInt(r, "limit", apiDefaultLimit)
+		if err != nil || limit == 0 || limit > apiMaxLimit {
+			writeJSONError(w, http.StatusBadRequest, errors.New("bad parameter limit"))
+			return
+		}
+		writeJSON(w, http.StatusOK, ApiRowsData(s.base, t.Name, offset, limit, s.prefix+apiPrefix))
+	}
+}
+
+func (s *server) apiRow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		obj, err := s.readRow(mux.Vars(r)["table"], mux.Vars(r)["n"])
+		if err != nil {
+			writeJSONError(w, errorStatus(err, http.StatusInternalServerError), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ApiRowData(s.base, *obj.Table, obj, s.prefix+apiPrefix))
+	}
+}
+
+func (s *server) apiBlob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rv, err := readBlobLink(s.base, mux.Vars(r)["blobOffset"], mux.Vars(r)["chunkOffset"], mux.Vars(r)["lenth"])
+		if err != nil {
+			writeJSONError(w, errorStatus(err, http.StatusBadRequest), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ApiBlobData(rv, "I"))
+	}
+}
+
+func (s *server) apiFieldBlob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rv, fieldType, _, _, err := s.blobByField(r)
+		if err != nil {
+			writeJSONError(w, errorStatus(err, http.StatusBadRequest), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ApiBlobData(rv, fieldType))
+	}
+}
+
+// ApiBlobData decodes blob of field type I or NT, blobs by offset are decoded as I
+func ApiBlobData(rv []byte, fieldType string) ApiBlob {
+	blob := onec.DecodeBlob(fieldType, rv)
+	data := ApiBlob{Kind: blob.Kind, Compressed: blob.Compressed, Length: len(blob.Data), Text: blob.Text}
+	if blob.Text == "" {
+		data.Data = blob.Data
+	}
+	return data
+}
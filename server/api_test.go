@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestApiRowsAfterEmptyRecord(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{})
+	testCases := []struct {
+		query   string
+		numbers []int
+		next    int
+	}{
+		{"", []int{1, 2, 4}, -1},
+		{"?limit=2", []int{1, 2}, 4},
+		{"?offset=3&limit=1", []int{4}, -1},
+		{"?offset=5", []int{}, -1},
+	}
+	for _, tc := range testCases {
+		w := get(bs, "/base/base"+apiPrefix+"/tables/_REFERENCE1/rows"+tc.query)
+		var data ApiRows
+		if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+			t.Fatal(tc.query, w.Code, w.Body.String())
+		}
+		numbers := []int{}
+		for _, v := range data.Rows {
+			numbers = append(numbers, v.Number)
+		}
+		if len(numbers) != len(tc.numbers) || data.Next != tc.next {
+			t.Errorf("%s: rows %v next %d, expected %v next %d", tc.query, numbers, data.Next, tc.numbers, tc.next)
+			continue
+		}
+		for k := range numbers {
+			if numbers[k] != tc.numbers[k] {
+				t.Errorf("%s: rows %v, expected %v", tc.query, numbers, tc.numbers)
+				break
+			}
+		}
+	}
+}
+
+// getJSON requests path of api of test base and decodes response to v, numbers are json.Number
+func getJSON(t *testing.T, h http.Handler, path string, v interface{}) int {
+	t.Helper()
+	w := get(h, "/base/base"+apiPrefix+path)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("%s: content type %s", path, ct)
+	}
+	d := json.NewDecoder(w.Body)
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		t.Fatalf("%s: status %d: %v", path, w.Code, err)
+	}
+	return w.Code
+}
+
+func TestApiSummaryAndTables(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{})
+	var summary map[string]interface{}
+	if code := getJSON(t, bs, "/summary", &summary); code != http.StatusOK || len(summary) == 0 {
+		t.Errorf("summary: status %d %v", code, summary)
+	}
+
+	var tables []ApiTable
+	if code := getJSON(t, bs, "/tables", &tables); code != http.StatusOK || len(tables) != 2 {
+		t.Fatalf("tables: status %d %v", code, tables)
+	}
+	if tables[0].Name != "V8USERS" || tables[1].Name != "_REFERENCE1" || tables[1].NumberOfFields != 4 || tables[1].BlobOffset == 0 {
+		t.Errorf("unexpected tables %v", tables)
+	}
+
+	var schema ApiSchema
+	if code := getJSON(t, bs, "/tables/_REFERENCE1", &schema); code != http.StatusOK || len(schema.Fields) != 4 {
+		t.Fatalf("schema: status %d %v", code, schema)
+	}
+	fields := map[string]ApiField{}
+	for _, v := range schema.Fields {
+		fields[v.Name] = v
+	}
+	if f := fields["_SUM"]; f.FieldType != "N" || !f.NullExist || f.Length != 10 || f.Precision != 2 {
+		t.Errorf("unexpected field _SUM %v", f)
+	}
+	if f := fields["_DESCRIPTION"]; f.FieldType != "NVC" || f.Length != 25 {
+		t.Errorf("unexpected field _DESCRIPTION %v", f)
+	}
+
+	var e ApiError
+	if code := getJSON(t, bs, "/tables/_UNKNOWN", &e); code != http.StatusNotFound || e.Error == "" {
+		t.Errorf("unknown table: status %d %v", code, e)
+	}
+}
+
+func TestApiRow(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{})
+	var row ApiRow
+	if code := getJSON(t, bs, "/tables/_REFERENCE1/rows/1", &row); code != http.StatusOK {
+		t.Fatalf("row 1: status %d", code)
+	}
+	expected := map[string]interface{}{
+		"_IDRREF":      "01000000000000000000000000000000",
+		"_DESCRIPTION": "Первый",
+		"_SUM":         json.Number("10.5"),
+		"_NOTE":        "/base/base" + apiPrefix + "/tables/_REFERENCE1/rows/1/fields/_NOTE/blob",
+	}
+	if row.Number != 1 || len(row.Fields) != len(expected) {
+		t.Fatalf("unexpected row %v", row)
+	}
+	for name, v := range expected {
+		if row.Fields[name] != v {
+			t.Errorf("%s is %#v, expected %#v", name, row.Fields[name], v)
+		}
+	}
+	if code := getJSON(t, bs, "/tables/_REFERENCE1/rows/2", &row); code != http.StatusOK || row.Fields["_SUM"] != nil || row.Fields["_NOTE"] != nil {
+		t.Errorf("NULL values of row 2: status %d %v", code, row)
+	}
+
+	for path, status := range map[string]int{
+		"/tables/_REFERENCE1/rows/0":           http.StatusNotFound, //header of free records
+		"/tables/_REFERENCE1/rows/3":           http.StatusNotFound, //empty record
+		"/tables/_REFERENCE1/rows/5":           http.StatusNotFound,
+		"/tables/_REFERENCE1/rows/-1":          http.StatusNotFound,
+		"/tables/_REFERENCE1/rows/x":           http.StatusBadRequest,
+		"/tables/_UNKNOWN/rows/1":              http.StatusNotFound,
+		"/tables/_REFERENCE1/rows?limit=0":     http.StatusBadRequest,
+		"/tables/_REFERENCE1/rows?limit=10001": http.StatusBadRequest,
+		"/tables/_REFERENCE1/rows?limit=x":     http.StatusBadRequest,
+		"/tables/_REFERENCE1/rows?offset=-1":   http.StatusBadRequest,
+		"/tables/_UNKNOWN/rows":                http.StatusNotFound,
+	} {
+		var e ApiError
+		if code := getJSON(t, bs, path, &e); code != status || e.Error == "" {
+			t.Errorf("%s: status %d %v, expected %d", path, code, e, status)
+		}
+	}
+}
+
+func TestApiBlob(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{})
+	var blob ApiBlob
+	if code := getJSON(t, bs, "/tables/_REFERENCE1/rows/1/fields/_NOTE/blob", &blob); code != http.StatusOK || blob.Kind != "text" || blob.Text != testNote {
+		t.Errorf("NT blob: status %d %v", code, blob)
+	}
+	if code := getJSON(t, bs, "/tables/V8USERS/rows/1/fields/DATA/blob", &blob); code != http.StatusOK || blob.Text != "SECRETHASH" || blob.Length != 10 {
+		t.Errorf("I blob: status %d %v", code, blob)
+	}
+	full := onectest.Open(t, testBase())
+	offset := strconv.Itoa(full.TableDescription["V8USERS"].BlobOffset)
+	if code := getJSON(t, bs, "/blob/"+offset+"/1/10", &blob); code != http.StatusOK || blob.Text != "SECRETHASH" {
+		t.Errorf("blob by offset: status %d %v", code, blob)
+	}
+
+	for path, status := range map[string]int{
+		"/tables/_REFERENCE1/rows/2/fields/_NOTE/blob":  http.StatusOK, //NULL
+		"/tables/_REFERENCE1/rows/1/fields/_SUM/blob":   http.StatusNotFound,
+		"/tables/_REFERENCE1/rows/1/fields/_OTHER/blob": http.StatusNotFound,
+		"/tables/_REFERENCE1/rows/3/fields/_NOTE/blob":  http.StatusNotFound,
+		"/blob/x/1/10":                   http.StatusBadRequest,
+		"/blob/" + offset + "/100000/10": http.StatusNotFound,
+		"/blob/1/1/10":                   http.StatusNotFound,
+	} {
+		var v map[string]interface{}
+		if code := getJSON(t, bs, path, &v); code != status {
+			t.Errorf("%s: status %d %v, expected %d", path, code, v, status)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/gorilla/mux"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// BaseIdle is the time after which file of unused base is closed, BaseIdleCheck is the period of check
+const BaseIdle = 10 * time.Minute
+const BaseIdleCheck = time.Minute
+
+// Base is a 1CD file served at /base/{ID}/, it is opened on first request and closed when idle
+type Base struct {
+	ID   string
+	Path string
+
+	mu       sync.Mutex
+	db       *os.File
+	base     *onec.BaseOnec
+	handler  http.Handler
+	used     time.Time
+	requests int
+	err      error // error of last open
+}
+
+// Bases serves several bases: picker of bases at / and routes of each base at /base/{id}/...
+type Bases struct {
+	router  *mux.Router
+	handler http.Handler // router behind authentication
+	access  Access
+	bases   map[string]*Base
+	ids     []string
+}
+
+// FindBases returns files of paths, directories are scanned for *.1CD files
+func FindBases(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil //unreadable folders are skipped
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".1CD") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New(strings.Join([]string{"No 1CD files found in", strings.Join(paths, ", ")}, " "))
+	}
+	return files, nil
+}
+
+// NewBases makes server of files, bases are not opened until they are requested
+func NewBases(files []string, access Access) *Bases {
+	bs := &Bases{router: mux.NewRouter(), access: access, bases: make(map[string]*Base, len(files))}
+	for _, v := range files {
+		id := baseID(v)
+		for n := 2; bs.bases[id] != nil; n++ {
+			id = baseID(v) + "-" + strconv.Itoa(n)
+		}
+		bs.bases[id] = &Base{ID: id, Path: v}
+		bs.ids = append(bs.ids, id)
+	}
+	sort.Strings(bs.ids)
+
+	bs.router.Handle("/", bs.picker())
+	bs.router.Handle("/diff", bs.diff())
+	bs.router.Handle("/base/{id}", bs.redirect())
+	bs.router.PathPrefix("/base/{id}/").Handler(bs.base())
+	bs.handler = access.Handler(bs.router)
+	return bs
+}
+
+// baseID is name of folder of 1Cv8.1CD or name of file without extension, characters except letters, digits, - and _ are replaced by _
+func baseID(path string) string {
+	name := filepath.Base(path)
+	if strings.EqualFold(name, "1Cv8.1CD") {
+		if dir := filepath.Base(filepath.Dir(path)); dir != "." && dir != string(filepath.Separator) {
+			name = dir
+		}
+	} else {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// baseLink is escaped path of base
+func baseLink(id string) string {
+	u := url.URL{Path: "/base/" + id + "/"}
+	return u.String()
+}
+
+func (bs *Bases) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bs.handler.ServeHTTP(w, r)
+}
+
+func (bs *Bases) picker() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(bs.ids) == 1 {
+			http.Redirect(w, r, baseLink(bs.ids[0]), http.StatusFound)
+			return
+		}
+		tmpl := PageBases()
+		tmpl.Execute(w, PageBasesData(bs))
+	}
+}
+
+// diff compares bases old and new, rows of tables t are compared when t is set
+func (bs *Bases) diff() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		data := DiffPageData{PageTitle: "diff", Bases: bs.ids, Old: q.Get("old"), New: q.Get("new"), Tables: strings.TrimSpace(q.Get("t"))}
+		tmpl := PageDiff()
+		if data.Old == "" || data.New == "" {
+			tmpl.Execute(w, data)
+			return
+		}
+		a, ok := bs.bases[data.Old]
+		b, okb := bs.bases[data.New]
+		if !ok || !okb {
+			http.NotFound(w, r)
+			return
+		}
+		for _, v := range []*Base{a, b} {
+			err := v.acquire(bs.access)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer v.release()
+		}
+		PageDiffData(&data, a.base, b.base)
+		tmpl.Execute(w, data)
+	}
+}
+
+func (bs *Bases) redirect() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, baseLink(mux.Vars(r)["id"]), http.StatusMovedPermanently)
+	}
+}
+
+func (bs *Bases) base() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, ok := bs.bases[mux.Vars(r)["id"]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		err := b.acquire(bs.access)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer b.release()
+		b.handler.ServeHTTP(w, r)
+	}
+}
+
+// acquire opens base if it is closed, base and handler are not closed until release.
+// Base is restricted by access
+func (b *Base) acquire(access Access) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handler == nil {
+		if err := b.open(access); err != nil {
+			b.err = err
+			return err
+		}
+		b.err = nil
+	}
+	b.requests++
+	b.used = time.Now()
+	return nil
+}
+
+// open opens file of base, corrupted or truncated file must give error of base and not stop server
+func (b *Base) open(access Access) (err error) {
+	db, err := os.Open(b.Path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(strings.Join([]string{"Base is corrupted:", fmt.Sprint(r)}, " "))
+		}
+		if err != nil {
+			db.Close()
+		}
+	}()
+	BaseOnec, err := onec.OpenBaseOnec(db)
+	if err != nil {
+		return err
+	}
+	router := mux.NewRouter()
+	prefix := "/base/" + b.ID
+	view := access.Restrict(BaseOnec)
+	newServer(router.PathPrefix(prefix).Subrouter(), view, BaseOnec, prefix)
+	b.db, b.base, b.handler = db, view, router
+	return nil
+}
+
+func (b *Base) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests--
+	b.used = time.Now()
+}
+
+// closeIdle closes file of base which has no requests for idle
+func (b *Base) closeIdle(idle time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handler == nil || b.requests > 0 || time.Since(b.used) < idle {
+		return
+	}
+	b.db.Close()
+	b.db, b.base, b.handler = nil, nil, nil
+}
+
+// IsOpen reports whether file of base is open
+func (b *Base) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.handler != nil
+}
+
+// Err returns error of last open of base
+func (b *Base) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// CloseIdle closes bases which are not used for idle, it is called by StartBases every BaseIdleCheck
+func (bs *Bases) CloseIdle(idle time.Duration) {
+	for _, v := range bs.ids {
+		bs.bases[v].closeIdle(idle)
+	}
+}
+
+// StartBases serves files and directories with *.1CD files
+func StartBases(paths []string, config Config) error {
+	files, err := FindBases(paths)
+	if err != nil {
+		return err
+	}
+	bs := NewBases(files, config.Access)
+	go func() {
+		for range time.Tick(BaseIdleCheck) {
+			bs.CloseIdle(BaseIdle)
+		}
+	}()
+	return listen(config, bs)
+}
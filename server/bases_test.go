@@ -0,0 +1,102 @@
+package server
+
+import (
+	"github.com/AlekseySP/onec/onec/onectest"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testBase has catalog _REFERENCE1 with rows 1, 2 and 4 (row 3 is empty), _NOTE of row 1 is text of CJK characters,
+// and users with hash of password in DATA
+func testBase() onectest.Base {
+	return onectest.Base{Tables: []onectest.Table{{
+		Name: "_REFERENCE1",
+		Fields: []onectest.Field{
+			{Name: "_IDRREF", Type: "B", Length: 16},
+			{Name: "_DESCRIPTION", Type: "NVC", Length: 25},
+			{Name: "_SUM", Type: "N", Null: true, Length: 10, Precision: 2},
+			{Name: "_NOTE", Type: "NT", Null: true},
+		},
+		Indexes: ",\n{\"_IDRREF\",\"1\",\n{\"_IDRREF\",16}\n}",
+		Rows: []onectest.Row{
+			{Values: map[string]interface{}{"_IDRREF": []byte{1}, "_DESCRIPTION": "Первый", "_SUM": "10.50", "_NOTE": testNote}},
+			{Values: map[string]interface{}{"_IDRREF": []byte{2}, "_DESCRIPTION": "Второй"}},
+			{Empty: true},
+			{Values: map[string]interface{}{"_IDRREF": []byte{4}, "_DESCRIPTION": "Четвертый", "_SUM": "-3"}},
+		},
+	}, {
+		Name: "V8USERS",
+		Fields: []onectest.Field{
+			{Name: "ID", Type: "B", Length: 16},
+			{Name: "NAME", Type: "NVC", Length: 64},
+			{Name: "DATA", Type: "I"},
+		},
+		Rows: []onectest.Row{{Values: map[string]interface{}{"ID": []byte{1}, "NAME": "Admin", "DATA": []byte("SECRETHASH")}}},
+	}}}
+}
+
+const testNote = "你好，世界。こんにちは"
+
+// writeTestBase writes base to file name in temporary folder
+func writeTestBase(t *testing.T, name string, b onectest.Base) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := b.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBasesTruncated(t *testing.T) {
+	good := writeTestBase(t, "good.1CD", testBase())
+	data, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trunc := filepath.Join(filepath.Dir(good), "trunc.1CD")
+	if err := os.WriteFile(trunc, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	bs := NewBases([]string{good, trunc}, Access{})
+
+	w := get(bs, "/base/trunc/")
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "EOF") {
+		t.Fatalf("truncated base: %d %s", w.Code, w.Body.String())
+	}
+	if bs.bases["trunc"].Err() == nil || bs.bases["trunc"].IsOpen() {
+		t.Fatal("truncated base has no error")
+	}
+
+	w = get(bs, "/base/good/")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "_REFERENCE1") {
+		t.Fatalf("good base: %d %s", w.Code, w.Body.String())
+	}
+	w = get(bs, "/")
+	if !strings.Contains(w.Body.String(), "EOF") {
+		t.Fatalf("picker does not show error of truncated base: %s", w.Body.String())
+	}
+}
+
+func TestBasesDiff(t *testing.T) {
+	changed := testBase()
+	changed.Tables[0].Rows[1] = onectest.Row{Values: map[string]interface{}{"_IDRREF": []byte{2}, "_DESCRIPTION": "Изменен"}}
+	changed.Tables = append(changed.Tables, onectest.Table{Name: "_REFERENCE2", Fields: []onectest.Field{{Name: "_CODE", Type: "NC", Length: 5}}})
+	bs := NewBases([]string{writeTestBase(t, "old.1CD", testBase()), writeTestBase(t, "new.1CD", changed)}, Access{})
+
+	w := get(bs, "/diff?old=old&new=new&t=_REFERENCE1")
+	body := w.Body.String()
+	for _, s := range []string{"added _REFERENCE2", "Второй &rarr; Изменен", `href="/base/old/table/_REFERENCE1?row=2"`, `href="/base/new/table/_REFERENCE1?row=2"`} {
+		if w.Code != http.StatusOK || !strings.Contains(body, s) {
+			t.Errorf("diff page has no %q: status %d %s", s, w.Code, body)
+		}
+	}
+	if w := get(bs, "/diff?old=old&new=old"); !strings.Contains(w.Body.String(), "no differences") {
+		t.Errorf("diff of same base: %s", w.Body.String())
+	}
+	if w := get(bs, "/diff?old=old&new=missing"); w.Code != http.StatusNotFound {
+		t.Errorf("diff with unknown base: status %d", w.Code)
+	}
+}
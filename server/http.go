@@ -1,10 +1,22 @@
 package server
 
 import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/query"
 	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type IndexTable struct {
@@ -15,6 +27,13 @@ type IndexTable struct {
 	RowLenth             string
 	DataOffset           string
 	BlobOffset           string
+	Rows                 string
+	Live                 string
+	Deleted              string
+	LastRow              string
+	DataSize             string
+	BlobSize             string
+	IndexSize            string
 	Done                 bool
 }
 
@@ -26,6 +45,8 @@ type IndexPageData struct {
 func PageIndex() *template.Template {
 
 	pageIndex := "<h1>{{.PageTitle}}</h1>\n" +
+		"<p><a href=\"summary\">summary</a> <a href=\"query\">query</a></p>\n" +
+		"<p>sort by: <a href=\"?sort=name\">name</a> <a href=\"?sort=size\">size</a> <a href=\"?sort=rows\">live rows</a> <a href=\"?sort=deleted\">deleted rows</a></p>\n" +
 		"<table border=\"1\">\n" +
 		"  {{range .Tables}}\n        " +
 		"   <tr>" +
@@ -39,6 +60,13 @@ func PageIndex() *template.Template {
 		"          <th>{{.RowLenth}}</th>\n        " +
 		"          <th>{{.DataOffset}}</th>\n        " +
 		"          <th>{{.BlobOffset}}</th>\n        " +
+		"          <th>{{.Rows}}</th>\n        " +
+		"          <th>{{.Live}}</th>\n        " +
+		"          <th>{{.Deleted}}</th>\n        " +
+		"          <th>{{.LastRow}}</th>\n        " +
+		"          <th>{{.DataSize}}</th>\n        " +
+		"          <th>{{.BlobSize}}</th>\n        " +
+		"          <th>{{.IndexSize}}</th>\n        " +
 		"   </tr>\n" +
 		"  {{end}}\n" +
 		"</table>"
@@ -54,13 +82,24 @@ func PageIndex() *template.Template {
 
 type BlobData struct {
 	PageTitle string
-	BlobData  string
+	Link      string    // path of blob relative to base
+	Size      int       // bytes of file
+	RawSize   int       // bytes as stored
+	Text      string    // text of blob
+	Hex       []HexLine // beginning of binary blob
+	Image     bool
+	Container bool
 }
 
 func PageBlob() *template.Template {
 
 	pageBlob := "<h1>{{.PageTitle}}</h1>\n" +
-		"<h2>{{.BlobData}}</h2>"
+		"<p>{{.Size}} bytes, stored {{.RawSize}} bytes: <a href=\"{{.Link}}/download\">download file</a>" +
+		" <a href=\"{{.Link}}/raw\">stored bytes</a> <a href=\"{{.Link}}/hex\">hex</a>" +
+		"{{if .Container}} <a href=\"{{.Link}}/container\">files of container</a>{{end}}</p>\n" +
+		"{{if .Image}}<p><img src=\"{{.Link}}/download\"></p>\n{{end}}" +
+		"{{if .Text}}<pre>{{.Text}}</pre>\n{{end}}" +
+		"{{if .Hex}}<pre>{{range .Hex}}{{.Offset}}  {{.Hex}}  {{.Text}}\n{{end}}</pre>\n{{end}}"
 	tmpl := template.New("blob")
 	tmpl, err := tmpl.Parse(pageBlob)
 	if err != nil {
@@ -69,31 +108,245 @@ func PageBlob() *template.Template {
 	return tmpl
 }
 
-func PageBlobData(BO *onec.BaseOnec, blobOffsetString string, chunkOffsetString string, lenthString string) (BlobData, error) {
+// HexPageSize is the number of bytes on page of hex viewer, HexLineSize - on line
+const HexPageSize = 4096
+const HexLineSize = 16
+
+// HexLine is a line of hex dump: offset, bytes in hex and printable characters
+type HexLine struct {
+	Offset string
+	Hex    string
+	Text   string
+}
+
+// HexDump formats b as lines of HexLineSize bytes, offset is the offset of b[0]
+func HexDump(b []byte, offset int) []HexLine {
+	lines := make([]HexLine, 0, (len(b)+HexLineSize-1)/HexLineSize)
+	for k := 0; k < len(b); k += HexLineSize {
+		line := b[k:onec.Min(k+HexLineSize, len(b))]
+		hexs := make([]string, HexLineSize)
+		text := make([]byte, len(line))
+		for i := range hexs {
+			hexs[i] = "  "
+			if i < len(line) {
+				hexs[i] = hex.EncodeToString(line[i : i+1])
+			}
+		}
+		for i, c := range line {
+			text[i] = '.'
+			if c >= 0x20 && c < 0x7f {
+				text[i] = c
+			}
+		}
+		lines = append(lines, HexLine{fmt.Sprintf("%08x", offset+k), strings.Join(hexs, " "), string(text)})
+	}
+	return lines
+}
+
+type HexPageData struct {
+	PageTitle string
+	Link      string
+	Lines     []HexLine
+	Page      int
+	Pages     int
+	Prev      string
+	Next      string
+	Decoded   bool
+}
+
+func PageHex() *template.Template {
+
+	pageHex := "<h1>{{.PageTitle}}</h1>\n" +
+		"<p><a href=\"{{.Link}}\">blob</a> " +
+		"{{if .Decoded}}<a href=\"{{.Link}}/hex\">stored bytes</a>{{else}}<a href=\"{{.Link}}/hex?decoded=1\">decoded file</a>{{end}}</p>\n" +
+		"<p>page {{.Page}} of {{.Pages}}{{if .Prev}} <a href=\"{{.Prev}}\">previous</a>{{end}}{{if .Next}} <a href=\"{{.Next}}\">next</a>{{end}}</p>\n" +
+		"<pre>{{range .Lines}}{{.Offset}}  {{.Hex}}  {{.Text}}\n{{end}}</pre>\n"
+	tmpl := template.New("hex")
+	tmpl, err := tmpl.Parse(pageHex)
+	if err != nil {
+		panic("err parse hex template")
+	}
+	return tmpl
+}
+
+// PageHexData shows page (from 1) of data by HexPageSize bytes
+func PageHexData(data []byte, link string, page int, decoded bool) HexPageData {
+	pages := onec.Max(1, (len(data)+HexPageSize-1)/HexPageSize)
+	page = onec.Min(onec.Max(page, 1), pages)
+	from := (page - 1) * HexPageSize
+	d := HexPageData{
+		PageTitle: "hex (" + strconv.Itoa(len(data)) + " bytes)",
+		Link:      link,
+		Lines:     HexDump(data[from:onec.Min(from+HexPageSize, len(data))], from),
+		Page:      page,
+		Pages:     pages,
+		Decoded:   decoded,
+	}
+	pageLink := func(n int) string {
+		q := url.Values{"page": {strconv.Itoa(n)}}
+		if decoded {
+			q.Set("decoded", "1")
+		}
+		return link + "/hex?" + q.Encode()
+	}
+	if page > 1 {
+		d.Prev = pageLink(page - 1)
+	}
+	if page < pages {
+		d.Next = pageLink(page + 1)
+	}
+	return d
+}
+
+type ContainerPageData struct {
+	PageTitle string
+	Link      string
+	Entries   []ContainerEntryLink
+}
+
+type ContainerEntryLink struct {
+	onec.ContainerEntry
+	Hyperlink string
+}
+
+func PageContainer() *template.Template {
+
+	pageContainer := "<h1>{{.PageTitle}}</h1>\n" +
+		"<p><a href=\"{{.Link}}\">blob</a></p>\n" +
+		"<table border=\"1\">\n" +
+		"   <tr><th>Name</th><th>Size</th><th>Created</th><th>Modified</th></tr>\n" +
+		"{{range .Entries}}" +
+		"   <tr><td><a href=\"{{.Hyperlink}}\">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.Created.Format \"2006-01-02 15:04:05\"}}</td><td>{{.Modified.Format \"2006-01-02 15:04:05\"}}</td></tr>\n" +
+		"{{end}}" +
+		"</table>"
+	tmpl := template.New("container")
+	tmpl, err := tmpl.Parse(pageContainer)
+	if err != nil {
+		panic("err parse container template")
+	}
+	return tmpl
+}
+
+func PageContainerData(c onec.Container, link string) ContainerPageData {
+	d := ContainerPageData{PageTitle: "container (" + strconv.Itoa(len(c.Entries)) + " files)", Link: link}
+	for _, v := range c.Entries {
+		d.Entries = append(d.Entries, ContainerEntryLink{v, link + "/container/" + url.PathEscape(v.Name)})
+	}
+	return d
+}
+
+// readBlobLink reads blob by offsets of link with cached page list of blob object, error of reading is returned
+func readBlobLink(BO *onec.BaseOnec, blobOffsetString string, chunkOffsetString string, lenthString string) (raw []byte, err error) {
+	defer onec.CatchReadError(&err)
 
 	blobOffset, err := strconv.Atoi(blobOffsetString)
 	if err != nil {
-		return BlobData{}, err
+		return nil, httpError{http.StatusBadRequest, err}
 	}
-	chunkOffset, err := strconv.Atoi(chunkOffsetString)
+	chunkOffset, err := strconv.ParseUint(chunkOffsetString, 10, 32)
 	if err != nil {
-		return BlobData{}, err
+		return nil, httpError{http.StatusBadRequest, err}
 	}
-	lenth, err := strconv.Atoi(lenthString)
+	lenth, err := strconv.ParseUint(lenthString, 10, 32)
 	if err != nil {
-		return BlobData{}, err
+		return nil, httpError{http.StatusBadRequest, err}
+	}
+	owner, ok := blobTable(BO, blobOffset)
+	if !ok {
+		return nil, httpError{http.StatusNotFound, errors.New("blob not found")}
+	}
+	if err = BO.CheckBlob(owner, uint32(chunkOffset), uint32(lenth)); err != nil {
+		return nil, httpError{http.StatusNotFound, err}
+	}
+	return BO.ReadBlob(owner, uint32(chunkOffset), uint32(lenth)), nil
+}
+
+// BlobFieldLink is path of blob of field of row relative to base
+func BlobFieldLink(table string, n string, field string) string {
+	return strings.Join([]string{"table", url.PathEscape(table), "row", n, "field", url.PathEscape(field), "blob"}, "/")
+}
+
+// blobTable is table of base whose blob object is at blobOffset. Offset of blob object of denied table
+// is not found, as it is not in base, blob objects of tables with masked blob fields are not found too
+func blobTable(BO *onec.BaseOnec, blobOffset int) (onec.Table, bool) {
+	if blobOffset == 0 {
+		return onec.Table{}, false
+	}
+	for _, v := range BO.TablesName {
+		t := BO.TableDescription[v]
+		if t.BlobOffset != blobOffset {
+			continue
+		}
+		for _, f := range t.Fields {
+			if f.Masked && (f.FieldType == "I" || f.FieldType == "NT") {
+				return onec.Table{}, false
+			}
+		}
+		return t, true
+	}
+	return onec.Table{}, false
+}
+
+// PageBlobData shows blob of field type I or NT stored as raw: text, image or beginning of bytes in hex, link is path of blob
+func PageBlobData(raw []byte, fieldType string, link string) BlobData {
+
+	blob := onec.DecodeBlob(fieldType, raw)
+	kind, data := blob.Kind, blob.Data
+
+	title := "blob data (" + blob.Kind
+	if blob.Compressed != "" {
+		title += ", " + blob.Compressed
 	}
-	BlockOfReplacemantBlob := onec.ReadBlockOfReplacemant(BO, blobOffset)
-	rv := onec.ReadBlobStream(BO.Db, uint64(BlockOfReplacemantBlob[uint32(chunkOffset)*onec.BlobChunkSize/BO.HeadDB.PageSize])*uint64(BO.HeadDB.PageSize)+uint64(uint32(chunkOffset)*onec.BlobChunkSize%BO.HeadDB.PageSize), BO.HeadDB.PageSize, BlockOfReplacemantBlob, nil)
-	if len(rv) < lenth {
-		lenth = len(rv)
+	if blob.Kind == onec.BlobValueStorage {
+		vs, err := onec.DecodeValueStorage(raw)
+		if err == nil {
+			title += ", " + vs.Kind + " " + vs.TypeID
+			kind, data = vs.Kind, vs.Data
+		}
 	}
-	returnValue := string(rv[:lenth])
+	title += ")"
 
-	return BlobData{"blob data:", returnValue}, nil
+	d := BlobData{PageTitle: title, Link: link, Size: len(data), RawSize: len(raw)}
+	switch kind {
+	case onec.BlobText:
+		d.Text = onec.DecodeText(data)
+		if fieldType == "NT" {
+			d.Text = blob.Text
+		}
+	case onec.BlobValueStorage:
+		d.Text = blob.Text
+	case onec.BlobPNG, onec.BlobJPEG, onec.BlobGIF, onec.BlobBMP:
+		d.Image = true
+	case onec.BlobContainer:
+		d.Container = true
+	}
+	if d.Text == "" && !d.Image {
+		d.Hex = HexDump(data[:onec.Min(len(data), HexLineSize*16)], 0)
+	}
+	return d
 }
 
-func PageIndexData(b *onec.BaseOnec) IndexPageData {
+// BlobFile returns content of blob of field type I or NT as file: value storages are unwrapped, compressed data is inflated,
+// text of NT is UTF-8. Name of file is name with extension of kind
+func BlobFile(raw []byte, fieldType string, name string) (string, string, []byte, error) {
+
+	blob := onec.DecodeBlob(fieldType, raw)
+	kind, data := blob.Kind, blob.Data
+	if fieldType == "NT" {
+		data = []byte(blob.Text)
+	}
+	if blob.Kind == onec.BlobValueStorage {
+		vs, err := onec.DecodeValueStorage(raw)
+		if err != nil {
+			return "", "", nil, err
+		}
+		kind, data = vs.Kind, vs.Data
+	}
+	return name + onec.BlobExtension(kind), onec.BlobContentType(kind), data, nil
+}
+
+// PageIndexData lists tables with statistics, sortBy is name, size, rows or deleted (descending), else order of base
+func PageIndexData(b *onec.BaseOnec, stats map[string]onec.TableStats, sortBy string) IndexPageData {
 
 	data := IndexPageData{
 		PageTitle: "1CV8.1CD page size: " + strconv.Itoa(int(b.HeadDB.PageSize)),
@@ -105,19 +358,50 @@ func PageIndexData(b *onec.BaseOnec) IndexPageData {
 			RowLenth:             "RowLenth",
 			DataOffset:           "DataOffset",
 			BlobOffset:           "BlobOffset",
+			Rows:                 "Rows",
+			Live:                 "Live",
+			Deleted:              "Deleted",
+			LastRow:              "LastRow",
+			DataSize:             "Data bytes (pages)",
+			BlobSize:             "Blob bytes (pages)",
+			IndexSize:            "Index bytes (pages)",
 		}},
 	}
 
-	for _, v := range b.TablesName {
+	names := append([]string{}, b.TablesName...)
+	sort.SliceStable(names, func(i, j int) bool {
+		x, y := stats[names[i]], stats[names[j]]
+		switch sortBy {
+		case "size":
+			return x.Size() > y.Size()
+		case "rows":
+			return x.Live > y.Live
+		case "deleted":
+			return x.Deleted > y.Deleted
+		case "name":
+			return names[i] < names[j]
+		}
+		return false
+	})
+
+	for _, v := range names {
 		ts := b.TableDescription[v]
+		st := stats[v]
 		IndexT := IndexTable{
 			Title:                ts.Name,
-			Hyperlink:            "table/" + ts.Name,
+			Hyperlink:            "table/" + url.PathEscape(ts.Name),
 			HyperlinkDescription: "tabledescription/" + ts.Name,
 			NumberOfFields:       strconv.Itoa(len(ts.FieldsName)),
 			RowLenth:             strconv.Itoa(ts.RowLength),
 			DataOffset:           strconv.Itoa(ts.DataOffset),
 			BlobOffset:           strconv.Itoa(ts.BlobOffset),
+			Rows:                 strconv.Itoa(st.Rows),
+			Live:                 strconv.Itoa(st.Live),
+			Deleted:              strconv.Itoa(st.Deleted),
+			LastRow:              strconv.Itoa(st.LastRow),
+			DataSize:             formatSize(st.DataSize, st.DataPages),
+			BlobSize:             formatSize(st.BlobSize, st.BlobPages),
+			IndexSize:            formatSize(st.IndexSize, st.IndexPages),
 			Done:                 true,
 		}
 		data.Tables = append(data.Tables, IndexT)
@@ -126,10 +410,25 @@ func PageIndexData(b *onec.BaseOnec) IndexPageData {
 	return data
 }
 
+func formatSize(size uint64, pages int) string {
+	return strconv.FormatUint(size, 10) + " (" + strconv.Itoa(pages) + ")"
+}
+
 type DataTableDescription struct {
 	PageTitle         string
 	Hyperlink         string
 	TablesDescription []TableDescription
+	Objects           []PageLink
+}
+
+// PageLink is link to page inspector
+type PageLink struct {
+	Name      string
+	Hyperlink string
+}
+
+func pageLink(name string, page uint32) PageLink {
+	return PageLink{name, "page/" + strconv.FormatUint(uint64(page), 10)}
 }
 
 type TableDescription struct {
@@ -145,8 +444,9 @@ type TableDescription struct {
 
 func PageTableDescription() *template.Template {
 
-	pageTableDescription := "<h1><a href=\"\\\">BASE </a>{{.PageTitle}}</h1>\n" +
+	pageTableDescription := "<h1><a href=\"./\">BASE </a>{{.PageTitle}}</h1>\n" +
 		" <h1><a href={{.Hyperlink}}>data</a></h1>\n        " +
+		"{{if .Objects}}<p>header pages of objects:{{range .Objects}} <a href=\"{{.Hyperlink}}\">{{.Name}}</a>{{end}}</p>\n{{end}}" +
 		"<table border=\"1\">\n" +
 		"  {{range .TablesDescription}}\n        " +
 		"   <tr>" +
@@ -175,7 +475,7 @@ func PageTableDescriptionData(b *onec.BaseOnec, table string) DataTableDescripti
 
 	data := DataTableDescription{
 		PageTitle: "table: " + b.TableDescription[table].Name,
-		Hyperlink: "/table/" + table,
+		Hyperlink: "table/" + url.PathEscape(table),
 		TablesDescription: []TableDescription{{
 			Name:            "Name",
 			FieldType:       "Field Type",
@@ -188,6 +488,16 @@ func PageTableDescriptionData(b *onec.BaseOnec, table string) DataTableDescripti
 		}},
 	}
 
+	t := b.TableDescription[table]
+	for _, o := range []struct {
+		name   string
+		offset int
+	}{{"data", t.DataOffset}, {"blob", t.BlobOffset}, {"index", t.IndexOffset}} {
+		if o.offset > 0 {
+			data.Objects = append(data.Objects, pageLink(o.name+" "+strconv.Itoa(o.offset), uint32(o.offset)))
+		}
+	}
+
 	for _, v := range b.TableDescription[table].FieldsName {
 		ts := b.TableDescription[table].Fields[v]
 		TD := TableDescription{
@@ -215,22 +525,154 @@ type FieldsN struct {
 type ValuesF struct {
 	NumberOfString string
 	Fields         []FieldsN
+	RowLink        string
+}
+
+type FilterInput struct {
+	Name  string
+	Value string
 }
 
 type TablePageData struct {
 	PageTitle            string
 	HyperLinkDescription string
+	Export               string
 	Values               []ValuesF
+	Filters              []FilterInput
+	Search               string
+	Pagination
+}
+
+// TableParams: page of table and filter from query string of request
+type TableParams struct {
+	Page   int
+	Size   int
+	Row    int // >= 0 selects page containing row number
+	Filter onec.Filter
+	Query  url.Values // filter parameters for links
+}
+
+// Pagination: pages of physical rows of table, Page is 1-based.
+// Rows and Pages are -1 when table is filtered and number of matched rows is unknown
+type Pagination struct {
+	Hyperlink string
+	Query     string
+	Rows      int
+	Page      int
+	Pages     int
+	Size      int
+	First     string
+	Prev      string
+	Next      string
+	Last      string
+}
+
+var ExportContentType = map[string]string{
+	"csv":     "text/csv; charset=utf-8",
+	"jsonl":   "application/x-ndjson; charset=utf-8",
+	"xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"parquet": "application/vnd.apache.parquet",
+}
+
+const DefaultPageSize = 100
+const MaxPageSize = 1000
+
+// ParseTableParams reads page, size, row, search q and column filters f_<field> from request
+func ParseTableParams(r *http.Request, t onec.Table) TableParams {
+	q := r.URL.Query()
+	params := TableParams{Row: -1, Query: url.Values{}}
+	params.Page, _ = strconv.Atoi(q.Get("page"))
+	params.Size, _ = strconv.Atoi(q.Get("size"))
+	if row, err := strconv.Atoi(q.Get("row")); err == nil {
+		params.Row = row
+	}
+	for _, v := range t.FieldsName {
+		expr := strings.TrimSpace(q.Get("f_" + v))
+		if expr == "" {
+			continue
+		}
+		params.Filter.Conditions = append(params.Filter.Conditions, onec.ParseCondition(v, expr))
+		params.Query.Set("f_"+v, expr)
+	}
+	if search := strings.TrimSpace(q.Get("q")); search != "" {
+		params.Filter.Search = search
+		params.Query.Set("q", search)
+	}
+	return params
 }
 
+// NewPagination makes page links for rows; row >= 0 selects page containing row number
+func NewPagination(hyperlink string, query url.Values, rows int, page int, size int, row int) Pagination {
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	size = onec.Min(size, MaxPageSize)
+	pages := -1
+	if rows >= 0 {
+		pages = onec.Max(1, (rows+size-1)/size)
+		if row >= 0 {
+			page = row/size + 1
+		}
+		page = onec.Min(page, pages)
+	}
+	page = onec.Max(page, 1)
+
+	p := Pagination{Hyperlink: hyperlink, Rows: rows, Page: page, Pages: pages, Size: size}
+	if len(query) > 0 {
+		p.Query = "&" + query.Encode()
+	}
+	if page > 1 {
+		p.First = p.link(1)
+		p.Prev = p.link(page - 1)
+	}
+	if page < pages {
+		p.Next = p.link(page + 1)
+		p.Last = p.link(pages)
+	}
+	return p
+}
+
+func (p Pagination) link(n int) string {
+	return p.Hyperlink + "?page=" + strconv.Itoa(n) + "&size=" + strconv.Itoa(p.Size) + p.Query
+}
+
+// SetMore sets link to next page when number of pages is unknown
+func (p *Pagination) SetMore(more bool) {
+	if more && p.Pages < 0 {
+		p.Next = p.link(p.Page + 1)
+	}
+}
+
+// FirstRow returns number of first physical row on page
+func (p Pagination) FirstRow() int {
+	return (p.Page - 1) * p.Size
+}
+
+const pageNavigation = "<p>{{if ge .Rows 0}}rows: {{.Rows}}, page {{.Page}} of {{.Pages}}{{else}}filtered, page {{.Page}}{{end}} \n" +
+	"  {{if .First}}<a href={{.First}}>first</a> <a href={{.Prev}}>prev</a>{{end}}\n" +
+	"  {{if .Next}}<a href={{.Next}}>next</a>{{end}} {{if .Last}}<a href={{.Last}}>last</a>{{end}}\n" +
+	"</p>\n" +
+	"{{if ge .Rows 0}}<form method=\"get\" action={{.Hyperlink}}>\n" +
+	"  <input type=\"hidden\" name=\"size\" value=\"{{.Size}}\">\n" +
+	"  row <input type=\"number\" name=\"row\" min=\"0\"> <input type=\"submit\" value=\"go\">\n" +
+	"</form>{{end}}\n"
+
 func PageTable() *template.Template {
 
-	pageTable := "<h1><a href=\"\\\">BASE </a>{{.PageTitle}}</h1>\n" +
+	pageTable := "<h1><a href=\"./\">BASE </a>{{.PageTitle}}</h1>\n" +
 		" <h1><a href={{.HyperLinkDescription}}>table description</a></h1>\n        " +
+		"<p>export: <a href={{.Export}}.csv>csv</a> <a href={{.Export}}.jsonl>jsonl</a> <a href={{.Export}}.xlsx>xlsx</a> <a href={{.Export}}.parquet>parquet</a>" +
+		" (with blobs: <a href=\"{{.Export}}.csv?blobs=1\">csv</a> <a href=\"{{.Export}}.jsonl?blobs=1\">jsonl</a> <a href=\"{{.Export}}.xlsx?blobs=1\">xlsx</a>)</p>\n" +
+		pageNavigation +
+		"<form method=\"get\" action={{.Hyperlink}}>\n" +
+		"  <input type=\"hidden\" name=\"size\" value=\"{{.Size}}\">\n" +
+		"  search <input type=\"text\" name=\"q\" value=\"{{.Search}}\"> <input type=\"submit\" value=\"filter\">\n" +
+		"  <a href={{.Hyperlink}}>reset</a> (column filter: text, =value, from..to, null, !null)\n" +
 		"<table border=\"1\">\n" +
+		"   <tr><th></th>{{range .Filters}}<th><input type=\"text\" size=\"8\" name=\"f_{{.Name}}\" value=\"{{.Value}}\"></th>{{end}}</tr>\n" +
 		"  {{range .Values}}\n        " + //rows
 		"   <tr>" +
-		"       <th>{{.NumberOfString}}</th>" +
+		"       <th>{{if .RowLink}}<a href=\"{{.RowLink}}\">{{.NumberOfString}}</a>{{else}}{{.NumberOfString}}{{end}}</th>" +
 		"      {{range .Fields}}\n        " + //columns
 		"          {{if .Blob}}\n            " +
 		"              <th><a href={{.FieldsName}}>blob ({{.LenthBlob}})</a></th>\n        " +
@@ -240,7 +682,9 @@ func PageTable() *template.Template {
 		"      {{end}}\n" +
 		"   </tr>\n" +
 		"  {{end}}\n" +
-		"</table>"
+		"</table>\n" +
+		"</form>\n" +
+		pageNavigation
 
 	tmpl := template.New("table")
 	tmpl, err := tmpl.Parse(pageTable)
@@ -251,48 +695,78 @@ func PageTable() *template.Template {
 	return tmpl
 }
 
-func PageTableData(b *onec.BaseOnec, table string) TablePageData {
+// PageTableData reads one page of table. Without filter pages are pages of physical rows,
+// with filter records are scanned and only matched ones are counted
+func PageTableData(b *onec.BaseOnec, table string, params TableParams) TablePageData {
 
 	var dataValuesF []ValuesF
-	//var dataFieldsN []FieldsN
+
+	rows := b.RowsCount(table)
+	if !params.Filter.IsEmpty() {
+		rows = -1
+	}
 
 	data := TablePageData{
 		PageTitle:            "table: " + b.TableDescription[table].Name,
-		HyperLinkDescription: "/tabledescription/" + table,
+		HyperLinkDescription: "tabledescription/" + table,
+		Export:               "export/" + table,
 		Values:               []ValuesF{},
+		Filters:              make([]FilterInput, len(b.TableDescription[table].FieldsName)),
+		Search:               params.Filter.Search,
+		Pagination:           NewPagination("table/"+url.PathEscape(table), params.Query, rows, params.Page, params.Size, params.Row),
 	}
 
 	dataFieldsN := make([]FieldsN, len(b.TableDescription[table].FieldsName))
 
 	for k, v := range b.TableDescription[table].FieldsName {
 		dataFieldsN[k] = FieldsN{false, "", v}
+		data.Filters[k] = FilterInput{v, params.Query.Get("f_" + v)}
 	}
-	dataValuesF = append(dataValuesF, ValuesF{"№", dataFieldsN})
+	dataValuesF = append(dataValuesF, ValuesF{"№", dataFieldsN, ""})
 
-	//if len(b.TableDescription[table].BlockOfReplacemant) > 0 {
-	for n := 0; n < 1000000; n++ {
+	row := func(obj onec.Object) ValuesF {
 		dataFieldsN := make([]FieldsN, len(dataFieldsN))
-		obj := b.Rows(b.TableDescription[table].Name, n, false)
-		if obj.NotExist {
-			break
-		}
-		if obj.Deleted { //do not show deleted object (lenth 5 byte{1}deleted{4}next free object)
-			continue
-		}
-		if len(b.TableDescription[table].BlockOfReplacemant) == 0 {
-			break
-		}
 		for k, v := range b.TableDescription[table].FieldsName {
-			if b.TableDescription[table].Fields[v].FieldType == "NT" || b.TableDescription[table].Fields[v].FieldType == "I" {
+			if f := b.TableDescription[table].Fields[v]; (f.FieldType == "NT" || f.FieldType == "I") && !f.Masked {
+				if obj.RepresentObject[v] == "" { //NULL
+					continue
+				}
 				lenthBlob := FindLenthBlobFromLink(obj.RepresentObject[v])
-				dataFieldsN[k] = FieldsN{true, lenthBlob, obj.RepresentObject[v]}
+				dataFieldsN[k] = FieldsN{true, lenthBlob, BlobFieldLink(table, strconv.Itoa(obj.Number), v)}
 			} else {
 				dataFieldsN[k] = FieldsN{false, "", obj.RepresentObject[v]}
 			}
 		}
-		dataValuesF = append(dataValuesF, ValuesF{strconv.Itoa(n), dataFieldsN})
+		return ValuesF{strconv.Itoa(obj.Number), dataFieldsN, RowLink(table, obj.Number)}
 	}
-	//}
+
+	if params.Filter.IsEmpty() {
+		first := data.FirstRow()
+		b.Scan(table, first, false, func(obj onec.Object) bool {
+			if obj.Number >= first+data.Size {
+				return false
+			}
+			dataValuesF = append(dataValuesF, row(obj))
+			return true
+		})
+	} else {
+		skip := data.FirstRow()
+		more := false
+		b.ScanFilter(table, 0, params.Filter, func(obj onec.Object) bool {
+			if skip > 0 {
+				skip--
+				return true
+			}
+			if len(dataValuesF) > data.Size {
+				more = true
+				return false
+			}
+			dataValuesF = append(dataValuesF, row(obj))
+			return true
+		})
+		data.SetMore(more)
+	}
+
 	data.Values = dataValuesF
 	return data
 }
@@ -301,3 +775,574 @@ func FindLenthBlobFromLink(s string) string {
 	ss := strings.Split(s, "/")
 	return ss[len(ss)-1]
 }
+
+// RowBlobText is the number of characters of text blobs shown on row page
+const RowBlobText = 4000
+
+// RowLink is path of row page relative to base
+func RowLink(table string, n int) string {
+	return "table/" + url.PathEscape(table) + "/row/" + strconv.Itoa(n)
+}
+
+type RowField struct {
+	Name      string
+	Metadata  string
+	Type      string
+	Hex       string
+	Value     string
+	Reference string    // link to referenced row
+	Blob      *BlobData // decoded blob of I and NT fields
+}
+
+type RowPageData struct {
+	PageTitle string
+	Metadata  string
+	TableLink string
+	Number    int
+	Prev      string
+	Next      string
+	Fields    []RowField
+}
+
+func PageRow() *template.Template {
+
+	pageRow := "<h1><a href=\"./\">BASE </a><a href=\"{{.TableLink}}\">{{.PageTitle}}</a>{{if .Metadata}} ({{.Metadata}}){{end}} row {{.Number}}</h1>\n" +
+		"<p>{{if .Prev}}<a href=\"{{.Prev}}\">previous</a>{{end}} {{if .Next}}<a href=\"{{.Next}}\">next</a>{{end}}</p>\n" +
+		"<table border=\"1\">\n" +
+		"   <tr><th>Field</th><th>Metadata</th><th>Type</th><th>Hex</th><th>Value</th></tr>\n" +
+		"{{range .Fields}}" +
+		"   <tr><th align=\"left\">{{.Name}}</th><td>{{.Metadata}}</td><td>{{.Type}}</td><td><code>{{.Hex}}</code></td><td>" +
+		"{{if .Reference}}<a href=\"{{.Reference}}\">{{.Value}}</a>{{else}}{{.Value}}{{end}}" +
+		"{{with .Blob}}<a href=\"{{.Link}}\">{{.PageTitle}}</a>, {{.Size}} bytes" +
+		"{{if .Image}}<br><img src=\"{{.Link}}/download\">{{end}}" +
+		"{{if .Text}}<pre>{{.Text}}</pre>{{end}}" +
+		"{{if .Hex}}<pre>{{range .Hex}}{{.Offset}}  {{.Hex}}  {{.Text}}\n{{end}}</pre>{{end}}{{end}}" +
+		"</td></tr>\n" +
+		"{{end}}" +
+		"</table>\n"
+	tmpl := template.New("row")
+	tmpl, err := tmpl.Parse(pageRow)
+	if err != nil {
+		panic("err parse row template")
+	}
+	return tmpl
+}
+
+// PageRowData shows fields of row vertically with names of metadata, blobs are decoded
+func PageRowData(b *onec.BaseOnec, meta onec.MetadataNames, obj onec.Object) RowPageData {
+	t := *obj.Table
+	data := RowPageData{
+		PageTitle: "table: " + t.Name,
+		Metadata:  meta.Name(t.Name),
+		TableLink: "table/" + url.PathEscape(t.Name) + "?row=" + strconv.Itoa(obj.Number),
+		Number:    obj.Number,
+	}
+	for n := obj.Number - 1; n >= 1; n-- {
+		if prev := b.Rows(t.Name, n, false); !prev.NotExist && !prev.Deleted {
+			data.Prev = RowLink(t.Name, n)
+			break
+		}
+	}
+	b.Scan(t.Name, obj.Number+1, false, func(next onec.Object) bool {
+		data.Next = RowLink(t.Name, next.Number)
+		return false
+	})
+
+	for _, v := range t.FieldsName {
+		f := t.Fields[v]
+		field := RowField{Name: v, Metadata: meta.Name(v), Type: onec.FieldDescription(f), Value: obj.RepresentObject[v]}
+		if !f.Masked {
+			field.Hex = hex.EncodeToString(obj.ValueObject[v])
+		}
+		switch {
+		case f.Masked:
+		case f.FieldType == "I" || f.FieldType == "NT":
+			field.Value = ""
+			raw, err := b.BlobOf(obj, v)
+			if err != nil {
+				field.Value = err.Error()
+			} else if raw == nil {
+				field.Value = "NULL"
+			} else {
+				blob := PageBlobData(raw, f.FieldType, BlobFieldLink(t.Name, strconv.Itoa(obj.Number), v))
+				if r := []rune(blob.Text); len(r) > RowBlobText {
+					blob.Text = string(r[:RowBlobText]) + "..."
+				}
+				field.Blob = &blob
+			}
+		default:
+			field.Reference = rowReference(b, meta, obj, v)
+		}
+		data.Fields = append(data.Fields, field)
+	}
+	return data
+}
+
+// rowReference is link to rows of table referenced by field: _PARENTIDRREF refers to the same table,
+// field with pair ...TREF (composite type, _RECORDERRREF and _RECORDERTREF) to table of number from DBNames
+func rowReference(b *onec.BaseOnec, meta onec.MetadataNames, obj onec.Object, name string) string {
+	f := obj.Table.Fields[name]
+	value := obj.ValueObject[name]
+	if f.NullExist && len(value) > 0 {
+		if value[0] == 0 {
+			return ""
+		}
+		value = value[1:]
+	}
+	if f.FieldType != "B" || f.Lenth != 16 || name == onec.KeyField || !strings.HasSuffix(name, "RREF") || len(value) != 16 || allZero(value) {
+		return ""
+	}
+	target := ""
+	if name == "_PARENTIDRREF" {
+		target = obj.Table.Name
+	} else if tf, ok := obj.Table.Fields[strings.TrimSuffix(name, "RREF")+"TREF"]; ok && tf.FieldType == "B" && tf.Lenth == 4 {
+		tv := obj.ValueObject[tf.Name]
+		if tf.NullExist && len(tv) > 0 {
+			tv = tv[1:]
+		}
+		if len(tv) == 4 {
+			for _, v := range meta.Tables[int(binary.BigEndian.Uint32(tv))] {
+				if t, ok := b.TableDescription[v]; ok {
+					if _, ok := t.Fields[onec.KeyField]; ok {
+						target = v
+						break
+					}
+				}
+			}
+		}
+	}
+	if target == "" {
+		return ""
+	}
+	q := url.Values{"f_" + onec.KeyField: {"=" + strings.TrimSpace(obj.RepresentObject[name])}}
+	return "table/" + url.PathEscape(target) + "?" + q.Encode()
+}
+
+func allZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type QueryColumn struct {
+	Name string
+	Type string
+}
+
+type QueryLink struct {
+	Name      string
+	Hyperlink string
+}
+
+type QueryPageData struct {
+	PageTitle string
+	Query     string
+	Error     string
+	Columns   []QueryColumn
+	Values    [][]string
+	Exports   []QueryLink
+	Pagination
+}
+
+// MaxQueryCell is the limit of characters of value shown on query page
+const MaxQueryCell = 256
+
+// Limits of queries of pages and exports: time of request and rows kept in memory for sorting, grouping and joins
+const (
+	QueryTimeout = time.Minute
+	QueryMaxRows = 100000
+)
+
+func PageQuery() *template.Template {
+
+	pageQuery := "<h1><a href=\"./\">BASE </a>{{.PageTitle}}</h1>\n" +
+		"<form method=\"get\" action=\"query\">\n" +
+		"  <textarea name=\"q\" rows=\"8\" cols=\"100\">{{.Query}}</textarea><br>\n" +
+		"  <input type=\"submit\" value=\"run\">\n" +
+		"</form>\n" +
+		"<p>SELECT ... FROM _Reference1 r LEFT JOIN _Document2 d ON d._Fld3RRef = r._IDRRef WHERE ... GROUP BY ... ORDER BY ... LIMIT n " +
+		"or ВЫБРАТЬ ПЕРВЫЕ n ... ИЗ ... ГДЕ ... СГРУППИРОВАТЬ ПО ... УПОРЯДОЧИТЬ ПО ...</p>\n" +
+		"{{if .Error}}<p><b>{{.Error}}</b></p>{{end}}\n" +
+		"{{if .Columns}}\n" +
+		"<p>export:{{range .Exports}} <a href=\"{{.Hyperlink}}\">{{.Name}}</a>{{end}}</p>\n" +
+		pageNavigation +
+		"<table border=\"1\">\n" +
+		"   <tr>{{range .Columns}}<th>{{.Name}}<br><small>{{.Type}}</small></th>{{end}}</tr>\n" +
+		"  {{range .Values}}\n" +
+		"   <tr>{{range .}}<td>{{.}}</td>{{end}}</tr>\n" +
+		"  {{end}}\n" +
+		"</table>\n" +
+		pageNavigation +
+		"{{end}}"
+
+	tmpl := template.New("query")
+	tmpl, err := tmpl.Parse(pageQuery)
+	if err != nil {
+		panic("err parse query template")
+	}
+
+	return tmpl
+}
+
+// PageQueryData runs query and reads one page of result, number of result rows is not counted
+func PageQueryData(ctx context.Context, b *onec.BaseOnec, q string, page int, size int) QueryPageData {
+	data := QueryPageData{
+		PageTitle:  "query",
+		Query:      q,
+		Pagination: NewPagination("query", url.Values{"q": {q}}, -1, page, size, -1),
+	}
+	if strings.TrimSpace(q) == "" {
+		return data
+	}
+
+	result, err := query.QueryContext(ctx, b, q, QueryMaxRows)
+	if err != nil {
+		data.Error = err.Error()
+		return data
+	}
+	for k, v := range result.Columns {
+		data.Columns = append(data.Columns, QueryColumn{v, result.Types[k]})
+	}
+	for _, format := range []string{"csv", "jsonl", "xlsx"} {
+		data.Exports = append(data.Exports, QueryLink{format, "query/export." + format + "?" + url.Values{"q": {q}}.Encode()})
+	}
+
+	skip := data.FirstRow()
+	for {
+		values, err := result.Next()
+		if err != nil {
+			data.Error = err.Error()
+			break
+		}
+		if values == nil {
+			break
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if len(data.Values) == data.Size {
+			data.SetMore(true)
+			break
+		}
+		row := make([]string, len(values))
+		for k, v := range values {
+			if data.Columns[k].Type == "" && v != nil {
+				data.Columns[k].Type = valueType(v)
+			}
+			row[k] = onec.FormatValue(v)
+			if rs := []rune(row[k]); len(rs) > MaxQueryCell {
+				row[k] = string(rs[:MaxQueryCell]) + "…"
+			}
+		}
+		data.Values = append(data.Values, row)
+	}
+	return data
+}
+
+// valueType names type of value of expression as type of 1C field
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case json.Number:
+		return "N"
+	case bool:
+		return "L"
+	case time.Time:
+		return "DT"
+	case []byte:
+		return "I"
+	}
+	return "NVC"
+}
+
+func PageSummary() *template.Template {
+
+	pageSummary := "<h1><a href=\"./\">BASE </a>summary</h1>\n" +
+		"<table border=\"1\">\n" +
+		"   <tr><th align=\"left\">Format version</th><td>{{.Version}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Page size</th><td>{{.PageSize}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Pages</th><td>{{.Pages}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Free pages</th><td>{{.FreePages}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Tables</th><td>{{.Tables}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Configuration</th><td>{{.ConfigName}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Configuration version</th><td>{{.ConfigVersion}}</td></tr>\n" +
+		"   <tr><th align=\"left\">IB version</th><td>{{.IBVersion}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Platform version required</th><td>{{.PlatformVersionRequired}}</td></tr>\n" +
+		"   <tr><th align=\"left\">Users</th><td>{{.Users}}</td></tr>\n" +
+		"</table>\n" +
+		"<h2>Largest tables</h2>\n" +
+		"<table border=\"1\">\n" +
+		"   <tr><th>Name</th><th>Size</th><th>Data</th><th>Blob</th><th>Index</th></tr>\n" +
+		"  {{range .LargestTables}}\n" +
+		"   <tr><th align=\"left\"><a href=\"table/{{.Name}}\">{{.Name}}</a></th><td>{{.Size}}</td><td>{{.DataSize}}</td><td>{{.BlobSize}}</td><td>{{.IndexSize}}</td></tr>\n" +
+		"  {{end}}\n" +
+		"</table>"
+
+	tmpl := template.New("summary")
+	tmpl, err := tmpl.Parse(pageSummary)
+	if err != nil {
+		panic("err parse summary template")
+	}
+
+	return tmpl
+}
+
+type BaseLink struct {
+	ID        string
+	Path      string
+	Hyperlink string
+	Size      string
+	Open      bool
+	Error     string
+}
+
+type BasesPageData struct {
+	PageTitle string
+	Bases     []BaseLink
+}
+
+func PageBases() *template.Template {
+
+	pageBases := "<h1>{{.PageTitle}}</h1>\n" +
+		"<p><a href=\"/diff\">compare bases</a></p>\n" +
+		"<table border=\"1\">\n" +
+		"   <tr><th>base</th><th>path</th><th>size</th><th>state</th></tr>\n" +
+		"  {{range .Bases}}\n        " +
+		"   <tr><th align=\"left\"><a href=\"{{.Hyperlink}}\">{{.ID}}</a></th><td>{{.Path}}</td><td>{{.Size}}</td>" +
+		"<td>{{if .Open}}open{{else}}closed{{end}} {{.Error}}</td></tr>\n" +
+		"  {{end}}\n" +
+		"</table>"
+
+	tmpl := template.New("bases")
+	tmpl, err := tmpl.Parse(pageBases)
+	if err != nil {
+		panic("err parse bases template")
+	}
+
+	return tmpl
+}
+
+func PageBasesData(bs *Bases) BasesPageData {
+	data := BasesPageData{PageTitle: "bases", Bases: make([]BaseLink, 0, len(bs.ids))}
+	for _, v := range bs.ids {
+		b := bs.bases[v]
+		link := BaseLink{ID: b.ID, Path: b.Path, Hyperlink: baseLink(b.ID), Open: b.IsOpen()}
+		if info, err := os.Stat(b.Path); err == nil {
+			link.Size = strconv.FormatInt(info.Size(), 10)
+		}
+		if err := b.Err(); err != nil {
+			link.Error = err.Error()
+		}
+		data.Bases = append(data.Bases, link)
+	}
+	return data
+}
+
+type DiffRow struct {
+	Kind    string
+	Key     string
+	OldLink string
+	NewLink string
+	Fields  []onec.FieldChange
+}
+
+type DiffTable struct {
+	onec.RowsDiff
+	Changes []DiffRow
+}
+
+type DiffPageData struct {
+	PageTitle string
+	Bases     []string
+	Old       string
+	New       string
+	Tables    string
+	Error     string
+	Schema    *onec.SchemaDiff
+	Rows      []DiffTable
+}
+
+func PageDiff() *template.Template {
+
+	pageDiff := "<h1><a href=\"/\">bases</a> {{.PageTitle}}</h1>\n" +
+		"<form method=\"get\" action=\"/diff\">\n" +
+		"  old <select name=\"old\">{{range .Bases}}<option{{if eq . $.Old}} selected{{end}}>{{.}}</option>{{end}}</select>\n" +
+		"  new <select name=\"new\">{{range .Bases}}<option{{if eq . $.New}} selected{{end}}>{{.}}</option>{{end}}</select>\n" +
+		"  rows of tables <input type=\"text\" size=\"40\" name=\"t\" value=\"{{.Tables}}\"> <input type=\"submit\" value=\"compare\">\n" +
+		"</form>\n" +
+		"{{if .Error}}<p>{{.Error}}</p>{{end}}\n" +
+		"{{with .Schema}}<h2>tables</h2>\n" +
+		"  {{if .IsEmpty}}<p>no differences</p>{{end}}\n" +
+		"  <ul>\n" +
+		"  {{range .RemovedTables}}<li>removed {{.}}</li>\n{{end}}" +
+		"  {{range .AddedTables}}<li>added {{.}}</li>\n{{end}}" +
+		"  {{range .ChangedTables}}<li>changed {{.Name}}: " +
+		"{{range .AddedFields}} +{{.}}{{end}}{{range .RemovedFields}} -{{.}}{{end}}" +
+		"{{range .ChangedFields}} {{.Name}} {{.Old}} &rarr; {{.New}}{{end}}{{if .IndexesChanged}} indexes{{end}}</li>\n{{end}}" +
+		"  </ul>\n" +
+		"{{end}}\n" +
+		"{{range .Rows}}<h2>{{.Table}}</h2>\n" +
+		"<p>key {{range .Key}}{{.}} {{end}}: added {{.Added}}, removed {{.Removed}}, changed {{.Changed}}{{if .Truncated}}, list is truncated{{end}}</p>\n" +
+		"<table border=\"1\">\n" +
+		"  {{range .Changes}}\n        " +
+		"   <tr><td>{{.Kind}}</td><th align=\"left\">{{.Key}}</th>" +
+		"<td>{{if .OldLink}}<a href=\"{{.OldLink}}\">old</a>{{end}}</td><td>{{if .NewLink}}<a href=\"{{.NewLink}}\">new</a>{{end}}</td>" +
+		"<td>{{range .Fields}}{{.Name}}: {{.Old}} &rarr; {{.New}}<br>{{end}}</td></tr>\n" +
+		"  {{end}}\n" +
+		"</table>\n" +
+		"{{end}}"
+
+	tmpl := template.New("diff")
+	tmpl, err := tmpl.Parse(pageDiff)
+	if err != nil {
+		panic("err parse diff template")
+	}
+
+	return tmpl
+}
+
+// PageDiffData compares base a (data.Old) and base b (data.New), rows of tables data.Tables are compared too
+func PageDiffData(data *DiffPageData, a *onec.BaseOnec, b *onec.BaseOnec) {
+	var tables []string
+	if data.Tables != "" {
+		tables = strings.Split(data.Tables, ",")
+		for k, v := range tables {
+			tables[k] = strings.TrimSpace(v)
+		}
+	}
+	d, err := onec.Diff(a, b, tables, onec.DiffRowsLimit)
+	if err != nil {
+		data.Error = err.Error()
+	}
+	data.Schema = &d.Schema
+	for _, t := range d.Rows {
+		dt := DiffTable{RowsDiff: t}
+		for _, r := range t.Rows {
+			row := DiffRow{Kind: r.Kind, Key: r.Key, Fields: r.Fields}
+			if r.OldRow >= 0 {
+				row.OldLink = baseLink(data.Old) + "table/" + url.PathEscape(t.Table) + "?row=" + strconv.Itoa(r.OldRow)
+			}
+			if r.NewRow >= 0 {
+				row.NewLink = baseLink(data.New) + "table/" + url.PathEscape(t.Table) + "?row=" + strconv.Itoa(r.NewRow)
+			}
+			dt.Changes = append(dt.Changes, row)
+		}
+		data.Rows = append(data.Rows, dt)
+	}
+}
+
+type BaseHeaderData struct {
+	Signature string
+	Version   string
+	Pages     int32
+	PageSize  uint32
+}
+
+type ObjectHeaderData struct {
+	FatLevel  byte
+	Versions  string
+	Length    uint64
+	PageTable []PageLink
+}
+
+type ChunkData struct {
+	Number uint32
+	Size   uint16
+	Next   uint32
+	Link   string // page of next chunk
+}
+
+type PhysicalPageData struct {
+	PageTitle  string
+	Offset     uint64
+	Prev       string
+	Next       string
+	Owner      string
+	OwnerIndex string
+	OwnerLink  string
+	Base       *BaseHeaderData
+	Header     *ObjectHeaderData
+	Chunks     []ChunkData
+	Lines      []HexLine
+}
+
+func PagePhysical() *template.Template {
+
+	pagePhysical := "<h1><a href=\"./\">BASE </a>{{.PageTitle}}</h1>\n" +
+		"<p>offset {{.Offset}} {{if .Prev}}<a href=\"{{.Prev}}\">previous</a>{{end}} {{if .Next}}<a href=\"{{.Next}}\">next</a>{{end}}</p>\n" +
+		"<p>owner: {{if .Owner}}{{.Owner}}, {{.OwnerIndex}}{{if .OwnerLink}} (<a href=\"{{.OwnerLink}}\">header</a>){{end}}{{else}}unknown (free page or not used){{end}}</p>\n" +
+		"{{with .Base}}<h2>header of base</h2>\n<p>signature {{.Signature}}, version {{.Version}}, pages {{.Pages}}, page size {{.PageSize}}</p>\n{{end}}" +
+		"{{with .Header}}<h2>header of object</h2>\n<p>signature 0x1CFD, fat level {{.FatLevel}}, versions {{.Versions}}, length {{.Length}}</p>\n" +
+		"<p>{{if eq .FatLevel 1}}pages of page table{{else}}data pages{{end}}:{{range .PageTable}} <a href=\"{{.Hyperlink}}\">{{.Name}}</a>{{end}}</p>\n{{end}}" +
+		"{{if .Chunks}}<h2>blob chunks</h2>\n<table border=\"1\">\n" +
+		"   <tr><th>Chunk</th><th>Size</th><th>Next chunk</th></tr>\n" +
+		"{{range .Chunks}}   <tr><td>{{.Number}}</td><td>{{.Size}}</td><td>{{if .Link}}<a href=\"{{.Link}}\">{{.Next}}</a>{{else}}{{.Next}}{{end}}</td></tr>\n{{end}}" +
+		"</table>\n{{end}}" +
+		"<h2>bytes</h2>\n<pre>{{range .Lines}}{{.Offset}}  {{.Hex}}  {{.Text}}\n{{end}}</pre>\n"
+	tmpl := template.New("page")
+	tmpl, err := tmpl.Parse(pagePhysical)
+	if err != nil {
+		panic("err parse page template")
+	}
+	return tmpl
+}
+
+// PagePhysicalData shows page n of base: header of base or object, owner of page, chunks of blob object and bytes
+func PagePhysicalData(b *onec.BaseOnec, owners map[uint32]onec.PageOwner, n uint32, page []byte) PhysicalPageData {
+	data := PhysicalPageData{
+		PageTitle: "page " + strconv.FormatUint(uint64(n), 10) + " of " + strconv.Itoa(int(b.HeadDB.NumberOfPages)),
+		Offset:    uint64(n) * uint64(len(page)),
+		Lines:     HexDump(page, 0),
+	}
+	if n > 0 {
+		data.Prev = pageLink("", n-1).Hyperlink
+	}
+	if int64(n)+1 < int64(b.HeadDB.NumberOfPages) {
+		data.Next = pageLink("", n+1).Hyperlink
+	}
+
+	if n == 0 {
+		data.Base = &BaseHeaderData{
+			Signature: string(b.HeadDB.Cd[:]),
+			Version:   fmt.Sprint(b.HeadDB.Ver),
+			Pages:     b.HeadDB.NumberOfPages,
+			PageSize:  b.HeadDB.PageSize,
+		}
+	} else if h, ok := onec.ParseObjectHeader(page); ok {
+		data.Header = &ObjectHeaderData{FatLevel: h.FatLevel, Versions: fmt.Sprint(h.Versions), Length: h.Length}
+		for _, v := range h.Pages {
+			data.Header.PageTable = append(data.Header.PageTable, pageLink(strconv.FormatUint(uint64(v), 10), v))
+		}
+	}
+
+	owner, ok := owners[n]
+	if !ok {
+		return data
+	}
+	data.Owner = owner.Object
+	switch owner.Index {
+	case onec.PageHeader:
+		data.OwnerIndex = "header page"
+	case onec.PagePageTable:
+		data.OwnerIndex = "page of page table"
+	default:
+		data.OwnerIndex = "data page " + strconv.Itoa(owner.Index)
+	}
+	if owner.Header != n {
+		data.OwnerLink = pageLink("", owner.Header).Hyperlink
+	}
+	if owner.Index >= 0 && (owner.Object == onec.OwnerRoot || strings.HasSuffix(owner.Object, " blob")) {
+		pages := onec.ReadBlockOfReplacemant(b, int(owner.Header))
+		for _, v := range onec.BlobChunks(page, owner.Index) {
+			c := ChunkData{Number: v.Number, Size: v.Size, Next: v.Next}
+			if next := int(uint64(v.Next) * uint64(onec.BlobChunkSize) / uint64(len(page))); v.Next != 0 && next < len(pages) {
+				c.Link = pageLink("", pages[next]).Hyperlink
+			}
+			data.Chunks = append(data.Chunks, c)
+		}
+	}
+	return data
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"encoding/binary"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBlobNT(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{})
+	link := "/base/base/table/_REFERENCE1/row/1/field/_NOTE/blob"
+	if w := get(bs, link); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), testNote) {
+		t.Errorf("blob page: status %d %s", w.Code, w.Body.String())
+	}
+	w := get(bs, link+"/download")
+	if w.Code != http.StatusOK || w.Body.String() != testNote || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("download: status %d %q %s", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+	if w := get(bs, "/base/base/table/_REFERENCE1/row/1"); !strings.Contains(w.Body.String(), testNote) {
+		t.Errorf("row page does not show text: %s", w.Body.String())
+	}
+}
+
+func TestNewPagination(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rows     int
+		page     int
+		size     int
+		row      int
+		expected Pagination
+	}{
+		{"first page", 45, 0, 20, -1, Pagination{Rows: 45, Page: 1, Pages: 3, Size: 20,
+			Next: "t?page=2&size=20&q=a", Last: "t?page=3&size=20&q=a"}},
+		{"middle page", 45, 2, 20, -1, Pagination{Rows: 45, Page: 2, Pages: 3, Size: 20,
+			First: "t?page=1&size=20&q=a", Prev: "t?page=1&size=20&q=a", Next: "t?page=3&size=20&q=a", Last: "t?page=3&size=20&q=a"}},
+		{"page after last", 45, 9, 20, -1, Pagination{Rows: 45, Page: 3, Pages: 3, Size: 20,
+			First: "t?page=1&size=20&q=a", Prev: "t?page=2&size=20&q=a"}},
+		{"page of row", 45, 1, 20, 20, Pagination{Rows: 45, Page: 2, Pages: 3, Size: 20,
+			First: "t?page=1&size=20&q=a", Prev: "t?page=1&size=20&q=a", Next: "t?page=3&size=20&q=a", Last: "t?page=3&size=20&q=a"}},
+		{"empty table", 0, 1, 0, -1, Pagination{Rows: 0, Page: 1, Pages: 1, Size: DefaultPageSize}},
+		{"unknown rows", -1, 4, MaxPageSize + 1, -1, Pagination{Rows: -1, Page: 4, Pages: -1, Size: MaxPageSize,
+			First: "t?page=1&size=1000&q=a", Prev: "t?page=3&size=1000&q=a"}},
+	}
+	for _, tc := range testCases {
+		p := NewPagination("t", url.Values{"q": {"a"}}, tc.rows, tc.page, tc.size, tc.row)
+		tc.expected.Hyperlink, tc.expected.Query = "t", "&q=a"
+		if !reflect.DeepEqual(p, tc.expected) {
+			t.Errorf("%s: got %+v, expected %+v", tc.name, p, tc.expected)
+		}
+	}
+}
+
+func TestParseTableParams(t *testing.T) {
+	table := onec.Table{
+		Fields: map[string]onec.Field{
+			"_DESCRIPTION": {Name: "_DESCRIPTION", FieldType: "NVC"},
+			"_SUM":         {Name: "_SUM", FieldType: "N"},
+		},
+		FieldsName: []string{"_DESCRIPTION", "_SUM"},
+	}
+	r := httptest.NewRequest(http.MethodGet, "/table/_REFERENCE1?page=2&size=50&row=7&f__SUM=+1..5+&f__DESCRIPTION=&f__OTHER=1&q=+ромашка", nil)
+	params := ParseTableParams(r, table)
+	expected := TableParams{
+		Page: 2, Size: 50, Row: 7,
+		Filter: onec.Filter{Conditions: []onec.Condition{onec.ParseCondition("_SUM", "1..5")}, Search: "ромашка"},
+		Query:  url.Values{"f__SUM": {"1..5"}, "q": {"ромашка"}},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("got %+v, expected %+v", params, expected)
+	}
+
+	params = ParseTableParams(httptest.NewRequest(http.MethodGet, "/table/_REFERENCE1?row=x", nil), table)
+	if params.Row != -1 || params.Page != 0 || len(params.Query) != 0 || len(params.Filter.Conditions) != 0 {
+		t.Errorf("without parameters: %+v", params)
+	}
+}
+
+func TestBlobFile(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{})
+	link := "/base/base/table/V8USERS/row/1/field/DATA/blob"
+
+	w := get(bs, link+"/hex")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "53 45 43 52 45 54 48 41 53 48") || !strings.Contains(w.Body.String(), "SECRETHASH") {
+		t.Errorf("hex: status %d %s", w.Code, w.Body.String())
+	}
+
+	w = get(bs, link+"/download")
+	if w.Code != http.StatusOK || w.Body.String() != "SECRETHASH" || w.Header().Get("Content-Disposition") != `attachment; filename=V8USERS_1_DATA.txt` {
+		t.Errorf("download: status %d %q %s", w.Code, w.Body.String(), w.Header().Get("Content-Disposition"))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, link+"/raw", nil)
+	r.Header.Set("Range", "bytes=2-5")
+	w = httptest.NewRecorder()
+	bs.ServeHTTP(w, r)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "CRET" || w.Header().Get("Content-Range") != "bytes 2-5/10" ||
+		w.Header().Get("Content-Disposition") != `attachment; filename=V8USERS_1_DATA.raw` {
+		t.Errorf("range: status %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestBlobReadError(t *testing.T) {
+	data, err := testBase().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// first page of blob object of V8USERS is out of base, page lists are read when base is opened
+	header := onectest.OpenBytes(t, data).TableDescription["V8USERS"].BlobOffset
+	binary.LittleEndian.PutUint32(data[header*onectest.DefaultPageSize+24:], 0xffffff)
+	b := onectest.OpenBytes(t, data)
+	router := mux.NewRouter()
+	NewServer(router, b)
+
+	for _, path := range []string{"/table/V8USERS/row/1/field/DATA/blob/raw", "/blob/" + strconv.Itoa(b.TableDescription["V8USERS"].BlobOffset) + "/1/10"} {
+		w := get(router, path)
+		if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "EOF") {
+			t.Errorf("%s: status %d %s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPageRowData(t *testing.T) {
+	id := func(n byte) []byte {
+		return []byte{n, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, n}
+	}
+	b := onectest.Open(t, onectest.Base{Tables: []onectest.Table{{
+		Name: "_REFERENCE1",
+		Fields: []onectest.Field{
+			{Name: "_IDRREF", Type: "B", Length: 16},
+			{Name: "_PARENTIDRREF", Type: "B", Length: 16},
+			{Name: "_FLD5_RTREF", Type: "B", Length: 4},
+			{Name: "_FLD5_RRREF", Type: "B", Length: 16},
+			{Name: "_NOTE", Type: "NT", Null: true},
+		},
+		Rows: []onectest.Row{
+			{Values: map[string]interface{}{"_IDRREF": id(1)}},
+			{Deleted: true},
+			{Values: map[string]interface{}{"_IDRREF": id(3), "_PARENTIDRREF": id(1), "_FLD5_RTREF": []byte{0, 0, 0, 1}, "_FLD5_RRREF": id(1), "_NOTE": "заметка"}},
+			{Values: map[string]interface{}{"_IDRREF": id(4)}},
+		},
+	}}})
+	meta := onec.MetadataNames{Names: map[string]string{"_REFERENCE1": "Номенклатура", "_FLD5": "Владелец"}, Tables: map[int][]string{1: {"_REFERENCE1"}}}
+
+	obj := b.Rows("_REFERENCE1", 3, false)
+	data := PageRowData(b, meta, obj)
+	if data.Metadata != "Номенклатура" || data.Prev != "table/_REFERENCE1/row/1" || data.Next != "table/_REFERENCE1/row/4" {
+		t.Errorf("row: %+v", data)
+	}
+	reference := "table/_REFERENCE1?" + url.Values{"f__IDRREF": {"=" + strings.TrimSpace(obj.RepresentObject["_FLD5_RRREF"])}}.Encode()
+	fields := map[string]RowField{}
+	for _, v := range data.Fields {
+		fields[v.Name] = v
+	}
+	if f := fields["_PARENTIDRREF"]; f.Reference != reference {
+		t.Errorf("parent: %+v, expected reference %s", f, reference)
+	}
+	if f := fields["_FLD5_RRREF"]; f.Reference != reference || f.Metadata != "Владелец" {
+		t.Errorf("composite reference: %+v, expected reference %s", f, reference)
+	}
+	if f := fields["_IDRREF"]; f.Reference != "" {
+		t.Errorf("key is a reference: %+v", f)
+	}
+	if f := fields["_NOTE"]; f.Blob == nil || f.Blob.Text != "заметка" || f.Blob.Link != "table/_REFERENCE1/row/3/field/_NOTE/blob" {
+		t.Errorf("blob: %+v", f)
+	}
+
+	first := PageRowData(b, meta, b.Rows("_REFERENCE1", 1, false))
+	for _, v := range first.Fields {
+		fields[v.Name] = v
+	}
+	if first.Prev != "" || first.Next != "table/_REFERENCE1/row/3" || fields["_PARENTIDRREF"].Reference != "" || fields["_NOTE"].Value != "NULL" {
+		t.Errorf("first row: %+v", first)
+	}
+}
@@ -1,21 +1,50 @@
 package server
 
 import (
-	"fmt"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/AlekseySP/onec/export"
 	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/query"
 	"github.com/gorilla/mux"
+	"html/template"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type server struct {
-	router *mux.Router
-	base   *onec.BaseOnec
+	router  *mux.Router
+	base    *onec.BaseOnec
+	full    *onec.BaseOnec             // base without restrictions of access, owners of pages are read from it
+	prefix  string                     // path of base: "" or /base/{id}, pages link relative to it
+	stats   map[string]onec.TableStats // read on first request of index page
+	statsMu sync.Mutex
+
+	metadata   *onec.MetadataNames // read on first request of row page
+	metadataMu sync.Mutex
+	owners     map[uint32]onec.PageOwner // read on first request of page inspector
+	ownersMu   sync.Mutex
 }
 
 func NewServer(router *mux.Router, b *onec.BaseOnec) *server {
+	return newServer(router, b, b, "")
+}
+
+// newServer configures routes of view of base full on router which matches paths with prefix
+func newServer(router *mux.Router, b *onec.BaseOnec, full *onec.BaseOnec, prefix string) *server {
 	s := &server{
 		router: router,
 		base:   b,
+		full:   full,
+		prefix: prefix,
 	}
 
 	s.configureRouter()
@@ -24,58 +53,422 @@ func NewServer(router *mux.Router, b *onec.BaseOnec) *server {
 }
 
 func (s *server) configureRouter() {
+	s.configureApiRouter()
 	s.router.Handle("/", s.index())
 	s.router.Handle("/table/{table}", s.table())
+	s.router.Handle("/table/{table}/row/{n}", s.row())
 	s.router.Handle("/tabledescription/{table}", s.tabledescription())
-	s.router.Handle("/blob/{blobOffset}/{chunkOffset}/{lenth}", s.blob())
+	s.router.Handle("/export/{table}.{format:csv|jsonl|xlsx|parquet}", s.export())
+	s.router.Handle("/summary", s.summary())
+	s.router.Handle("/page/{n}", s.page())
+	s.router.Handle("/query", s.query())
+	s.router.Handle("/query/export.{format:csv|jsonl|xlsx}", s.queryExport())
+	s.handleBlob("/blob/{blobOffset}/{chunkOffset}/{lenth}", s.blobByOffset)
+	s.handleBlob("/table/{table}/row/{n}/field/{field}/blob", s.blobByField)
 }
 
-func (s *server) blob() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		blobOffset := mux.Vars(r)["blobOffset"]
-		chunkOffset := mux.Vars(r)["chunkOffset"]
-		lenth := mux.Vars(r)["lenth"]
+// httpError is an error of request with status code of response
+type httpError struct {
+	code int
+	err  error
+}
+
+func (e httpError) Error() string {
+	return e.err.Error()
+}
+
+// errorStatus is status code of httpError, else code
+func errorStatus(err error, code int) int {
+	var he httpError
+	if errors.As(err, &he) {
+		return he.code
+	}
+	return code
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+}
+
+// readRow reads live row n of table
+func (s *server) readRow(table string, n string) (onec.Object, error) {
+	if _, ok := s.base.TableDescription[table]; !ok {
+		return onec.Object{}, httpError{http.StatusNotFound, errors.New(strings.Join([]string{"Table not found", table}, " "))}
+	}
+	number, err := strconv.Atoi(n)
+	if err != nil {
+		return onec.Object{}, httpError{http.StatusBadRequest, err}
+	}
+	if number < 1 || number >= s.base.RowsCount(table) {
+		return onec.Object{}, httpError{http.StatusNotFound, errors.New(strings.Join([]string{"Row", n, "is out of table", table}, " "))}
+	}
+	obj := s.base.Rows(table, number, false)
+	if obj.NotExist || obj.Deleted || obj.Table == nil {
+		return onec.Object{}, httpError{http.StatusNotFound, errors.New(strings.Join([]string{"Row", n, "of table", table, "is deleted or empty"}, " "))}
+	}
+	return obj, nil
+}
+
+// blobLoader reads stored bytes of blob of request and type of its field (I or NT), link is path of blob relative to base
+// and name is name of file without extension
+type blobLoader func(r *http.Request) (raw []byte, fieldType string, link string, name string, err error)
 
+// handleBlob configures pages of blob at path: blob, decoded file, stored bytes, hex viewer and files of container
+func (s *server) handleBlob(path string, load blobLoader) {
+	s.router.Handle(path, s.blob(load))
+	s.router.Handle(path+"/download", s.blobDownload(load))
+	s.router.Handle(path+"/raw", s.blobRaw(load))
+	s.router.Handle(path+"/hex", s.blobHex(load))
+	s.router.Handle(path+"/container", s.blobContainer(load))
+	s.router.Handle(path+"/container/{entry}", s.blobContainerEntry(load))
+}
+
+// blobByOffset reads blob by offsets of link, field of blob is not known and it is decoded as I
+func (s *server) blobByOffset(r *http.Request) ([]byte, string, string, string, error) {
+	blobOffset := mux.Vars(r)["blobOffset"]
+	chunkOffset := mux.Vars(r)["chunkOffset"]
+	lenth := mux.Vars(r)["lenth"]
+
+	raw, err := readBlobLink(s.base, blobOffset, chunkOffset, lenth)
+	link := strings.Join([]string{"blob", blobOffset, chunkOffset, lenth}, "/")
+	return raw, "I", link, "blob_" + blobOffset + "_" + chunkOffset, err
+}
+
+// blobByField reads blob of field of row, offsets of blob are taken from the row, error of reading is returned
+func (s *server) blobByField(r *http.Request) (raw []byte, fieldType string, link string, name string, err error) {
+	defer onec.CatchReadError(&err)
+	table := mux.Vars(r)["table"]
+	n := mux.Vars(r)["n"]
+	field := mux.Vars(r)["field"]
+
+	link = BlobFieldLink(table, n, field)
+	obj, err := s.readRow(table, n)
+	if err != nil {
+		return nil, "", link, "", err
+	}
+	raw, err = s.base.BlobOf(obj, field)
+	if err != nil {
+		return nil, "", link, "", httpError{http.StatusNotFound, err}
+	}
+	return raw, obj.Table.Fields[field].FieldType, link, table + "_" + n + "_" + field, nil
+}
+
+func (s *server) blob(load blobLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, fieldType, link, _, err := load(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
 		tmpl := PageBlob()
-		data, err := PageBlobData(s.base, blobOffset, chunkOffset, lenth)
+		s.render(w, tmpl, PageBlobData(raw, fieldType, link))
+	}
+}
+
+func (s *server) blobDownload(load blobLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, fieldType, _, name, err := load(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		name, contentType, data, err := BlobFile(raw, fieldType, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		serveFile(w, r, name, contentType, data)
+	}
+}
+
+// blobRaw sends blob as it is stored in base
+func (s *server) blobRaw(load blobLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, _, _, name, err := load(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		serveFile(w, r, name+".raw", "application/octet-stream", raw)
+	}
+}
+
+// blobHex shows stored bytes or with decoded=1 decoded file by pages
+func (s *server) blobHex(load blobLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, fieldType, link, name, err := load(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		data := raw
+		decoded := r.URL.Query().Get("decoded") != ""
+		if decoded {
+			if _, _, data, err = BlobFile(raw, fieldType, name); err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		tmpl := PageHex()
+		s.render(w, tmpl, PageHexData(data, link, page, decoded))
+	}
+}
+
+// blobContainer reads decoded file of blob as 1C container
+func blobContainer(w http.ResponseWriter, r *http.Request, load blobLoader) (onec.Container, string, bool) {
+	raw, fieldType, link, name, err := load(r)
+	if err != nil {
+		writeError(w, err)
+		return onec.Container{}, "", false
+	}
+	_, _, data, err := BlobFile(raw, fieldType, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return onec.Container{}, "", false
+	}
+	c, err := onec.ParseContainer(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return onec.Container{}, "", false
+	}
+	return c, link, true
+}
+
+func (s *server) blobContainer(load blobLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, link, ok := blobContainer(w, r, load)
+		if !ok {
+			return
+		}
+		tmpl := PageContainer()
+		s.render(w, tmpl, PageContainerData(c, link))
+	}
+}
+
+func (s *server) blobContainerEntry(load blobLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, _, ok := blobContainer(w, r, load)
+		if !ok {
+			return
+		}
+		name := mux.Vars(r)["entry"]
+		data, err := c.File(name)
 		if err != nil {
-			fmt.Println("err blob hf")
+			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
-		tmpl.Execute(w, data)
+		serveFile(w, r, name, onec.BlobContentType(onec.SniffBlob(data)), data)
+	}
+}
+
+// serveFile sends data as attachment name, range requests are supported
+func serveFile(w http.ResponseWriter, r *http.Request, name string, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+func (s *server) export() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		table := mux.Vars(r)["table"]
+		format := mux.Vars(r)["format"]
+		if _, ok := s.base.TableDescription[table]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", ExportContentType[format])
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+table+"."+format+"\"")
+		err := export.Table(w, s.base, table, format, export.Options{Blobs: r.URL.Query().Get("blobs") != ""})
+		if err != nil {
+			log.Println("export of table", table, "to", format, "failed:", err) //headers are sent, error is only logged
+		}
+	}
+}
+
+func (s *server) summary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tmpl := PageSummary()
+		s.render(w, tmpl, s.base.Summary())
+	}
+}
+
+func (s *server) query() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		ctx, cancel := context.WithTimeout(r.Context(), QueryTimeout)
+		defer cancel()
+		tmpl := PageQuery()
+		data := PageQueryData(ctx, s.base, r.URL.Query().Get("q"), page, size)
+		s.render(w, tmpl, data)
+	}
+}
+
+func (s *server) queryExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := mux.Vars(r)["format"]
+		ctx, cancel := context.WithTimeout(r.Context(), QueryTimeout)
+		defer cancel()
+		result, err := query.QueryContext(ctx, s.base, r.URL.Query().Get("q"), QueryMaxRows)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", ExportContentType[format])
+		w.Header().Set("Content-Disposition", "attachment; filename=\"query."+format+"\"")
+		err = export.Result(w, "query", result.Columns, format, result.Next)
+		if err != nil {
+			log.Println("export of query", strconv.Quote(r.URL.Query().Get("q")), "to", format, "failed:", err)
+		}
 	}
 }
 
 func (s *server) index() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tmpl := PageIndex()
-		data := PageIndexData(s.base)
-		tmpl.Execute(w, data)
+		data := PageIndexData(s.base, s.tableStats(), r.URL.Query().Get("sort"))
+		s.render(w, tmpl, data)
 	}
 }
 
+// tableStats reads statistics of all tables once, base is opened read-only and they do not change
+func (s *server) tableStats() map[string]onec.TableStats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if s.stats == nil {
+		stats := make(map[string]onec.TableStats, len(s.base.TablesName))
+		for _, v := range s.base.TablesName {
+			stats[v] = s.base.TableStats(v)
+		}
+		s.stats = stats
+	}
+	return s.stats
+}
+
 func (s *server) table() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		table := mux.Vars(r)["table"]
+		if _, ok := s.base.TableDescription[table]; !ok {
+			http.NotFound(w, r)
+			return
+		}
 		tmpl := PageTable()
-		data := PageTableData(s.base, table)
-		tmpl.Execute(w, data)
+		data := PageTableData(s.base, table, ParseTableParams(r, s.base.TableDescription[table]))
+		s.render(w, tmpl, data)
 	}
 }
 
+func (s *server) row() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		obj, err := s.readRow(mux.Vars(r)["table"], mux.Vars(r)["n"])
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		tmpl := PageRow()
+		s.render(w, tmpl, PageRowData(s.base, s.metadataNames(), obj))
+	}
+}
+
+func (s *server) page() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.ParseUint(mux.Vars(r)["n"], 10, 32)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !s.pageAllowed(uint32(n)) { //before reading, missing pages must not differ from hidden ones
+			http.Error(w, "page "+strconv.FormatUint(n, 10)+" is hidden by access restrictions", http.StatusForbidden)
+			return
+		}
+		page, err := s.full.ReadPage(uint32(n))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		tmpl := PagePhysical()
+		s.render(w, tmpl, PagePhysicalData(s.base, s.pageOwners(), uint32(n), page))
+	}
+}
+
+// pageOwners reads owners of pages once
+func (s *server) pageOwners() map[uint32]onec.PageOwner {
+	s.ownersMu.Lock()
+	defer s.ownersMu.Unlock()
+	if s.owners == nil {
+		s.owners = s.full.PageOwners()
+	}
+	return s.owners
+}
+
+// pageAllowed is true when base is not restricted, for view of base page must be header of base or page of object
+// of allowed table without masked fields, pages of blob are allowed as blobTable
+func (s *server) pageAllowed(n uint32) bool {
+	if s.base == s.full {
+		return true
+	}
+	owner, ok := s.pageOwners()[n]
+	switch {
+	case !ok || (owner.Table == "" && owner.Object != onec.OwnerBaseHeader): //root object has descriptions of all tables
+		return false
+	case owner.Table == "":
+		return true
+	case owner.Object == owner.Table+" blob":
+		_, ok := blobTable(s.base, int(owner.Header))
+		return ok
+	}
+	t, ok := s.base.TableDescription[owner.Table]
+	if !ok {
+		return false
+	}
+	for _, f := range t.Fields {
+		if f.Masked {
+			return false
+		}
+	}
+	return true
+}
+
+// metadataNames reads names of metadata once
+func (s *server) metadataNames() onec.MetadataNames {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	if s.metadata == nil {
+		m := s.base.MetadataNames()
+		s.metadata = &m
+	}
+	return *s.metadata
+}
+
 func (s *server) tabledescription() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		table := mux.Vars(r)["table"]
+		if _, ok := s.base.TableDescription[table]; !ok {
+			http.NotFound(w, r)
+			return
+		}
 		tmpl := PageTableDescription()
 		data := PageTableDescriptionData(s.base, table)
-		tmpl.Execute(w, data)
+		s.render(w, tmpl, data)
+	}
+}
+
+// render writes page with <base> of prefix, links of pages are relative
+func (s *server) render(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	base := &url.URL{Path: s.prefix + "/"}
+	io.WriteString(w, "<base href=\""+template.HTMLEscapeString(base.String())+"\">\n")
+	if s.prefix != "" {
+		io.WriteString(w, "<p><a href=\"/\">all bases</a></p>\n")
 	}
+	tmpl.Execute(w, data)
 }
 
-func Start(b *onec.BaseOnec, port string) error {
+// Start serves base with authentication, access restrictions and TLS of config
+func Start(b *onec.BaseOnec, config Config) error {
 	router := mux.NewRouter()
-	server := NewServer(router, b)
-	return http.ListenAndServe(":"+port, server)
+	newServer(router, config.Access.Restrict(b), b, "")
+	return listen(config, config.Access.Handler(router))
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
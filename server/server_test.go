@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"github.com/AlekseySP/onec/onec"
+	"github.com/AlekseySP/onec/onec/onectest"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// get requests path of handler and returns response
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestPageAccess(t *testing.T) {
+	path := writeTestBase(t, "base.1CD", testBase())
+	full := onectest.Open(t, testBase())
+	pages := map[string]uint32{"header of base": 0, "root": uint32(onec.RootObjectOffset), "free pages": onec.FreePagesOffset}
+	for _, v := range []string{"_REFERENCE1", "V8USERS"} {
+		pages[v+" data"] = uint32(full.TableDescription[v].DataOffset)
+	}
+	users := full.TableDescription["V8USERS"]
+	for n, owner := range full.PageOwners() {
+		if owner.Header == uint32(users.BlobOffset) && owner.Index == 0 {
+			pages["V8USERS blob"] = n
+		}
+	}
+	if len(pages) != 6 {
+		t.Fatalf("unexpected pages %v", pages)
+	}
+
+	testCases := []struct {
+		name    string
+		access  Access
+		allowed map[string]bool
+	}{
+		{"no restrictions", Access{}, map[string]bool{"header of base": true, "root": true, "free pages": true, "_REFERENCE1 data": true, "V8USERS data": true, "V8USERS blob": true}},
+		{"deny", Access{Deny: []string{"V8USERS"}}, map[string]bool{"header of base": true, "_REFERENCE1 data": true}},
+		{"mask", Access{Mask: []string{DefaultMask}}, map[string]bool{"header of base": true, "_REFERENCE1 data": true}},
+		{"allow", Access{Allow: []string{"V8*"}}, map[string]bool{"header of base": true, "V8USERS data": true, "V8USERS blob": true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bs := NewBases([]string{path}, tc.access)
+			for name, n := range pages {
+				w := get(bs, "/base/base/page/"+strconv.Itoa(int(n)))
+				if expected := map[bool]int{true: http.StatusOK, false: http.StatusForbidden}[tc.allowed[name]]; w.Code != expected {
+					t.Errorf("page %d (%s): status %d, expected %d", n, name, w.Code, expected)
+				}
+			}
+			missing := http.StatusNotFound
+			if len(tc.allowed) != len(pages) { //restricted view does not tell which pages exist
+				missing = http.StatusForbidden
+			}
+			if w := get(bs, "/base/base/page/100000"); w.Code != missing {
+				t.Errorf("page out of base: status %d, expected %d", w.Code, missing)
+			}
+		})
+	}
+}
+
+// TestConcurrentRequests requests rows and blobs of one base in parallel, it is run with -race
+func TestConcurrentRequests(t *testing.T) {
+	bs := NewBases([]string{writeTestBase(t, "base.1CD", testBase())}, Access{})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, path := range []string{"/table/V8USERS/row/1/field/DATA/blob", apiPrefix + "/tables/_REFERENCE1/rows", "/table/_REFERENCE1/row/2", "/"} {
+				if w := get(bs, "/base/base"+path); w.Code != http.StatusOK {
+					t.Errorf("%s: status %d", path, w.Code)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPageQueryDataContext(t *testing.T) {
+	b := onectest.Open(t, testBase())
+	data := PageQueryData(context.Background(), b, "SELECT _DESCRIPTION, _NOTE FROM _REFERENCE1 ORDER BY _DESCRIPTION", 1, 10)
+	if data.Error != "" || len(data.Values) == 0 {
+		t.Errorf("query: error %q, rows %v", data.Error, data.Values)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	data = PageQueryData(ctx, b, "SELECT _DESCRIPTION, _NOTE FROM _REFERENCE1 ORDER BY _DESCRIPTION", 1, 10)
+	if data.Error != context.Canceled.Error() {
+		t.Errorf("canceled query: error %q", data.Error)
+	}
+}
+
+func TestPageIndexDataSort(t *testing.T) {
+	b := onectest.Open(t, testBase())
+	stats := map[string]onec.TableStats{}
+	for _, v := range b.TablesName {
+		stats[v] = b.TableStats(v)
+	}
+	if ts := stats["_REFERENCE1"]; ts.Live != 3 || ts.Empty != 1 || ts.LastRow != 4 {
+		t.Errorf("stats of _REFERENCE1: %+v", ts)
+	}
+	testCases := []struct {
+		sortBy   string
+		expected string
+	}{
+		{"name", "V8USERS"},
+		{"rows", "_REFERENCE1"},
+		{"size", "_REFERENCE1"},
+	}
+	for _, tc := range testCases {
+		data := PageIndexData(b, stats, tc.sortBy)
+		if len(data.Tables) != 3 || data.Tables[1].Title != tc.expected || data.Tables[1].Live != strconv.Itoa(stats[tc.expected].Live) {
+			t.Errorf("sort by %s: %+v", tc.sortBy, data.Tables)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// SelfSignedValidity is the validity period of generated certificate
+const SelfSignedValidity = 365 * 24 * time.Hour
+
+// TLS of web server: certificate and key files or self-signed certificate.
+// With SelfSigned and files the certificate is generated once and saved to files, without files it lives in memory.
+// RedirectAddr is address of plain HTTP server which redirects to HTTPS (serve sets port 80 unless -no-redirect),
+// without it only HTTPS is served
+type TLS struct {
+	CertFile     string
+	KeyFile      string
+	SelfSigned   bool
+	RedirectAddr string
+}
+
+// Enabled is true when server should use HTTPS
+func (t TLS) Enabled() bool {
+	return t.SelfSigned || t.CertFile != "" || t.KeyFile != ""
+}
+
+// Certificate loads or generates certificate
+func (t TLS) Certificate() (tls.Certificate, error) {
+	if t.SelfSigned {
+		if t.CertFile == "" || t.KeyFile == "" {
+			certPEM, keyPEM, err := SelfSignedCertificate()
+			if err != nil {
+				return tls.Certificate{}, err
+			}
+			return tls.X509KeyPair(certPEM, keyPEM)
+		}
+		if _, err := os.Stat(t.CertFile); errors.Is(err, os.ErrNotExist) {
+			certPEM, keyPEM, err := SelfSignedCertificate()
+			if err != nil {
+				return tls.Certificate{}, err
+			}
+			if err = os.WriteFile(t.KeyFile, keyPEM, 0600); err != nil {
+				return tls.Certificate{}, err
+			}
+			if err = os.WriteFile(t.CertFile, certPEM, 0644); err != nil {
+				return tls.Certificate{}, err
+			}
+		}
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return tls.Certificate{}, errors.New("Both certificate and key files are required")
+	}
+	return tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+}
+
+// SelfSignedCertificate generates ECDSA certificate for localhost, name of computer and its addresses, PEM encoded
+func SelfSignedCertificate() ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"onec"}, CommonName: "onec"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(SelfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		template.DNSNames = append(template.DNSNames, host)
+	}
+	template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, v := range addrs {
+			if ipnet, ok := v.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+				template.IPAddresses = append(template.IPAddresses, ipnet.IP)
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// RedirectHandler redirects requests to the same path on HTTPS server with port of addr
+func RedirectHandler(addr string) http.Handler {
+	_, port, _ := net.SplitHostPort(addr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// listen serves handler on config.Addr, with TLS over HTTPS and redirect from RedirectAddr.
+// Error of redirect server (port 80 is busy or needs privileges) is logged and HTTPS is still served
+func listen(config Config, handler http.Handler) error {
+	if !config.TLS.Enabled() {
+		return http.ListenAndServe(config.Addr, handler)
+	}
+	cert, err := config.TLS.Certificate()
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:      config.Addr,
+		Handler:   handler,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	if config.TLS.RedirectAddr != "" {
+		go func() {
+			err := http.ListenAndServe(config.TLS.RedirectAddr, RedirectHandler(config.Addr))
+			log.Println("Redirect to HTTPS on", config.TLS.RedirectAddr, "is not served:", err)
+		}()
+	}
+	return srv.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	testCases := []struct {
+		addr     string
+		host     string
+		path     string
+		expected string
+	}{
+		{":443", "example.com", "/base/a/table/_REFERENCE1?page=2", "https://example.com/base/a/table/_REFERENCE1?page=2"},
+		{":443", "example.com:80", "/", "https://example.com/"},
+		{"127.0.0.1:8443", "localhost:8080", "/summary", "https://localhost:8443/summary"},
+		{":8443", "[::1]:80", "/query?q=SELECT+1", "https://[::1]:8443/query?q=SELECT+1"},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		r.Host = tc.host
+		w := httptest.NewRecorder()
+		RedirectHandler(tc.addr).ServeHTTP(w, r)
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != tc.expected {
+			t.Errorf("%s %s%s: status %d location %q, expected %q", tc.addr, tc.host, tc.path, w.Code, w.Header().Get("Location"), tc.expected)
+		}
+	}
+}
+
+func TestSelfSignedCertificate(t *testing.T) {
+	certPEM, keyPEM, err := SelfSignedCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = leaf.VerifyHostname("localhost"); err != nil {
+		t.Error(err)
+	}
+	if err = leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Error(err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+		t.Errorf("body %q", body)
+	}
+}
+
+func TestCertificateFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := TLS{SelfSigned: true, CertFile: filepath.Join(dir, "server.crt"), KeyFile: filepath.Join(dir, "server.key")}
+	first, err := config.Certificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(config.KeyFile); err != nil {
+		t.Fatal(err)
+	}
+	// saved certificate is loaded, not generated again
+	second, err := config.Certificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first.Certificate[0]) != string(second.Certificate[0]) {
+		t.Error("certificate is generated again")
+	}
+	if _, err = (TLS{CertFile: config.CertFile}).Certificate(); err == nil {
+		t.Error("no error without key file")
+	}
+}